@@ -16,6 +16,8 @@ limitations under the License.
 
 package augmentedtree
 
+import "sort"
+
 func intervalOverlaps(n *node, low, high int64, interval Interval, maxDimension uint64) bool {
 	if !overlaps(n.interval.HighAtDimension(1), high, n.interval.LowAtDimension(1), low) {
 		return false
@@ -207,6 +209,114 @@ func (tree *tree) Add(intervals ...Interval) {
 	}
 }
 
+// AddAll will add the provided intervals to this tree as a batch.  If
+// the batch is at least as large as the tree, the existing and new
+// intervals are sorted and a balanced tree is built directly from
+// them, which is O(n log n) for the sort and O(n) for the build with
+// no rebalancing.  Smaller batches are added in sorted order.
+func (tree *tree) AddAll(intervals ...Interval) {
+	if len(intervals) == 0 {
+		return
+	}
+
+	sorted := make(Intervals, 0, uint64(len(intervals))+tree.number)
+	sorted = append(sorted, intervals...)
+	if uint64(len(intervals)) < tree.number || hasDuplicateIDs(sorted) {
+		sortIntervals(sorted)
+		tree.Add(sorted...)
+		return
+	}
+
+	if tree.root != nil {
+		sorted = tree.root.appendIntervals(sorted)
+		if hasDuplicateIDs(sorted) {
+			sortIntervals(sorted[:len(intervals)])
+			tree.Add(sorted[:len(intervals)]...)
+			return
+		}
+	}
+
+	sortIntervals(sorted)
+	blackHeight := 0
+	for n := len(sorted) + 1; n > 1; n >>= 1 {
+		blackHeight++
+	}
+
+	tree.root = buildBalanced(sorted, 0, blackHeight)
+	tree.root.red = false
+	tree.root.adjustRanges()
+	tree.number = uint64(len(sorted))
+}
+
+// appendIntervals appends the intervals in the subtree rooted at n
+// to the provided list.
+func (n *node) appendIntervals(ivs Intervals) Intervals {
+	for i := 0; i <= 1; i++ {
+		if n.children[i] != nil {
+			ivs = n.children[i].appendIntervals(ivs)
+		}
+	}
+
+	return append(ivs, n.interval)
+}
+
+// buildBalanced builds a balanced subtree from the provided sorted
+// intervals.  Every path from the root to a leaf has either blackHeight
+// or blackHeight+1 nodes, the nodes on the deepest, incomplete level
+// are colored red so the red-black properties hold.  Ranges must be
+// adjusted after the tree is built.
+func buildBalanced(ivs Intervals, depth, blackHeight int) *node {
+	if len(ivs) == 0 {
+		return nil
+	}
+
+	mid := len(ivs) / 2
+	iv := ivs[mid]
+	n := newNode(iv, iv.LowAtDimension(1), iv.HighAtDimension(1), 1)
+	n.red = depth >= blackHeight
+	n.children[0] = buildBalanced(ivs[:mid], depth+1, blackHeight)
+	n.children[1] = buildBalanced(ivs[mid+1:], depth+1, blackHeight)
+	return n
+}
+
+// sortIntervals sorts the provided intervals in the order in which
+// they are placed in the tree, by low value and then by ID.
+func sortIntervals(ivs Intervals) {
+	sort.Sort(intervalsByLow(ivs))
+}
+
+type intervalsByLow Intervals
+
+func (ivs intervalsByLow) Len() int {
+	return len(ivs)
+}
+
+func (ivs intervalsByLow) Less(i, j int) bool {
+	li, lj := ivs[i].LowAtDimension(1), ivs[j].LowAtDimension(1)
+	if li != lj {
+		return li < lj
+	}
+
+	return ivs[i].ID() < ivs[j].ID()
+}
+
+func (ivs intervalsByLow) Swap(i, j int) {
+	ivs[i], ivs[j] = ivs[j], ivs[i]
+}
+
+func hasDuplicateIDs(ivs Intervals) bool {
+	seen := make(map[uint64]struct{}, len(ivs))
+	for _, iv := range ivs {
+		id := iv.ID()
+		if _, ok := seen[id]; ok {
+			return true
+		}
+		seen[id] = struct{}{}
+	}
+
+	return false
+}
+
 // delete will remove the provided interval from the tree.
 func (tree *tree) delete(iv Interval) {
 	if tree.root == nil {
@@ -265,6 +265,120 @@ func TestAddLargeNumberOfItems(t *testing.T) {
 	assert.Equal(t, uint64(numItems), it.Len())
 }
 
+// blackHeight returns the number of black nodes on every path from n
+// to a leaf or -1 if the paths disagree.
+func blackHeight(n *node) int {
+	if n == nil {
+		return 1
+	}
+
+	left, right := blackHeight(n.children[0]), blackHeight(n.children[1])
+	if left == -1 || right == -1 || left != right {
+		return -1
+	}
+
+	if isRed(n) {
+		return left
+	}
+
+	return left + 1
+}
+
+func TestAddAll(t *testing.T) {
+	for _, number := range []int{1, 2, 3, 7, 8, 100, 1000} {
+		it := newTree(1)
+		ivs := make(Intervals, 0, number)
+		for i := number - 1; i >= 0; i-- {
+			// highs vary so the max is not always the rightmost node
+			iv := constructSingleDimensionInterval(
+				int64(i), int64(i)+int64((i*7)%13)+1, uint64(i),
+			)
+			ivs = append(ivs, iv)
+		}
+
+		it.AddAll(ivs...)
+
+		assert.Equal(t, uint64(number), it.Len())
+		assert.False(t, isRed(it.root))
+		assert.NotEqual(t, -1, blackHeight(it.root))
+		checkRedBlack(t, it.root, 1)
+
+		for _, iv := range ivs {
+			result := it.Query(iv)
+			expected := 0
+			for _, other := range ivs {
+				if other.HighAtDimension(1) >= iv.LowAtDimension(1) &&
+					other.LowAtDimension(1) <= iv.HighAtDimension(1) {
+
+					expected++
+				}
+			}
+			assert.Len(t, result, expected)
+		}
+	}
+}
+
+func TestAddAllDoesNotModifyInput(t *testing.T) {
+	it := newTree(1)
+	iv1 := constructSingleDimensionInterval(5, 10, 0)
+	iv2 := constructSingleDimensionInterval(0, 10, 1)
+	ivs := Intervals{iv1, iv2}
+
+	it.AddAll(ivs...)
+
+	assert.Equal(t, Intervals{iv1, iv2}, ivs)
+	assert.Equal(t, uint64(2), it.Len())
+}
+
+func TestAddAllToExistingTree(t *testing.T) {
+	it, ivs := constructSingleDimensionTestTree(10)
+
+	// large batch rebuilds the tree
+	batch := make(Intervals, 0, 20)
+	for i := 10; i < 30; i++ {
+		batch = append(batch, constructSingleDimensionInterval(
+			int64(i), int64(i)+10, uint64(i),
+		))
+	}
+	it.AddAll(batch...)
+
+	assert.Equal(t, uint64(30), it.Len())
+	assert.NotEqual(t, -1, blackHeight(it.root))
+	checkRedBlack(t, it.root, 1)
+	result := it.Query(constructSingleDimensionInterval(0, 100, 0))
+	assert.Len(t, result, 30)
+
+	// small batch is added one at a time
+	it.AddAll(constructSingleDimensionInterval(100, 200, 100))
+	assert.Equal(t, uint64(31), it.Len())
+	assert.NotEqual(t, -1, blackHeight(it.root))
+	checkRedBlack(t, it.root, 1)
+
+	for _, iv := range ivs {
+		it.Delete(iv)
+	}
+	assert.Equal(t, uint64(21), it.Len())
+	checkRedBlack(t, it.root, 1)
+}
+
+func TestAddAllDuplicate(t *testing.T) {
+	it := newTree(1)
+	iv := constructSingleDimensionInterval(0, 10, 0)
+
+	it.AddAll(iv, iv, iv)
+
+	assert.Equal(t, uint64(1), it.Len())
+	checkRedBlack(t, it.root, 1)
+}
+
+func TestAddAllEmpty(t *testing.T) {
+	it := newTree(1)
+	it.AddAll()
+
+	assert.Equal(t, uint64(0), it.Len())
+	assert.Nil(t, it.root)
+}
+
 func BenchmarkAddItems(b *testing.B) {
 	numItems := int64(1000)
 	intervals := make(Intervals, 0, numItems)
@@ -282,6 +396,23 @@ func BenchmarkAddItems(b *testing.B) {
 	}
 }
 
+func BenchmarkAddAllItems(b *testing.B) {
+	numItems := int64(1000)
+	intervals := make(Intervals, 0, numItems)
+
+	for i := int64(0); i < numItems; i++ {
+		iv := constructSingleDimensionInterval(i, i+1, uint64(i))
+		intervals = append(intervals, iv)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		it := newTree(1)
+		it.AddAll(intervals...)
+	}
+}
+
 func BenchmarkQueryItems(b *testing.B) {
 	numItems := int64(1000)
 	intervals := make(Intervals, 0, numItems)
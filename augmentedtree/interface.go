@@ -61,6 +61,10 @@ type Interval interface {
 type Tree interface {
 	// Add will add the provided intervals to the tree.
 	Add(intervals ...Interval)
+	// AddAll will add the provided intervals to the tree as a batch.
+	// This is much faster than Add when loading a large number of
+	// intervals at once, especially into an empty tree.
+	AddAll(intervals ...Interval)
 	// Len returns the number of intervals in the tree.
 	Len() uint64
 	// Delete will remove the provided intervals from the tree.
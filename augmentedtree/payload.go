@@ -0,0 +1,69 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package augmentedtree
+
+// payloadInterval is the interval stored in a PayloadTree's
+// underlying tree, carrying the interval's value alongside it.
+type payloadInterval[V any] struct {
+	Interval
+	value V
+}
+
+// PayloadTree is a Tree in which every interval carries a value of
+// type V.  Queries return those values directly, so there is no need
+// to keep the values elsewhere, looked up by interval ID.  Intervals
+// are identified by ID exactly as they are in a Tree.
+type PayloadTree[V any] struct {
+	tree Tree
+}
+
+// Add will add the provided interval to the tree with the provided
+// value.
+func (pt *PayloadTree[V]) Add(interval Interval, value V) {
+	pt.tree.Add(&payloadInterval[V]{Interval: interval, value: value})
+}
+
+// Delete will remove the provided intervals, and their values, from
+// the tree.
+func (pt *PayloadTree[V]) Delete(intervals ...Interval) {
+	pt.tree.Delete(intervals...)
+}
+
+// Len returns the number of intervals in the tree.
+func (pt *PayloadTree[V]) Len() uint64 {
+	return pt.tree.Len()
+}
+
+// Query will return the values of the intervals that intersect the
+// provided interval.  The values are in the same order the intervals
+// would be returned by Tree's Query.
+func (pt *PayloadTree[V]) Query(interval Interval) []V {
+	ivs := pt.tree.Query(interval)
+	values := make([]V, 0, len(ivs))
+	for _, iv := range ivs {
+		values = append(values, iv.(*payloadInterval[V]).value)
+	}
+	ivs.Dispose()
+
+	return values
+}
+
+// NewPayloadTree constructs and returns a PayloadTree with the
+// provided number of dimensions.
+func NewPayloadTree[V any](dimensions uint64) *PayloadTree[V] {
+	return &PayloadTree[V]{tree: New(dimensions)}
+}
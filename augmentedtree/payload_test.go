@@ -0,0 +1,62 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package augmentedtree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payload struct {
+	name  string
+	count int
+}
+
+func TestPayloadTreeQuery(t *testing.T) {
+	pt := NewPayloadTree[*payload](1)
+	p1, p2 := &payload{`first`, 1}, &payload{`second`, 2}
+	pt.Add(constructSingleDimensionInterval(0, 10, 0), p1)
+	pt.Add(constructSingleDimensionInterval(5, 15, 1), p2)
+	assert.Equal(t, uint64(2), pt.Len())
+
+	assert.Equal(t, []*payload{p1}, pt.Query(constructSingleDimensionInterval(1, 2, 0)))
+	assert.Equal(t, []*payload{p1, p2}, pt.Query(constructSingleDimensionInterval(6, 7, 0)))
+	assert.Equal(t, []*payload{p2}, pt.Query(constructSingleDimensionInterval(12, 20, 0)))
+	assert.Len(t, pt.Query(constructSingleDimensionInterval(20, 30, 0)), 0)
+}
+
+func TestPayloadTreeDelete(t *testing.T) {
+	pt := NewPayloadTree[string](1)
+	iv1 := constructSingleDimensionInterval(0, 10, 0)
+	iv2 := constructSingleDimensionInterval(0, 10, 1)
+	pt.Add(iv1, `first`)
+	pt.Add(iv2, `second`)
+
+	pt.Delete(iv1)
+	assert.Equal(t, uint64(1), pt.Len())
+	assert.Equal(t, []string{`second`}, pt.Query(constructSingleDimensionInterval(1, 2, 0)))
+}
+
+func TestPayloadTreeMultiDimension(t *testing.T) {
+	pt := NewPayloadTree[int](2)
+	pt.Add(constructMultiDimensionInterval(0, &dimension{0, 10}, &dimension{0, 10}), 1)
+	pt.Add(constructMultiDimensionInterval(1, &dimension{0, 10}, &dimension{20, 30}), 2)
+
+	result := pt.Query(constructMultiDimensionInterval(0, &dimension{1, 2}, &dimension{21, 22}))
+	assert.Equal(t, []int{2}, result)
+}
@@ -71,6 +71,12 @@ func (ba *bitArray) Capacity() uint64 {
 	return uint64(len(ba.blocks)) * s
 }
 
+// SizeInBytes returns an estimate of the memory used by this bit
+// array, which is one block for every s bits of capacity.
+func (ba *bitArray) SizeInBytes() uint64 {
+	return uint64(len(ba.blocks)) * (s / 8)
+}
+
 // ToNums converts this bitarray to a list of numbers contained within it.
 func (ba *bitArray) ToNums() []uint64 {
 	nums := make([]uint64, 0, ba.highest-ba.lowest/4)
@@ -140,6 +146,8 @@ func (ba *bitArray) ClearBit(k uint64) error {
 // Or will bitwise or two bit arrays and return a new bit array
 // representing the result.
 func (ba *bitArray) Or(other BitArray) BitArray {
+	other = unwrap(other)
+
 	if dba, ok := other.(*bitArray); ok {
 		return orDenseWithDenseBitArray(ba, dba)
 	}
@@ -150,6 +158,8 @@ func (ba *bitArray) Or(other BitArray) BitArray {
 // And will bitwise and two bit arrays and return a new bit array
 // representing the result.
 func (ba *bitArray) And(other BitArray) BitArray {
+	other = unwrap(other)
+
 	if dba, ok := other.(*bitArray); ok {
 		return andDenseWithDenseBitArray(ba, dba)
 	}
@@ -160,6 +170,8 @@ func (ba *bitArray) And(other BitArray) BitArray {
 // Nand will return the result of doing a bitwise and not of the bit array
 // with the other bit array on each block.
 func (ba *bitArray) Nand(other BitArray) BitArray {
+	other = unwrap(other)
+
 	if dba, ok := other.(*bitArray); ok {
 		return nandDenseWithDenseBitArray(ba, dba)
 	}
@@ -177,6 +189,8 @@ func (ba *bitArray) Reset() {
 
 // Equals returns a bool indicating if these two bit arrays are equal.
 func (ba *bitArray) Equals(other BitArray) bool {
+	other = unwrap(other)
+
 	if other.Capacity() == 0 && ba.highest > 0 {
 		return false
 	}
@@ -216,6 +230,8 @@ func (ba *bitArray) Equals(other BitArray) bool {
 // bitarray.  If the supplied bitarray is longer than this bitarray, this
 // function returns false.
 func (ba *bitArray) Intersects(other BitArray) bool {
+	other = unwrap(other)
+
 	if other.Capacity() > ba.Capacity() {
 		return false
 	}
@@ -227,6 +243,44 @@ func (ba *bitArray) Intersects(other BitArray) bool {
 	return ba.intersectsDenseBitArray(other.(*bitArray))
 }
 
+// Contains returns a bool indicating if every bit set in the supplied
+// bitarray is also set in this bitarray.  Capacities may differ, the
+// supplied bitarray is only contained if it has no bits set beyond the
+// capacity of this bitarray.  This does not allocate unless the
+// supplied bitarray is thread safe.
+func (ba *bitArray) Contains(other BitArray) bool {
+	other = unwrap(other)
+
+	switch o := other.(type) {
+	case *sparseBitArray:
+		for i, index := range o.indices {
+			if !ba.containsBlock(index, o.blocks[i]) {
+				return false
+			}
+		}
+	case *bitArray:
+		for i, b := range o.blocks {
+			if !ba.containsBlock(uint64(i), b) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// containsBlock returns a bool indicating if every bit in the provided
+// block is set in the block at the provided index.
+func (ba *bitArray) containsBlock(index uint64, b block) bool {
+	if b == 0 {
+		return true
+	}
+	if index >= uint64(len(ba.blocks)) {
+		return false
+	}
+	return ba.blocks[index].intersects(b)
+}
+
 // Blocks will return an iterator over this bit array.
 func (ba *bitArray) Blocks() Iterator {
 	return newBitArrayIterator(ba)
@@ -269,6 +269,61 @@ func BenchmarkIntersects(b *testing.B) {
 	}
 }
 
+func TestContains(t *testing.T) {
+	ba := newBitArray(130)
+	ba.SetBit(1)
+	ba.SetBit(2)
+	ba.SetBit(129)
+
+	other := newBitArray(10)
+	assert.True(t, ba.Contains(other))
+
+	other.SetBit(1)
+	other.SetBit(2)
+	assert.True(t, ba.Contains(other))
+
+	other.SetBit(3)
+	assert.False(t, ba.Contains(other))
+
+	sparse := newSparseBitArray()
+	sparse.SetBit(129)
+	assert.True(t, ba.Contains(sparse))
+
+	sparse.SetBit(1000)
+	assert.False(t, ba.Contains(sparse))
+
+	sparse.ClearBit(1000)
+	assert.True(t, ba.Contains(sparse))
+}
+
+func TestContainsLargerCapacity(t *testing.T) {
+	ba := newBitArray(10)
+	ba.SetBit(1)
+
+	other := newBitArray(1000)
+	other.SetBit(1)
+	assert.True(t, ba.Contains(other))
+	assert.False(t, other.Contains(newBitArray(2000, true)))
+
+	other.SetBit(999)
+	assert.False(t, ba.Contains(other))
+}
+
+func BenchmarkContains(b *testing.B) {
+	ba := newBitArray(162432)
+	other := newBitArray(ba.Capacity())
+
+	ba.SetBit(159999)
+	other.SetBit(159999)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ba.Contains(other)
+	}
+}
+
 func TestComplement(t *testing.T) {
 	ba := newBitArray(10)
 
@@ -480,3 +535,16 @@ func BenchmarkBitArrayToNums(b *testing.B) {
 		ba.ToNums()
 	}
 }
+
+func TestSizeInBytes(t *testing.T) {
+	ba := newBitArray(0)
+	assert.Equal(t, uint64(0), ba.SizeInBytes())
+
+	ba = newBitArray(s * 10)
+	assert.Equal(t, uint64(80), ba.SizeInBytes())
+	ba.SetBit(3)
+	assert.Equal(t, uint64(80), ba.SizeInBytes())
+
+	ba = newBitArray(s + 1)
+	assert.Equal(t, uint64(16), ba.SizeInBytes())
+}
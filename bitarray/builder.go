@@ -0,0 +1,81 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+// BitArrayBuilder collects positions whose range is not known up front
+// and builds whichever of a dense or sparse bit array holds them in
+// the least memory.
+type BitArrayBuilder struct {
+	sba      *sparseBitArray
+	count    uint64
+	min, max uint64
+}
+
+// Set records the provided position.  Setting a position more than
+// once has no further effect.
+func (b *BitArrayBuilder) Set(k uint64) {
+	if ok, _ := b.sba.GetBit(k); ok {
+		return
+	}
+
+	if b.count == 0 || k < b.min {
+		b.min = k
+	}
+	if b.count == 0 || k > b.max {
+		b.max = k
+	}
+	b.count++
+	b.sba.SetBit(k)
+}
+
+// Count returns the number of distinct positions that have been set.
+func (b *BitArrayBuilder) Count() uint64 {
+	return b.count
+}
+
+// Build returns a bit array with every position that has been set and
+// resets the builder.  A dense bit array needs a block for every block
+// up to the highest position while a sparse bit array needs a block
+// and an index for every block that holds a position, so the dense
+// bit array is chosen when it needs no more memory than the sparse.
+func (b *BitArrayBuilder) Build() BitArray {
+	sba := b.sba
+	count, min, max := b.count, b.min, b.max
+	*b = *NewBitArrayBuilder()
+
+	if count == 0 {
+		return sba
+	}
+
+	denseBlocks, _ := getIndexAndRemainder(max)
+	denseBlocks++
+	if !preferDense(denseBlocks, uint64(len(sba.blocks))) {
+		return sba
+	}
+
+	ba := newBitArray(denseBlocks * s)
+	for i, index := range sba.indices {
+		ba.blocks[index] = sba.blocks[i]
+	}
+	ba.lowest, ba.highest, ba.anyset = min, max, true
+	return ba
+}
+
+// NewBitArrayBuilder returns a new, empty BitArrayBuilder.
+func NewBitArrayBuilder() *BitArrayBuilder {
+	return &BitArrayBuilder{sba: newSparseBitArray()}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderDense(t *testing.T) {
+	b := NewBitArrayBuilder()
+	for i := uint64(0); i < 200; i += 2 {
+		b.Set(199 - i)
+	}
+	b.Set(1)
+	assert.Equal(t, uint64(100), b.Count())
+
+	ba := b.Build()
+	_, ok := ba.(*bitArray)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(256), ba.Capacity())
+	assert.Len(t, ba.ToNums(), 100)
+	for i := uint64(1); i < 200; i += 2 {
+		result, err := ba.GetBit(i)
+		assert.Nil(t, err)
+		assert.True(t, result)
+	}
+
+	dense := ba.(*bitArray)
+	assert.Equal(t, uint64(1), dense.lowest)
+	assert.Equal(t, uint64(199), dense.highest)
+}
+
+func TestBuilderSparse(t *testing.T) {
+	b := NewBitArrayBuilder()
+	b.Set(1 << 40)
+	b.Set(5)
+	b.Set(1 << 20)
+
+	ba := b.Build()
+	_, ok := ba.(*sparseBitArray)
+	assert.True(t, ok)
+	assert.Equal(t, []uint64{5, 1 << 20, 1 << 40}, ba.ToNums())
+}
+
+func TestBuilderEquals(t *testing.T) {
+	b := NewBitArrayBuilder()
+	expected := NewBitArray(128)
+	for _, k := range []uint64{3, 70, 64, 3, 127} {
+		b.Set(k)
+		expected.SetBit(k)
+	}
+	assert.Equal(t, uint64(4), b.Count())
+	assert.True(t, b.Build().Equals(expected))
+}
+
+func TestBuilderReset(t *testing.T) {
+	b := NewBitArrayBuilder()
+	b.Set(10)
+	first := b.Build()
+
+	assert.Equal(t, uint64(0), b.Count())
+	b.Set(20)
+	second := b.Build()
+	assert.Equal(t, []uint64{10}, first.ToNums())
+	assert.Equal(t, []uint64{20}, second.ToNums())
+
+	empty := b.Build()
+	assert.True(t, empty.IsEmpty())
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+// preferDense returns a bool indicating if a dense bit array of the
+// provided number of blocks needs no more memory than a sparse bit
+// array holding the provided number of non-empty blocks, which needs
+// an index as well as a block for each.
+func preferDense(denseBlocks, sparseBlocks uint64) bool {
+	return denseBlocks <= sparseBlocks*2
+}
+
+// denseFromSparse returns a dense bit array with the bits of the
+// provided sparse bit array.  Its capacity ends with the block
+// holding the highest bit.
+func denseFromSparse(sba *sparseBitArray) *bitArray {
+	if len(sba.indices) == 0 {
+		return newBitArray(0)
+	}
+
+	ba := newBitArray((sba.indices[len(sba.indices)-1] + 1) * s)
+	for i, index := range sba.indices {
+		ba.blocks[index] = sba.blocks[i]
+	}
+	ba.setLowest()
+	if ba.anyset {
+		ba.setHighest()
+	}
+	return ba
+}
+
+// sparseFromDense returns a sparse bit array with the bits of the
+// provided dense bit array.
+func sparseFromDense(ba *bitArray) *sparseBitArray {
+	sba := newSparseBitArray()
+	for i, b := range ba.blocks {
+		if b == 0 {
+			continue
+		}
+		sba.indices = append(sba.indices, uint64(i))
+		sba.blocks = append(sba.blocks, b)
+	}
+	return sba
+}
+
+// nonEmptyBlocks returns the number of blocks with a bit set.
+func (ba *bitArray) nonEmptyBlocks() uint64 {
+	count := uint64(0)
+	for _, b := range ba.blocks {
+		if b != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// ToDense returns a copy of this bit array with the same capacity.
+func (ba *bitArray) ToDense() BitArray {
+	return ba.copy()
+}
+
+// ToSparse returns a sparse bit array with the same bits set as this
+// bit array.
+func (ba *bitArray) ToSparse() BitArray {
+	return sparseFromDense(ba)
+}
+
+// Optimize returns a sparse bit array with the same bits set if it
+// would take less memory than this bit array, otherwise this bit
+// array is returned.
+func (ba *bitArray) Optimize() BitArray {
+	if preferDense(uint64(len(ba.blocks)), ba.nonEmptyBlocks()) {
+		return ba
+	}
+	return sparseFromDense(ba)
+}
+
+// ToDense returns a dense bit array with the same bits set as this bit
+// array.  Its capacity ends with the block holding the highest bit.
+func (sba *sparseBitArray) ToDense() BitArray {
+	return denseFromSparse(sba)
+}
+
+// ToSparse returns a copy of this bit array.
+func (sba *sparseBitArray) ToSparse() BitArray {
+	return sba.copy()
+}
+
+// Optimize returns a dense bit array with the same bits set if it
+// would take no more memory than this bit array, otherwise this bit
+// array is returned.
+func (sba *sparseBitArray) Optimize() BitArray {
+	if len(sba.indices) == 0 {
+		return sba
+	}
+
+	denseBlocks := sba.indices[len(sba.indices)-1] + 1
+	if !preferDense(denseBlocks, uint64(len(sba.indices))) {
+		return sba
+	}
+	return denseFromSparse(sba)
+}
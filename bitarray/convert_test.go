@@ -0,0 +1,111 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDenseToSparse(t *testing.T) {
+	ba := newBitArray(1000)
+	ba.SetBit(3)
+	ba.SetBit(64)
+	ba.SetBit(999)
+
+	sparse := ba.ToSparse()
+	if !assert.IsType(t, &sparseBitArray{}, sparse) {
+		return
+	}
+	assert.Equal(t, ba.ToNums(), sparse.ToNums())
+	assert.Len(t, sparse.(*sparseBitArray).blocks, 3)
+
+	dense := ba.ToDense()
+	assert.Equal(t, ba.Capacity(), dense.Capacity())
+	assert.True(t, ba.Equals(dense))
+	dense.SetBit(4)
+	ok, _ := ba.GetBit(4)
+	assert.False(t, ok)
+}
+
+func TestSparseToDense(t *testing.T) {
+	sba := newSparseBitArray()
+	sba.SetBit(70)
+	sba.SetBit(5)
+	sba.SetBit(300)
+
+	dense := sba.ToDense()
+	if !assert.IsType(t, &bitArray{}, dense) {
+		return
+	}
+	assert.Equal(t, sba.ToNums(), dense.ToNums())
+	assert.Equal(t, uint64(320), dense.Capacity())
+	assert.Equal(t, uint64(5), dense.(*bitArray).lowest)
+	assert.Equal(t, uint64(300), dense.(*bitArray).highest)
+
+	sparse := sba.ToSparse()
+	assert.Equal(t, sba.ToNums(), sparse.ToNums())
+	sparse.SetBit(6)
+	ok, _ := sba.GetBit(6)
+	assert.False(t, ok)
+
+	empty := newSparseBitArray().ToDense()
+	assert.True(t, empty.IsEmpty())
+	assert.Equal(t, uint64(0), empty.Capacity())
+}
+
+func TestOptimize(t *testing.T) {
+	ba := newBitArray(64 * 100)
+	ba.SetBit(10)
+	optimized := ba.Optimize()
+	assert.IsType(t, &sparseBitArray{}, optimized)
+	assert.Equal(t, ba.ToNums(), optimized.ToNums())
+
+	full := newBitArray(64*10, true)
+	assert.True(t, full.Optimize() == full)
+
+	sba := newSparseBitArray()
+	for i := uint64(0); i < 640; i += 2 {
+		sba.SetBit(i)
+	}
+	optimized = sba.Optimize()
+	assert.IsType(t, &bitArray{}, optimized)
+	assert.Equal(t, sba.ToNums(), optimized.ToNums())
+
+	sba = newSparseBitArray()
+	sba.SetBit(1 << 20)
+	assert.True(t, sba.Optimize() == sba)
+
+	empty := newSparseBitArray()
+	assert.True(t, empty.Optimize() == empty)
+}
+
+func TestThreadSafeConvert(t *testing.T) {
+	ba := newBitArray(64 * 100)
+	ba.SetBit(6000)
+	ts := NewThreadSafe(ba)
+
+	optimized := ts.Optimize()
+	assert.IsType(t, &threadSafeBitArray{}, optimized)
+	assert.IsType(t, &sparseBitArray{}, optimized.(*threadSafeBitArray).inner)
+	assert.True(t, optimized.Optimize() == optimized)
+
+	assert.IsType(t, &threadSafeBitArray{}, ts.ToSparse())
+	assert.Equal(t, ts.ToNums(), ts.ToSparse().ToNums())
+	assert.Equal(t, ts.ToNums(), ts.ToDense().ToNums())
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+import "math"
+
+// CountingBitArray keeps a counter in place of every bit of a dense
+// bit array.  This is useful when a position may be set by many
+// sources and it must stay set until every one of them has removed it,
+// as opposed to Or which only records that some source set it.
+type CountingBitArray struct {
+	counts []uint32
+}
+
+// Add increments the count at the given position.  Returns an error
+// if the position is out of range.  The count saturates at the
+// maximum uint32.
+func (cba *CountingBitArray) Add(k uint64) error {
+	if k >= cba.Capacity() {
+		return OutOfRangeError(k)
+	}
+
+	if cba.counts[k] < math.MaxUint32 {
+		cba.counts[k]++
+	}
+	return nil
+}
+
+// Remove decrements the count at the given position.  Returns an
+// error if the position is out of range.  Removing at a position
+// with a zero count is a no-op.
+func (cba *CountingBitArray) Remove(k uint64) error {
+	if k >= cba.Capacity() {
+		return OutOfRangeError(k)
+	}
+
+	if cba.counts[k] > 0 {
+		cba.counts[k]--
+	}
+	return nil
+}
+
+// AddBitArray increments the count at every position set in the
+// provided bit array.  An error is returned, and nothing is added, if
+// any of those positions are out of range.
+func (cba *CountingBitArray) AddBitArray(ba BitArray) error {
+	nums, err := cba.nums(ba)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range nums {
+		cba.Add(k)
+	}
+	return nil
+}
+
+// RemoveBitArray decrements the count at every position set in the
+// provided bit array.  An error is returned, and nothing is removed,
+// if any of those positions are out of range.
+func (cba *CountingBitArray) RemoveBitArray(ba BitArray) error {
+	nums, err := cba.nums(ba)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range nums {
+		cba.Remove(k)
+	}
+	return nil
+}
+
+func (cba *CountingBitArray) nums(ba BitArray) ([]uint64, error) {
+	nums := ba.ToNums()
+	// nums are sorted so only the last needs checking
+	if len(nums) > 0 && nums[len(nums)-1] >= cba.Capacity() {
+		return nil, OutOfRangeError(nums[len(nums)-1])
+	}
+
+	return nums, nil
+}
+
+// Count returns the count at the given position.  Returns 0 if the
+// position is out of range.
+func (cba *CountingBitArray) Count(k uint64) uint32 {
+	if k >= cba.Capacity() {
+		return 0
+	}
+
+	return cba.counts[k]
+}
+
+// Capacity returns the number of positions in this array.
+func (cba *CountingBitArray) Capacity() uint64 {
+	return uint64(len(cba.counts))
+}
+
+// Reset sets all counts to zero.
+func (cba *CountingBitArray) Reset() {
+	for i := range cba.counts {
+		cba.counts[i] = 0
+	}
+}
+
+// ToBitArray returns a new dense bit array with a bit set at every
+// position with a count of at least one.
+func (cba *CountingBitArray) ToBitArray() BitArray {
+	ba := newBitArray(cba.Capacity())
+	for k, count := range cba.counts {
+		if count > 0 {
+			ba.SetBit(uint64(k))
+		}
+	}
+
+	return ba
+}
+
+// NewCountingBitArray returns a new CountingBitArray with size
+// positions, all with a count of zero.  The size is rounded up to
+// the next multiple of the block size to match NewBitArray.
+func NewCountingBitArray(size uint64) *CountingBitArray {
+	i, r := getIndexAndRemainder(size)
+	if r > 0 {
+		i++
+	}
+
+	return &CountingBitArray{
+		counts: make([]uint32, i*s),
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountingBitArrayAddRemove(t *testing.T) {
+	cba := NewCountingBitArray(10)
+	assert.Equal(t, uint64(64), cba.Capacity())
+
+	assert.Nil(t, cba.Add(3))
+	assert.Nil(t, cba.Add(3))
+	assert.Nil(t, cba.Add(5))
+	assert.Equal(t, uint32(2), cba.Count(3))
+	assert.Equal(t, uint32(1), cba.Count(5))
+	assert.Equal(t, uint32(0), cba.Count(4))
+
+	assert.Nil(t, cba.Remove(3))
+	assert.Equal(t, uint32(1), cba.Count(3))
+	assert.Nil(t, cba.Remove(5))
+	assert.Nil(t, cba.Remove(5))
+	assert.Equal(t, uint32(0), cba.Count(5))
+}
+
+func TestCountingBitArrayOutOfRange(t *testing.T) {
+	cba := NewCountingBitArray(10)
+
+	assert.Equal(t, OutOfRangeError(64), cba.Add(64))
+	assert.Equal(t, OutOfRangeError(100), cba.Remove(100))
+	assert.Equal(t, uint32(0), cba.Count(100))
+}
+
+func TestCountingBitArrayBitArrays(t *testing.T) {
+	cba := NewCountingBitArray(128)
+	ba1 := NewBitArray(128)
+	ba1.SetBit(1)
+	ba1.SetBit(70)
+	ba2 := NewSparseBitArray()
+	ba2.SetBit(70)
+	ba2.SetBit(100)
+
+	assert.Nil(t, cba.AddBitArray(ba1))
+	assert.Nil(t, cba.AddBitArray(ba2))
+	assert.Equal(t, uint32(1), cba.Count(1))
+	assert.Equal(t, uint32(2), cba.Count(70))
+	assert.Equal(t, uint32(1), cba.Count(100))
+	assert.True(t, cba.ToBitArray().Equals(ba1.Or(ba2)))
+
+	assert.Nil(t, cba.RemoveBitArray(ba1))
+	assert.Equal(t, uint32(0), cba.Count(1))
+	assert.Equal(t, uint32(1), cba.Count(70))
+	assert.Equal(t, []uint64{70, 100}, cba.ToBitArray().ToNums())
+}
+
+func TestCountingBitArrayBitArrayOutOfRange(t *testing.T) {
+	cba := NewCountingBitArray(64)
+	ba := NewSparseBitArray()
+	ba.SetBit(1)
+	ba.SetBit(200)
+
+	assert.Equal(t, OutOfRangeError(200), cba.AddBitArray(ba))
+	assert.Equal(t, uint32(0), cba.Count(1))
+	assert.Equal(t, OutOfRangeError(200), cba.RemoveBitArray(ba))
+}
+
+func TestCountingBitArrayToBitArray(t *testing.T) {
+	cba := NewCountingBitArray(10)
+	ba := cba.ToBitArray()
+	assert.True(t, ba.IsEmpty())
+	assert.Equal(t, cba.Capacity(), ba.Capacity())
+
+	cba.Add(2)
+	cba.Add(2)
+	cba.Add(9)
+	assert.Equal(t, []uint64{2, 9}, cba.ToBitArray().ToNums())
+
+	cba.Reset()
+	assert.Equal(t, uint32(0), cba.Count(2))
+	assert.True(t, cba.ToBitArray().IsEmpty())
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+// Delta returns the changes between two versions of a bit array, the
+// bits set in new but not in old and the bits set in old but not in
+// new.  Each is found with a single Nand, so the two results are the
+// only bit arrays allocated, other than a snapshot of any thread safe
+// bit array provided.  A sparse result is built only from the blocks
+// of the sparse array it is taken from.  ApplyDelta reverses this.
+func Delta(old, new BitArray) (added, removed BitArray) {
+	old, new = unwrap(old), unwrap(new)
+	return new.Nand(old), old.Nand(new)
+}
+
+// ApplyDelta returns a new bit array with the bits of added set and the
+// bits of removed cleared in old, so ApplyDelta(old, Delta(old, new))
+// has the same bits set as new.  old is not changed.
+func ApplyDelta(old, added, removed BitArray) BitArray {
+	return old.Or(added).Nand(removed)
+}
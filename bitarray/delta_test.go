@@ -0,0 +1,95 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func constructDeltaArrays(dense bool, bits ...uint64) BitArray {
+	var ba BitArray = newSparseBitArray()
+	if dense {
+		ba = newBitArray(s * 20)
+	}
+	for _, bit := range bits {
+		ba.SetBit(bit)
+	}
+
+	return ba
+}
+
+func TestDelta(t *testing.T) {
+	for _, oldDense := range []bool{false, true} {
+		for _, newDense := range []bool{false, true} {
+			old := constructDeltaArrays(oldDense, 1, 5, 64, 300, 1000)
+			new := constructDeltaArrays(newDense, 1, 6, 64, 700, 1000, 1200)
+
+			added, removed := Delta(old, new)
+			assert.Equal(t, []uint64{6, 700, 1200}, added.ToNums())
+			assert.Equal(t, []uint64{5, 300}, removed.ToNums())
+
+			assert.Equal(t, new.ToNums(), ApplyDelta(old, added, removed).ToNums())
+			assert.Equal(t, []uint64{1, 5, 64, 300, 1000}, old.ToNums())
+		}
+	}
+}
+
+func TestDeltaUnchanged(t *testing.T) {
+	old := constructDeltaArrays(false, 3, 900)
+	new := constructDeltaArrays(true, 3, 900)
+
+	added, removed := Delta(old, new)
+	assert.True(t, added.IsEmpty())
+	assert.True(t, removed.IsEmpty())
+
+	added, removed = Delta(newSparseBitArray(), new)
+	assert.Equal(t, []uint64{3, 900}, added.ToNums())
+	assert.True(t, removed.IsEmpty())
+}
+
+func TestDeltaDifferentCapacities(t *testing.T) {
+	old := newBitArray(s)
+	old.SetBit(3)
+	new := newBitArray(s * 4)
+	new.SetBit(200)
+
+	added, removed := Delta(old, new)
+	assert.Equal(t, []uint64{200}, added.ToNums())
+	assert.Equal(t, []uint64{3}, removed.ToNums())
+	assert.Equal(t, new.ToNums(), ApplyDelta(old, added, removed).ToNums())
+}
+
+func TestDeltaSparseTouchesOnlyItsBlocks(t *testing.T) {
+	old := constructDeltaArrays(false, 10, 5000)
+	new := constructDeltaArrays(false, 10, 5001, s*1000)
+
+	added, removed := Delta(old, new)
+	assert.Len(t, added.(*sparseBitArray).indices, 2)
+	assert.Len(t, removed.(*sparseBitArray).indices, 1)
+}
+
+func TestDeltaThreadSafe(t *testing.T) {
+	old := NewThreadSafe(constructDeltaArrays(false, 1, 2))
+	new := NewThreadSafe(constructDeltaArrays(true, 2, 3))
+
+	added, removed := Delta(old, new)
+	assert.Equal(t, []uint64{3}, added.ToNums())
+	assert.Equal(t, []uint64{1}, removed.ToNums())
+	assert.Equal(t, new.ToNums(), ApplyDelta(old, added, removed).ToNums())
+}
@@ -18,11 +18,27 @@ package bitarray
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/binary"
+	"encoding/gob"
 	"errors"
 	"io"
 )
 
+var (
+	_ encoding.BinaryMarshaler   = (*bitArray)(nil)
+	_ encoding.BinaryUnmarshaler = (*bitArray)(nil)
+	_ encoding.BinaryMarshaler   = (*sparseBitArray)(nil)
+	_ encoding.BinaryUnmarshaler = (*sparseBitArray)(nil)
+)
+
+func init() {
+	// registering the concrete types allows a BitArray interface
+	// value to be gob encoded as part of a larger structure.
+	gob.Register(&bitArray{})
+	gob.Register(&sparseBitArray{})
+}
+
 // Marshal takes a dense or sparse bit array and serializes it to a
 // byte slice.
 func Marshal(ba BitArray) ([]byte, error) {
@@ -95,6 +111,23 @@ func (ba *sparseBitArray) Serialize() ([]byte, error) {
 	return w.Bytes(), nil
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler using the same
+// format as Serialize.
+func (ba *sparseBitArray) MarshalBinary() ([]byte, error) {
+	return ba.Serialize()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.  Any existing
+// contents of the sparseBitArray are replaced.
+func (ret *sparseBitArray) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != 'S' {
+		return errors.New("unrecognized encoding")
+	}
+
+	ret.blocks, ret.indices = nil, nil
+	return ret.Deserialize(data)
+}
+
 // This function is a copy from the binary package, with some added error
 // checking to avoid panics. The function will return the value, and the number
 // of bytes read from the buffer. If the number of bytes is negative, then
@@ -109,47 +142,63 @@ func Uint64FromBytes(b []byte) (uint64, int) {
 	return val, 8
 }
 
+// errInvalidData is returned when deserializing data that was cut
+// short.
+var errInvalidData = errors.New("Invalid data for BitArray")
+
+// countAt reads the count of uint64s that starts at the provided
+// location of the incoming byte slice, checking that the slice holds
+// that many after it.  Returned is the count and the location of the
+// first of them.
+func countAt(incoming []byte, loc uint64) (uint64, uint64, error) {
+	var intsize = uint64(s / 8)
+	if loc > uint64(len(incoming)) {
+		return 0, 0, errInvalidData
+	}
+
+	count, bytesRead := Uint64FromBytes(incoming[loc:])
+	if bytesRead < 0 {
+		return 0, 0, errInvalidData
+	}
+	loc += intsize
+
+	if count > (uint64(len(incoming))-loc)/intsize {
+		return 0, 0, errInvalidData
+	}
+	return count, loc, nil
+}
+
 // Deserialize takes the incoming byte slice, and populates the sparseBitArray
 // with data in the bytes. Note that this will overwrite any capacity
 // specified when creating the sparseBitArray. Also note that if an error
 // is returned, the sparseBitArray this is called on might be populated
-// with partial data.
+// with partial data.  An error is returned if the data is cut short.
 func (ret *sparseBitArray) Deserialize(incoming []byte) error {
 	var intsize = uint64(s / 8)
 	var curLoc = uint64(1) // Ignore the identifier byte
 
-	var intsToRead uint64
-	var bytesRead int
-	intsToRead, bytesRead = Uint64FromBytes(incoming[curLoc : curLoc+intsize])
-	if bytesRead < 0 {
-		return errors.New("Invalid data for BitArray")
+	intsToRead, curLoc, err := countAt(incoming, curLoc)
+	if err != nil {
+		return err
 	}
-	curLoc += intsize
 
 	var nextblock uint64
 	ret.blocks = make([]block, intsToRead)
 	for i := uint64(0); i < intsToRead; i++ {
-		nextblock, bytesRead = Uint64FromBytes(incoming[curLoc : curLoc+intsize])
-		if bytesRead < 0 {
-			return errors.New("Invalid data for BitArray")
-		}
+		nextblock, _ = Uint64FromBytes(incoming[curLoc : curLoc+intsize])
 		ret.blocks[i] = block(nextblock)
 		curLoc += intsize
 	}
 
-	intsToRead, bytesRead = Uint64FromBytes(incoming[curLoc : curLoc+intsize])
-	if bytesRead < 0 {
-		return errors.New("Invalid data for BitArray")
+	intsToRead, curLoc, err = countAt(incoming, curLoc)
+	if err != nil {
+		return err
 	}
-	curLoc += intsize
 
 	var nextuint uint64
 	ret.indices = make(uintSlice, intsToRead)
 	for i := uint64(0); i < intsToRead; i++ {
-		nextuint, bytesRead = Uint64FromBytes(incoming[curLoc : curLoc+intsize])
-		if bytesRead < 0 {
-			return errors.New("Invalid data for BitArray")
-		}
+		nextuint, _ = Uint64FromBytes(incoming[curLoc : curLoc+intsize])
 		ret.indices[i] = nextuint
 		curLoc += intsize
 	}
@@ -193,11 +242,32 @@ func (ba *bitArray) Serialize() ([]byte, error) {
 	return w.Bytes(), nil
 }
 
+// MarshalBinary implements encoding.BinaryMarshaler using the same
+// format as Serialize.
+func (ba *bitArray) MarshalBinary() ([]byte, error) {
+	return ba.Serialize()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.  Any existing
+// contents of the bitArray are replaced.
+func (ret *bitArray) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != 'B' {
+		return errors.New("unrecognized encoding")
+	}
+
+	*ret = bitArray{}
+	return ret.Deserialize(data)
+}
+
 // Deserialize takes the incoming byte slice, and populates the bitArray
 // with data in the bytes. Note that this will overwrite any capacity
 // specified when creating the bitArray. Also note that if an error is returned,
 // the bitArray this is called on might be populated with partial data.
 func (ret *bitArray) Deserialize(incoming []byte) error {
+	if len(incoming) == 0 {
+		return errInvalidData
+	}
+
 	r := bytes.NewReader(incoming[1:]) // Discard identifier
 
 	err := binary.Read(r, binary.LittleEndian, &ret.lowest)
@@ -17,6 +17,8 @@ limitations under the License.
 package bitarray
 
 import (
+	"bytes"
+	"encoding/gob"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -136,3 +138,89 @@ func TestUnmarshalErrors(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, output, nil)
 }
+
+func TestGobRoundTrip(t *testing.T) {
+	type container struct {
+		Dense  BitArray
+		Sparse BitArray
+	}
+
+	dense := newBitArray(1280)
+	sparse := newSparseBitArray()
+	for i := uint64(0); i < 1280; i++ {
+		if i%3 == 0 {
+			dense.SetBit(i)
+			sparse.SetBit(i * 7)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(container{Dense: dense, Sparse: sparse})
+	assert.Nil(t, err)
+
+	var result container
+	err = gob.NewDecoder(&buf).Decode(&result)
+	assert.Nil(t, err)
+	assert.IsType(t, dense, result.Dense)
+	assert.IsType(t, sparse, result.Sparse)
+	assert.True(t, dense.Equals(result.Dense))
+	assert.True(t, sparse.Equals(result.Sparse))
+}
+
+func TestUnmarshalBinaryReplacesContents(t *testing.T) {
+	input := newBitArray(128)
+	input.SetBit(5)
+	data, err := input.MarshalBinary()
+	assert.Nil(t, err)
+
+	output := newBitArray(256)
+	output.SetBit(200)
+	assert.Nil(t, output.UnmarshalBinary(data))
+	assert.True(t, input.Equals(output))
+
+	sparseInput := newSparseBitArray()
+	sparseInput.SetBit(5)
+	data, err = sparseInput.MarshalBinary()
+	assert.Nil(t, err)
+
+	sparseOutput := newSparseBitArray()
+	sparseOutput.SetBit(200)
+	assert.Nil(t, sparseOutput.UnmarshalBinary(data))
+	assert.True(t, sparseInput.Equals(sparseOutput))
+
+	assert.Error(t, sparseOutput.UnmarshalBinary(nil))
+	assert.Error(t, output.UnmarshalBinary(data))
+}
+
+func TestUnmarshalBinaryTruncated(t *testing.T) {
+	sparse := newSparseBitArray()
+	sparse.SetBit(5)
+	sparse.SetBit(500)
+	data, err := sparse.MarshalBinary()
+	assert.Nil(t, err)
+
+	for i := 1; i < len(data); i++ {
+		assert.Error(t, newSparseBitArray().UnmarshalBinary(data[:i]))
+		_, err = Unmarshal(data[:i])
+		assert.Error(t, err)
+	}
+
+	// a count larger than the data holds is refused before allocating
+	huge := append([]byte{'S'}, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)
+	assert.Error(t, newSparseBitArray().UnmarshalBinary(huge))
+
+	dense := newBitArray(128)
+	dense.SetBit(5)
+	data, err = dense.MarshalBinary()
+	assert.Nil(t, err)
+
+	// dense data cut between blocks holds fewer blocks, anything else
+	// is an error, but no truncation may panic
+	for i := 1; i < len(data); i++ {
+		assert.NotPanics(t, func() {
+			newBitArray(0).UnmarshalBinary(data[:i])
+		})
+	}
+	assert.Error(t, newBitArray(0).UnmarshalBinary(data[:10]))
+	assert.Error(t, newBitArray(0).Deserialize(nil))
+}
@@ -51,10 +51,17 @@ type BitArray interface {
 	// Intersects returns a bool indicating if the other bit
 	// array intersects with this bit array.
 	Intersects(other BitArray) bool
+	// Contains returns a bool indicating if every bit set in the
+	// other bit array is also set in this bit array.
+	Contains(other BitArray) bool
 	// Capacity returns either the given capacity of the bit array
 	// in the case of a dense bit array or the highest possible
 	// seen capacity of the sparse array.
 	Capacity() uint64
+	// SizeInBytes returns an estimate of the memory used to hold the
+	// bits of this bit array.  Go runtime overhead, such as slice
+	// headers and unused slice capacity, is not included.
+	SizeInBytes() uint64
 	// Or will bitwise or the two bitarrays and return a new bitarray
 	// representing the result.
 	Or(other BitArray) BitArray
@@ -64,6 +71,16 @@ type BitArray interface {
 	// Nand will bitwise nand the two bitarrays and return a new bitarray
 	// representing the result.
 	Nand(other BitArray) BitArray
+	// ToDense returns a dense bit array with the same bits set as this
+	// bit array.
+	ToDense() BitArray
+	// ToSparse returns a sparse bit array with the same bits set as
+	// this bit array.
+	ToSparse() BitArray
+	// Optimize returns whichever of a dense or sparse bit array with
+	// the same bits set as this bit array takes the least memory.  This
+	// bit array is returned if it already has that backing.
+	Optimize() BitArray
 	// ToNums converts this bit array to the list of numbers contained
 	// within it.
 	ToNums() []uint64
@@ -137,10 +137,11 @@ func nandDenseWithSparseBitArray(sba *bitArray, other *sparseBitArray) BitArray
 }
 
 func nandDenseWithDenseBitArray(dba, other *bitArray) BitArray {
-	min := uint64(len(dba.blocks))
-
-	ba := newBitArray(min * s)
+	ba := newBitArray(uint64(len(dba.blocks)) * s)
+	// blocks past the end of the other array are kept as they are
+	copy(ba.blocks, dba.blocks)
 
+	min := minUint64(uint64(len(dba.blocks)), uint64(len(other.blocks)))
 	for i := uint64(0); i < min; i++ {
 		ba.blocks[i] = dba.blocks[i].nand(other.blocks[i])
 	}
@@ -16,6 +16,13 @@ limitations under the License.
 
 package bitarray
 
+import (
+	"container/heap"
+	"runtime"
+	"sort"
+	"sync"
+)
+
 func orSparseWithSparseBitArray(sba *sparseBitArray,
 	other *sparseBitArray) BitArray {
 
@@ -150,3 +157,187 @@ func orDenseWithDenseBitArray(dba *bitArray, other *bitArray) BitArray {
 
 	return ba
 }
+
+// minParallelBlocks is the fewest blocks per goroutine for which
+// OrAllParallel splits the work.
+const minParallelBlocks = 1024
+
+// OrAll will bitwise or all of the provided bit arrays in a single pass
+// and return a new bit array representing the result.  The result is
+// dense if any of the bit arrays is dense, and sparse otherwise.  No bit
+// array is returned as is, even if only one is provided.
+func OrAll(arrays ...BitArray) BitArray {
+	arrays = unwrapAll(arrays)
+
+	size, dense := orAllSize(arrays)
+	if !dense {
+		return orAllSparse(arrays)
+	}
+
+	ba := newBitArray(size * s)
+	orAllRange(ba, arrays, 0, size)
+	ba.setLowest()
+	ba.setHighest()
+	return ba
+}
+
+// OrAllParallel is like OrAll but splits the blocks of a dense result
+// into ranges that are bitwised or across goroutines.  A sparse result,
+// or one too small to be worth splitting, is found as OrAll finds it.
+func OrAllParallel(arrays ...BitArray) BitArray {
+	arrays = unwrapAll(arrays)
+
+	size, dense := orAllSize(arrays)
+	workers := uint64(runtime.GOMAXPROCS(0))
+	if !dense || workers < 2 || size < 2*minParallelBlocks {
+		return OrAll(arrays...)
+	}
+
+	if max := size / minParallelBlocks; workers > max {
+		workers = max
+	}
+
+	ba := newBitArray(size * s)
+	chunk := (size + workers - 1) / workers
+	var wg sync.WaitGroup
+	for low := uint64(0); low < size; low += chunk {
+		high := minUint64(low+chunk, size)
+		wg.Add(1)
+		go func(low, high uint64) {
+			defer wg.Done()
+			orAllRange(ba, arrays, low, high)
+		}(low, high)
+	}
+	wg.Wait()
+
+	ba.setLowest()
+	ba.setHighest()
+	return ba
+}
+
+// unwrapAll returns a new list with any thread safe bit arrays
+// replaced by a snapshot of the bit array they wrap.
+func unwrapAll(arrays []BitArray) []BitArray {
+	unwrapped := make([]BitArray, len(arrays))
+	for i, ba := range arrays {
+		unwrapped[i] = unwrap(ba)
+	}
+
+	return unwrapped
+}
+
+// orAllSize returns the number of blocks needed to hold the bitwise or
+// of the provided bit arrays and whether any of them is dense.
+func orAllSize(arrays []BitArray) (uint64, bool) {
+	var size uint64
+	dense := false
+	for _, ba := range arrays {
+		switch b := ba.(type) {
+		case *bitArray:
+			dense = true
+			size = maxUint64(size, uint64(len(b.blocks)))
+		case *sparseBitArray:
+			if len(b.indices) > 0 {
+				size = maxUint64(size, b.indices[len(b.indices)-1]+1)
+			}
+		}
+	}
+
+	return size, dense
+}
+
+// orAllRange will bitwise or the blocks of the provided bit arrays from
+// low up to but not including high into ba.
+func orAllRange(ba *bitArray, arrays []BitArray, low, high uint64) {
+	for _, array := range arrays {
+		switch b := array.(type) {
+		case *bitArray:
+			end := minUint64(high, uint64(len(b.blocks)))
+			for i := low; i < end; i++ {
+				ba.blocks[i] |= b.blocks[i]
+			}
+		case *sparseBitArray:
+			i := sort.Search(len(b.indices), func(i int) bool {
+				return b.indices[i] >= low
+			})
+			for ; i < len(b.indices) && b.indices[i] < high; i++ {
+				ba.blocks[b.indices[i]] |= b.blocks[i]
+			}
+		}
+	}
+}
+
+// sparseCursor is the position of the next block to be merged from a
+// sparse bit array.
+type sparseCursor struct {
+	sba      *sparseBitArray
+	position int
+}
+
+func (sc *sparseCursor) index() uint64 {
+	return sc.sba.indices[sc.position]
+}
+
+// sparseCursors is a min heap of cursors ordered by the index of their
+// next block.
+type sparseCursors []*sparseCursor
+
+func (sc sparseCursors) Len() int { return len(sc) }
+
+func (sc sparseCursors) Less(i, j int) bool { return sc[i].index() < sc[j].index() }
+
+func (sc sparseCursors) Swap(i, j int) { sc[i], sc[j] = sc[j], sc[i] }
+
+func (sc *sparseCursors) Push(x interface{}) { *sc = append(*sc, x.(*sparseCursor)) }
+
+func (sc *sparseCursors) Pop() interface{} {
+	old := *sc
+	cursor := old[len(old)-1]
+	old[len(old)-1] = nil
+	*sc = old[:len(old)-1]
+	return cursor
+}
+
+// orAllSparse merges the blocks of the provided sparse bit arrays in
+// order of their indices.
+func orAllSparse(arrays []BitArray) BitArray {
+	cursors := make(sparseCursors, 0, len(arrays))
+	max := 0
+	for _, ba := range arrays {
+		sba := ba.(*sparseBitArray)
+		if len(sba.indices) == 0 {
+			continue
+		}
+		cursors = append(cursors, &sparseCursor{sba: sba})
+		if len(sba.indices) > max {
+			max = len(sba.indices)
+		}
+	}
+	heap.Init(&cursors)
+
+	result := &sparseBitArray{
+		indices: make(uintSlice, 0, max),
+		blocks:  make(blocks, 0, max),
+	}
+	for len(cursors) > 0 {
+		cursor := cursors[0]
+		index, b := cursor.index(), cursor.sba.blocks[cursor.position]
+
+		last := len(result.indices) - 1
+		if last >= 0 && result.indices[last] == index {
+			result.blocks[last] = result.blocks[last].or(b)
+		} else {
+			result.indices = append(result.indices, index)
+			result.blocks = append(result.blocks, b)
+		}
+
+		cursor.position++
+		if cursor.position == len(cursor.sba.indices) {
+			heap.Pop(&cursors)
+		} else {
+			heap.Fix(&cursors, 0)
+		}
+	}
+
+	return result
+}
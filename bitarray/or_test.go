@@ -271,3 +271,84 @@ func TestOrDenseWithEmptyDense(t *testing.T) {
 	result = orDenseWithDenseBitArray(dba, other)
 	assert.Equal(t, other, result)
 }
+
+func TestOrAllSparse(t *testing.T) {
+	arrays := make([]BitArray, 0, 5)
+	var expected BitArray = newSparseBitArray()
+	for i := uint64(0); i < 5; i++ {
+		sba := newSparseBitArray()
+		for j := i; j < 2000; j += 7 * (i + 1) {
+			sba.SetBit(j)
+		}
+		arrays = append(arrays, sba)
+		expected = expected.Or(sba)
+	}
+
+	result := OrAll(arrays...)
+	assert.IsType(t, &sparseBitArray{}, result)
+	assert.True(t, expected.Equals(result))
+	assert.Equal(t, expected.ToNums(), result.ToNums())
+
+	assert.True(t, OrAll().IsEmpty())
+	single := OrAll(arrays[0])
+	assert.Equal(t, arrays[0].ToNums(), single.ToNums())
+	single.SetBit(1)
+	ok, _ := arrays[0].GetBit(1)
+	assert.False(t, ok)
+}
+
+func TestOrAllMixed(t *testing.T) {
+	sparse := newSparseBitArray()
+	sparse.SetBit(3)
+	sparse.SetBit(500)
+	dense := newBitArray(200)
+	dense.SetBit(3)
+	dense.SetBit(150)
+	threadSafe := NewThreadSafe(newSparseBitArray())
+	threadSafe.SetBit(64)
+
+	result := OrAll(sparse, dense, threadSafe, newSparseBitArray())
+	assert.IsType(t, &bitArray{}, result)
+	assert.Equal(t, []uint64{3, 64, 150, 500}, result.ToNums())
+	assert.True(t, result.Equals(sparse.Or(dense).Or(threadSafe)))
+}
+
+func TestOrAllParallel(t *testing.T) {
+	size := uint64(8 * minParallelBlocks * s)
+	arrays := make([]BitArray, 0, 4)
+	for i := uint64(0); i < 4; i++ {
+		ba := newBitArray(size)
+		for j := i; j < size; j += 97 {
+			ba.SetBit(j)
+		}
+		arrays = append(arrays, ba)
+	}
+	sba := newSparseBitArray()
+	sba.SetBit(size - 1)
+	sba.SetBit(size + 10)
+	arrays = append(arrays, sba)
+
+	expected := OrAll(arrays...)
+	result := OrAllParallel(arrays...)
+	assert.True(t, expected.Equals(result))
+	assert.Equal(t, expected.ToNums(), result.ToNums())
+
+	small := OrAllParallel(sba, newSparseBitArray())
+	assert.Equal(t, sba.ToNums(), small.ToNums())
+}
+
+func BenchmarkOrAll(b *testing.B) {
+	arrays := make([]BitArray, 0, 100)
+	for i := uint64(0); i < 100; i++ {
+		sba := newSparseBitArray()
+		for j := i; j < 100000; j += 1000 {
+			sba.SetBit(j)
+		}
+		arrays = append(arrays, sba)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		OrAll(arrays...)
+	}
+}
@@ -182,9 +182,18 @@ func (sba *sparseBitArray) Capacity() uint64 {
 	return sba.indices[len(sba.indices)-1] + s
 }
 
+// SizeInBytes returns an estimate of the memory used by this sparse
+// bitarray, which is a block and its index for every block holding a
+// set bit.
+func (sba *sparseBitArray) SizeInBytes() uint64 {
+	return uint64(len(sba.blocks)) * (s/8 + 8)
+}
+
 // Equals returns a bool indicating if the provided bit array
 // equals this bitarray.
 func (sba *sparseBitArray) Equals(other BitArray) bool {
+	other = unwrap(other)
+
 	if other.Capacity() == 0 && sba.Capacity() > 0 {
 		return false
 	}
@@ -228,6 +237,8 @@ func (sba *sparseBitArray) Equals(other BitArray) bool {
 // Or will perform a bitwise or operation with the provided bitarray and
 // return a new result bitarray.
 func (sba *sparseBitArray) Or(other BitArray) BitArray {
+	other = unwrap(other)
+
 	if ba, ok := other.(*sparseBitArray); ok {
 		return orSparseWithSparseBitArray(sba, ba)
 	}
@@ -238,6 +249,8 @@ func (sba *sparseBitArray) Or(other BitArray) BitArray {
 // And will perform a bitwise and operation with the provided bitarray and
 // return a new result bitarray.
 func (sba *sparseBitArray) And(other BitArray) BitArray {
+	other = unwrap(other)
+
 	if ba, ok := other.(*sparseBitArray); ok {
 		return andSparseWithSparseBitArray(sba, ba)
 	}
@@ -248,6 +261,8 @@ func (sba *sparseBitArray) And(other BitArray) BitArray {
 // Nand will return the result of doing a bitwise and not of the bit array
 // with the other bit array on each block.
 func (sba *sparseBitArray) Nand(other BitArray) BitArray {
+	other = unwrap(other)
+
 	if ba, ok := other.(*sparseBitArray); ok {
 		return nandSparseWithSparseBitArray(sba, ba)
 	}
@@ -275,6 +290,8 @@ func (sba *sparseBitArray) copy() *sparseBitArray {
 // Intersects returns a bool indicating if the provided bit array
 // intersects with this bitarray.
 func (sba *sparseBitArray) Intersects(other BitArray) bool {
+	other = unwrap(other)
+
 	if other.Capacity() == 0 {
 		return true
 	}
@@ -309,6 +326,49 @@ func (sba *sparseBitArray) Intersects(other BitArray) bool {
 	return true
 }
 
+// Contains returns a bool indicating if every bit set in the provided
+// bit array is also set in this bitarray.  Only the blocks present in
+// the provided bit array are checked, so capacities may differ.  This
+// does not allocate unless the provided bit array is thread safe.
+func (sba *sparseBitArray) Contains(other BitArray) bool {
+	other = unwrap(other)
+
+	var selfIndex int
+	switch o := other.(type) {
+	case *sparseBitArray:
+		for i, index := range o.indices {
+			if !sba.containsBlock(&selfIndex, index, o.blocks[i]) {
+				return false
+			}
+		}
+	case *bitArray:
+		for i, b := range o.blocks {
+			if !sba.containsBlock(&selfIndex, uint64(i), b) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// containsBlock returns a bool indicating if every bit in the provided
+// block is set in the block at the provided index.  Indices must be
+// checked in ascending order, selfIndex tracks our position between
+// calls.
+func (sba *sparseBitArray) containsBlock(selfIndex *int, index uint64, b block) bool {
+	if b == 0 {
+		return true
+	}
+	for *selfIndex < len(sba.indices) && sba.indices[*selfIndex] < index {
+		*selfIndex++
+	}
+	if *selfIndex == len(sba.indices) || sba.indices[*selfIndex] != index {
+		return false
+	}
+	return sba.blocks[*selfIndex].intersects(b)
+}
+
 func (sba *sparseBitArray) IntersectsBetween(other BitArray, start, stop uint64) bool {
 	return true
 }
@@ -308,3 +308,43 @@ func BenchmarkSparseBitArrayToNums(b *testing.B) {
 		sba.ToNums()
 	}
 }
+
+func TestSparseContains(t *testing.T) {
+	sba := newSparseBitArray()
+	sba.SetBit(3)
+	sba.SetBit(5)
+	sba.SetBit(200)
+
+	other := newSparseBitArray()
+	assert.True(t, sba.Contains(other))
+
+	other.SetBit(5)
+	other.SetBit(200)
+	assert.True(t, sba.Contains(other))
+
+	other.SetBit(100)
+	assert.False(t, sba.Contains(other))
+
+	dense := newBitArray(300)
+	dense.SetBit(3)
+	dense.SetBit(200)
+	assert.True(t, sba.Contains(dense))
+
+	dense.SetBit(201)
+	assert.False(t, sba.Contains(dense))
+
+	assert.False(t, newSparseBitArray().Contains(dense))
+	assert.True(t, newSparseBitArray().Contains(newBitArray(300)))
+}
+
+func TestSparseSizeInBytes(t *testing.T) {
+	sba := newSparseBitArray()
+	assert.Equal(t, uint64(0), sba.SizeInBytes())
+
+	sba.SetBit(3)
+	sba.SetBit(5)
+	assert.Equal(t, uint64(16), sba.SizeInBytes())
+
+	sba.SetBit(s * 100)
+	assert.Equal(t, uint64(32), sba.SizeInBytes())
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+import "sync"
+
+// threadSafeBitArray wraps another bit array and guards it with a
+// read/write lock.
+type threadSafeBitArray struct {
+	lock  sync.RWMutex
+	inner BitArray
+}
+
+// SetBit sets the bit at the given position under the write lock.
+func (ts *threadSafeBitArray) SetBit(k uint64) error {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	return ts.inner.SetBit(k)
+}
+
+// GetBit gets the bit at the given position under the read lock.
+func (ts *threadSafeBitArray) GetBit(k uint64) (bool, error) {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.GetBit(k)
+}
+
+// ClearBit clears the bit at the given position under the write lock.
+func (ts *threadSafeBitArray) ClearBit(k uint64) error {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	return ts.inner.ClearBit(k)
+}
+
+// Reset sets all values to zero.
+func (ts *threadSafeBitArray) Reset() {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+
+	ts.inner.Reset()
+}
+
+// Blocks returns an iterator over a snapshot of this bit array, later
+// changes are not seen by the iterator.
+func (ts *threadSafeBitArray) Blocks() Iterator {
+	return ts.snapshot().Blocks()
+}
+
+// Equals returns a bool indicating equality between the two bit arrays.
+func (ts *threadSafeBitArray) Equals(other BitArray) bool {
+	other = unwrap(other)
+
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.Equals(other)
+}
+
+// Intersects returns a bool indicating if the other bit array
+// intersects with this bit array.
+func (ts *threadSafeBitArray) Intersects(other BitArray) bool {
+	other = unwrap(other)
+
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.Intersects(other)
+}
+
+// Contains returns a bool indicating if every bit set in the other
+// bit array is also set in this bit array.
+func (ts *threadSafeBitArray) Contains(other BitArray) bool {
+	other = unwrap(other)
+
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.Contains(other)
+}
+
+// Capacity returns the capacity of the wrapped bit array.
+func (ts *threadSafeBitArray) Capacity() uint64 {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.Capacity()
+}
+
+// SizeInBytes returns an estimate of the memory used by the wrapped
+// bit array.
+func (ts *threadSafeBitArray) SizeInBytes() uint64 {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.SizeInBytes()
+}
+
+// Or will bitwise or the two bitarrays and return a new bitarray
+// representing the result.  The result is not wrapped.
+func (ts *threadSafeBitArray) Or(other BitArray) BitArray {
+	other = unwrap(other)
+
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.Or(other)
+}
+
+// And will bitwise and the two bitarrays and return a new bitarray
+// representing the result.  The result is not wrapped.
+func (ts *threadSafeBitArray) And(other BitArray) BitArray {
+	other = unwrap(other)
+
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.And(other)
+}
+
+// Nand will bitwise nand the two bitarrays and return a new bitarray
+// representing the result.  The result is not wrapped.
+func (ts *threadSafeBitArray) Nand(other BitArray) BitArray {
+	other = unwrap(other)
+
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.Nand(other)
+}
+
+// ToDense returns a dense copy of the wrapped bit array.  Unlike the
+// results of Or, And and Nand, the copy is wrapped so the conversion
+// keeps it safe for concurrent use.
+func (ts *threadSafeBitArray) ToDense() BitArray {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return NewThreadSafe(ts.inner.ToDense())
+}
+
+// ToSparse returns a sparse copy of the wrapped bit array.  The copy
+// is wrapped.
+func (ts *threadSafeBitArray) ToSparse() BitArray {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return NewThreadSafe(ts.inner.ToSparse())
+}
+
+// Optimize returns this bit array if the wrapped bit array already has
+// the backing that takes the least memory, otherwise a wrapped copy
+// with the other backing.
+func (ts *threadSafeBitArray) Optimize() BitArray {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	optimized := ts.inner.Optimize()
+	if optimized == ts.inner {
+		return ts
+	}
+	return NewThreadSafe(optimized)
+}
+
+// ToNums converts this bit array to the list of numbers contained
+// within it.
+func (ts *threadSafeBitArray) ToNums() []uint64 {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.ToNums()
+}
+
+// IsEmpty checks to see if any values are set on the bitarray.
+func (ts *threadSafeBitArray) IsEmpty() bool {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return ts.inner.IsEmpty()
+}
+
+// snapshot returns a copy of the wrapped bit array taken under
+// the read lock.
+func (ts *threadSafeBitArray) snapshot() BitArray {
+	ts.lock.RLock()
+	defer ts.lock.RUnlock()
+
+	return copyBitArray(ts.inner)
+}
+
+func copyBitArray(ba BitArray) BitArray {
+	switch b := ba.(type) {
+	case *bitArray:
+		return b.copy()
+	case *sparseBitArray:
+		return b.copy()
+	case *threadSafeBitArray:
+		return b.snapshot()
+	}
+
+	return ba
+}
+
+// unwrap returns a snapshot of the provided bit array if it is thread
+// safe so the other's lock is never held together with our own.  Other
+// bit arrays are returned as is.
+func unwrap(ba BitArray) BitArray {
+	if ts, ok := ba.(*threadSafeBitArray); ok {
+		return ts.snapshot()
+	}
+
+	return ba
+}
+
+// NewThreadSafe returns a bit array that wraps the provided bit array
+// and is safe for concurrent use.  Reads take a read lock and mutations
+// take the write lock.  Operations with another thread safe bit array
+// work on a snapshot of the other array.  The wrapped bit array should
+// not be used directly once it is wrapped.
+func NewThreadSafe(inner BitArray) BitArray {
+	if ts, ok := inner.(*threadSafeBitArray); ok {
+		return ts
+	}
+
+	return &threadSafeBitArray{inner: inner}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bitarray
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThreadSafeBitOperations(t *testing.T) {
+	ba := NewThreadSafe(newBitArray(10))
+
+	assert.Nil(t, ba.SetBit(5))
+	result, err := ba.GetBit(5)
+	assert.Nil(t, err)
+	assert.True(t, result)
+	assert.False(t, ba.IsEmpty())
+	assert.Equal(t, []uint64{5}, ba.ToNums())
+
+	assert.Nil(t, ba.ClearBit(5))
+	result, err = ba.GetBit(5)
+	assert.Nil(t, err)
+	assert.False(t, result)
+	assert.True(t, ba.IsEmpty())
+
+	assert.Equal(t, OutOfRangeError(100), ba.SetBit(100))
+	assert.Equal(t, uint64(64), ba.Capacity())
+	assert.Equal(t, uint64(8), ba.SizeInBytes())
+
+	ba.SetBit(3)
+	ba.Reset()
+	assert.True(t, ba.IsEmpty())
+}
+
+func TestThreadSafeWrapsOnce(t *testing.T) {
+	ba := NewThreadSafe(newSparseBitArray())
+	assert.True(t, ba == NewThreadSafe(ba))
+}
+
+func TestThreadSafeSetOperations(t *testing.T) {
+	dense := newBitArray(100)
+	dense.SetBit(3)
+	dense.SetBit(70)
+	sparse := newSparseBitArray()
+	sparse.SetBit(3)
+	sparse.SetBit(5)
+
+	for _, inner := range []BitArray{dense, sparse} {
+		ts := NewThreadSafe(copyBitArray(inner))
+		other := newSparseBitArray()
+		other.SetBit(3)
+		other.SetBit(7)
+		tsOther := NewThreadSafe(copyBitArray(other))
+
+		assert.Equal(t, inner.Or(other).ToNums(), ts.Or(tsOther).ToNums())
+		assert.Equal(t, inner.Or(other).ToNums(), ts.Or(other).ToNums())
+		assert.Equal(t, other.Or(inner).ToNums(), other.Or(ts).ToNums())
+		assert.Equal(t, inner.And(other).ToNums(), ts.And(tsOther).ToNums())
+		assert.Equal(t, inner.Nand(other).ToNums(), ts.Nand(tsOther).ToNums())
+		assert.Equal(t, other.Nand(inner).ToNums(), tsOther.Nand(ts).ToNums())
+
+		assert.True(t, ts.Equals(NewThreadSafe(copyBitArray(inner))))
+		assert.True(t, inner.Equals(ts))
+		assert.False(t, ts.Equals(tsOther))
+		assert.Equal(t, inner.Intersects(other), ts.Intersects(tsOther))
+		assert.Equal(t, inner.Contains(other), ts.Contains(tsOther))
+		assert.True(t, ts.Contains(ts))
+
+		// operations with itself must not deadlock
+		assert.Equal(t, inner.ToNums(), ts.Or(ts).ToNums())
+	}
+}
+
+func TestThreadSafeBlocksSnapshot(t *testing.T) {
+	ts := NewThreadSafe(newBitArray(100))
+	ts.SetBit(1)
+
+	iter := ts.Blocks()
+	ts.SetBit(2)
+
+	assert.True(t, iter.Next())
+	_, block := iter.Value()
+	assert.Equal(t, block, block.insert(1))
+	assert.NotEqual(t, block, block.insert(2))
+}
+
+func TestThreadSafeConcurrentAccess(t *testing.T) {
+	ts := NewThreadSafe(newSparseBitArray())
+	other := NewThreadSafe(newSparseBitArray())
+	var wg sync.WaitGroup
+	wg.Add(4)
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			for j := uint64(0); j < 1000; j++ {
+				ts.SetBit(j)
+				other.SetBit(j * 2)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for j := uint64(0); j < 1000; j++ {
+				ts.GetBit(j)
+				ts.Or(other)
+				other.And(ts)
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Len(t, ts.ToNums(), 1000)
+	assert.Len(t, other.ToNums(), 1000)
+}
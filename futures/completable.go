@@ -0,0 +1,60 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+// CompletableFuture is a future that is completed by whoever holds it
+// rather than by a channel or a function the package runs.  This
+// makes it easy to bridge callback based APIs into futures.  Only the
+// first call to Complete or Fail takes effect.
+type CompletableFuture struct {
+	Future
+}
+
+// Complete completes the future with the provided item.  Returns
+// false if the future was already completed, in which case nothing
+// changes.
+func (cf *CompletableFuture) Complete(item interface{}) bool {
+	return cf.complete(item, nil)
+}
+
+// Fail completes the future with the provided error.  Returns false if
+// the future was already completed, in which case nothing changes.
+func (cf *CompletableFuture) Fail(err error) bool {
+	return cf.complete(nil, err)
+}
+
+func (cf *CompletableFuture) complete(item interface{}, err error) bool {
+	cf.lock.Lock()
+	if cf.triggered {
+		cf.lock.Unlock()
+		return false
+	}
+	cf.triggered = true
+	cf.item = item
+	cf.err = err
+	cf.lock.Unlock()
+	cf.wg.Done()
+	return true
+}
+
+// NewCompletable returns a future that has no result until Complete
+// or Fail is called.  GetResult blocks until then.
+func NewCompletable() *CompletableFuture {
+	cf := &CompletableFuture{}
+	cf.wg.Add(1)
+	return cf
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletable(t *testing.T) {
+	cf := NewCompletable()
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			result, err := cf.GetResult()
+			assert.Nil(t, err)
+			assert.Equal(t, `test`, result)
+		}()
+	}
+
+	assert.True(t, cf.Complete(`test`))
+	wg.Wait()
+
+	assert.False(t, cf.Complete(`other`))
+	assert.False(t, cf.Fail(fmt.Errorf(`fail`)))
+	result, err := cf.GetResult()
+	assert.Nil(t, err)
+	assert.Equal(t, `test`, result)
+}
+
+func TestCompletableFail(t *testing.T) {
+	cf := NewCompletable()
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		cf.Fail(fmt.Errorf(`fail`))
+	}()
+
+	result, err := cf.GetResult()
+	assert.Nil(t, result)
+	assert.Equal(t, fmt.Errorf(`fail`), err)
+	assert.False(t, cf.Complete(`test`))
+}
+
+func TestCompletableRace(t *testing.T) {
+	cf := NewCompletable()
+	var wg sync.WaitGroup
+	results := make(chan bool, 10)
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func(i int) {
+			defer wg.Done()
+			results <- cf.Complete(i)
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	won := 0
+	for ok := range results {
+		if ok {
+			won++
+		}
+	}
+	assert.Equal(t, 1, won)
+}
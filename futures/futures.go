@@ -85,7 +85,12 @@ func listenForResult(f *Future, ch Completer, timeout time.Duration, wg *sync.Wa
 // notified.  If timeout is hit before toComplete is called,
 // any listeners will get passed an error.
 func New(completer Completer, timeout time.Duration) *Future {
-	f := &Future{}
+	return start(&Future{}, completer, timeout)
+}
+
+// start begins listening for the result of the provided future, which
+// must not have a result.
+func start(f *Future, completer Completer, timeout time.Duration) *Future {
 	f.wg.Add(1)
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -0,0 +1,68 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"sync"
+	"time"
+)
+
+// FuturePool reuses futures to reduce allocations in code that creates
+// many short lived futures.  A future taken from the pool with Get
+// behaves exactly like one returned by New.  Once a future is returned
+// with Put it may be handed out again, so Put must only be called when
+// nothing holds on to the future anymore: every GetResult call has
+// returned and no other goroutine will call it again.  Using a future
+// after it has been put back may return the result of a later use.
+// FuturePool is safe for concurrent use.
+type FuturePool struct {
+	pool sync.Pool
+}
+
+// Get returns a future from the pool, or a new one if the pool is
+// empty, that is completed by the provided completer in the same way
+// as a future returned by New.
+func (fp *FuturePool) Get(completer Completer, timeout time.Duration) *Future {
+	f, _ := fp.pool.Get().(*Future)
+	if f == nil {
+		f = &Future{}
+	}
+
+	return start(f, completer, timeout)
+}
+
+// Put resets the provided future and returns it to the pool.  A future
+// that has not completed yet is still in use and is not returned to the
+// pool.
+func (fp *FuturePool) Put(f *Future) {
+	if f == nil || !f.completed() {
+		return
+	}
+
+	f.lock.Lock()
+	f.triggered = false
+	f.item = nil
+	f.err = nil
+	f.lock.Unlock()
+
+	fp.pool.Put(f)
+}
+
+// NewFuturePool returns an empty pool of futures.
+func NewFuturePool() *FuturePool {
+	return &FuturePool{}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolReuse(t *testing.T) {
+	fp := NewFuturePool()
+
+	completer := make(chan interface{}, 1)
+	completer <- `first`
+	f := fp.Get(completer, 30*time.Minute)
+	result, err := f.GetResult()
+	assert.Nil(t, err)
+	assert.Equal(t, `first`, result)
+
+	fp.Put(f)
+	assert.False(t, f.completed())
+
+	// whether or not f is handed out again, the future must behave as
+	// if it were new
+	completer = make(chan interface{})
+	f = fp.Get(completer, 30*time.Minute)
+	assert.False(t, f.completed())
+
+	completer <- `second`
+	result, err = f.GetResult()
+	assert.Nil(t, err)
+	assert.Equal(t, `second`, result)
+}
+
+func TestPoolResetsError(t *testing.T) {
+	fp := NewFuturePool()
+
+	f := fp.Get(make(chan interface{}), 0)
+	_, err := f.GetResult()
+	assert.NotNil(t, err)
+	fp.Put(f)
+
+	completer := make(chan interface{}, 1)
+	completer <- `test`
+	f = fp.Get(completer, 30*time.Minute)
+	result, err := f.GetResult()
+	assert.Nil(t, err)
+	assert.Equal(t, `test`, result)
+}
+
+func TestPoolPutIncomplete(t *testing.T) {
+	fp := NewFuturePool()
+	completer := make(chan interface{})
+	f := fp.Get(completer, 30*time.Minute)
+
+	fp.Put(f)
+	fp.Put(nil)
+
+	completer <- `test`
+	result, err := f.GetResult()
+	assert.Nil(t, err)
+	assert.Equal(t, `test`, result)
+}
+
+func BenchmarkPool(b *testing.B) {
+	fp := NewFuturePool()
+	completer := make(chan interface{}, 1)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		completer <- i
+		f := fp.Get(completer, time.Minute)
+		f.GetResult()
+		fp.Put(f)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// ProgressComputation is a function executed by a progress future.
+// It may call report any number of times with the fraction of its
+// work that is complete.  A nil error signals success and the returned
+// item completes the future.
+type ProgressComputation func(report func(float64)) (interface{}, error)
+
+// progress delivers the fractions reported by a computation.  Only
+// the latest fraction is kept so a slow reader never holds up the
+// computation.
+type progress struct {
+	lock   sync.Mutex
+	ch     chan float64
+	closed bool
+}
+
+func (p *progress) report(fraction float64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed { // the computation is done, ignore stragglers
+		return
+	}
+
+	select {
+	case p.ch <- fraction:
+	default:
+		// replace the fraction that hasn't been read, only the reader
+		// will take from the channel so there is now room
+		select {
+		case <-p.ch:
+		default:
+		}
+		p.ch <- fraction
+	}
+}
+
+func (p *progress) close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	close(p.ch)
+}
+
+// NewWithProgress returns a future that is completed with the result
+// of fn, and a channel on which the fractions fn reports are
+// delivered.  If the channel is not read as fast as fn reports, only
+// the latest fraction is delivered.  The channel is closed once the
+// future has completed.  If fn panics the future resolves with a
+// *FuturePanicError.
+func NewWithProgress(fn ProgressComputation) (*Future, <-chan float64) {
+	f := &Future{}
+	f.wg.Add(1)
+	p := &progress{ch: make(chan float64, 1)}
+	go runWithProgress(f, fn, p)
+	return f, p.ch
+}
+
+func runWithProgress(f *Future, fn ProgressComputation, p *progress) {
+	defer p.close()
+	defer func() {
+		if r := recover(); r != nil {
+			f.setItem(nil, &FuturePanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+
+	item, err := fn(p.report)
+	f.setItem(item, err)
+}
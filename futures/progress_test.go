@@ -0,0 +1,96 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	proceed := make(chan struct{})
+	f, ch := NewWithProgress(func(report func(float64)) (interface{}, error) {
+		for i := 1; i <= 4; i++ {
+			<-proceed
+			report(float64(i) / 4)
+		}
+		return `done`, nil
+	})
+
+	for i := 1; i <= 4; i++ {
+		proceed <- struct{}{}
+		assert.Equal(t, float64(i)/4, <-ch)
+	}
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	result, err := f.GetResult()
+	assert.Nil(t, err)
+	assert.Equal(t, `done`, result)
+}
+
+func TestProgressKeepsLatest(t *testing.T) {
+	reported := make(chan struct{})
+	proceed := make(chan struct{})
+	f, ch := NewWithProgress(func(report func(float64)) (interface{}, error) {
+		for i := 1; i <= 10; i++ {
+			report(float64(i) / 10)
+		}
+		close(reported)
+		<-proceed
+		return nil, fmt.Errorf(`fail`)
+	})
+
+	<-reported
+	assert.Equal(t, float64(1), <-ch)
+	close(proceed)
+
+	_, ok := <-ch
+	assert.False(t, ok)
+	_, err := f.GetResult()
+	assert.Equal(t, fmt.Errorf(`fail`), err)
+}
+
+func TestProgressAfterCompletion(t *testing.T) {
+	var saved func(float64)
+	f, ch := NewWithProgress(func(report func(float64)) (interface{}, error) {
+		saved = report
+		return nil, nil
+	})
+
+	for range ch {
+	}
+	f.GetResult()
+	saved(.5) // must not panic on the closed channel
+}
+
+func TestProgressPanic(t *testing.T) {
+	f, ch := NewWithProgress(func(report func(float64)) (interface{}, error) {
+		panic(`boom`)
+	})
+
+	for range ch {
+	}
+	_, err := f.GetResult()
+	panicErr, ok := err.(*FuturePanicError)
+	if assert.True(t, ok) {
+		assert.Equal(t, `boom`, panicErr.Value)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+// ErrNoAttempts is returned by a retry future if it was asked to
+// make less than one attempt.
+var ErrNoAttempts = errors.New(`futures: attempts must be positive`)
+
+// FuturePanicError is the error a future resolves with when the
+// computation backing it panics.  Value is the value passed to panic
+// and Stack is the stack of the panicking goroutine.
+type FuturePanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns a human readable description of the panic.
+func (err *FuturePanicError) Error() string {
+	return fmt.Sprintf("futures: computation panicked: %v\n%s", err.Value, err.Stack)
+}
+
+// Computation is a function that is executed by a retry future.  A nil
+// error signals success and the returned item completes the future.
+type Computation func() (interface{}, error)
+
+// Backoff returns the duration to wait before the provided attempt
+// is made.  Attempts are numbered starting at 1 with the first retry,
+// the initial attempt is never delayed.
+type Backoff func(attempt int) time.Duration
+
+// Retry returns a future that is completed with the result of fn.  If
+// fn returns an error it is called again, up to a total of attempts
+// times, waiting the duration returned by backoff between calls.  The
+// future resolves with the first successful result or with the error
+// returned by the final attempt.  A nil backoff retries immediately.
+// If fn or backoff panics the future resolves immediately with a
+// *FuturePanicError and no further attempts are made.
+func Retry(fn Computation, attempts int, backoff Backoff) *Future {
+	return RetryContext(context.Background(), fn, attempts, backoff)
+}
+
+// RetryContext is identical to Retry except that retries are abandoned
+// once ctx is done.  In that case the future resolves with the context's
+// error.  An attempt that is already running is not interrupted.
+func RetryContext(ctx context.Context, fn Computation,
+	attempts int, backoff Backoff) *Future {
+
+	f := &Future{}
+	f.wg.Add(1)
+	go retry(ctx, f, fn, attempts, backoff)
+	return f
+}
+
+func retry(ctx context.Context, f *Future, fn Computation,
+	attempts int, backoff Backoff) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			f.setItem(nil, &FuturePanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+
+	if attempts < 1 {
+		f.setItem(nil, ErrNoAttempts)
+		return
+	}
+
+	var (
+		item interface{}
+		err  error
+	)
+	for i := 0; i < attempts; i++ {
+		if i > 0 && backoff != nil {
+			timer := time.NewTimer(backoff(i))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				f.setItem(nil, ctx.Err())
+				return
+			case <-timer.C:
+			}
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			f.setItem(nil, ctxErr)
+			return
+		}
+
+		item, err = fn()
+		if err == nil {
+			f.setItem(item, nil)
+			return
+		}
+	}
+
+	f.setItem(nil, err)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrySucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	attempts := []int{}
+	f := Retry(func() (interface{}, error) {
+		calls++
+		if calls < 3 {
+			return nil, fmt.Errorf(`fail %d`, calls)
+		}
+		return `test`, nil
+	}, 5, func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		return time.Millisecond
+	})
+
+	result, err := f.GetResult()
+	assert.Nil(t, err)
+	assert.Equal(t, `test`, result)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, attempts)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	calls := 0
+	f := Retry(func() (interface{}, error) {
+		calls++
+		return nil, fmt.Errorf(`fail %d`, calls)
+	}, 3, nil)
+
+	result, err := f.GetResult()
+	assert.Nil(t, result)
+	assert.Equal(t, fmt.Errorf(`fail 3`), err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryNoAttempts(t *testing.T) {
+	f := Retry(func() (interface{}, error) {
+		return `test`, nil
+	}, 0, nil)
+
+	result, err := f.GetResult()
+	assert.Nil(t, result)
+	assert.Equal(t, ErrNoAttempts, err)
+}
+
+func TestRetryContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	f := RetryContext(ctx, func() (interface{}, error) {
+		calls++
+		cancel()
+		return nil, fmt.Errorf(`fail`)
+	}, 5, func(attempt int) time.Duration {
+		return time.Hour
+	})
+
+	result, err := f.GetResult()
+	assert.Nil(t, result)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryPanic(t *testing.T) {
+	calls := 0
+	f := Retry(func() (interface{}, error) {
+		calls++
+		panic(`boom`)
+	}, 3, nil)
+
+	result, err := f.GetResult()
+	assert.Nil(t, result)
+	assert.Equal(t, 1, calls)
+
+	panicErr, ok := err.(*FuturePanicError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, `boom`, panicErr.Value)
+	assert.Contains(t, string(panicErr.Stack), `TestRetryPanic`)
+	assert.Contains(t, panicErr.Error(), `boom`)
+}
+
+func TestRetryBackoffPanic(t *testing.T) {
+	f := Retry(func() (interface{}, error) {
+		return nil, fmt.Errorf(`fail`)
+	}, 3, func(attempt int) time.Duration {
+		panic(fmt.Errorf(`backoff %d`, attempt))
+	})
+
+	_, err := f.GetResult()
+	panicErr, ok := err.(*FuturePanicError)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, fmt.Errorf(`backoff 1`), panicErr.Value)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrDeadlineExceeded is the error given for a future that had not
+// completed by the deadline passed to WaitAllWithDeadline.
+var ErrDeadlineExceeded = errors.New(`futures: deadline exceeded`)
+
+type indexedResult struct {
+	index int
+	item  interface{}
+	err   error
+}
+
+// completed returns a bool indicating if this future has a result.
+func (f *Future) completed() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.triggered
+}
+
+// WaitAllWithDeadline waits until every provided future has completed
+// or the deadline passes, whichever is first, and returns the result
+// and error of each future in the slot matching its position.  A
+// future that had not completed by the deadline is given
+// ErrDeadlineExceeded, while the results of those that did complete
+// are still returned.  Futures that are already complete are returned
+// even if the deadline has already passed.
+func WaitAllWithDeadline(deadline time.Time, futures ...*Future) ([]interface{}, []error) {
+	items := make([]interface{}, len(futures))
+	errs := make([]error, len(futures))
+	done := make([]bool, len(futures))
+
+	// buffered so the listeners never block if we stop waiting on them
+	results := make(chan indexedResult, len(futures))
+	pending := 0
+	for i, f := range futures {
+		if f.completed() {
+			items[i], errs[i] = f.GetResult()
+			done[i] = true
+			continue
+		}
+
+		pending++
+		go func(i int, f *Future) {
+			item, err := f.GetResult()
+			results <- indexedResult{index: i, item: item, err: err}
+		}(i, f)
+	}
+
+	if pending > 0 {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+
+		for pending > 0 {
+			select {
+			case r := <-results:
+				items[r.index], errs[r.index], done[r.index] = r.item, r.err, true
+				pending--
+			case <-timer.C:
+				pending = 0
+			}
+		}
+	}
+
+	for i := range futures {
+		if !done[i] {
+			errs[i] = ErrDeadlineExceeded
+		}
+	}
+
+	return items, errs
+}
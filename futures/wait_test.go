@@ -0,0 +1,78 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package futures
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitAllWithDeadline(t *testing.T) {
+	c1, c2 := make(chan interface{}, 1), make(chan interface{}, 1)
+	f1, f2 := New(c1, time.Minute), New(c2, time.Minute)
+	c1 <- `one`
+	c2 <- `two`
+
+	items, errs := WaitAllWithDeadline(time.Now().Add(time.Second), f1, f2)
+	assert.Equal(t, []interface{}{`one`, `two`}, items)
+	assert.Equal(t, []error{nil, nil}, errs)
+}
+
+func TestWaitAllWithDeadlinePartial(t *testing.T) {
+	c1, c2, c3 := make(chan interface{}, 1), make(chan interface{}), make(chan interface{}, 1)
+	f1, f2, f3 := New(c1, time.Minute), New(c2, time.Minute), New(c3, time.Minute)
+	c1 <- `one`
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		c3 <- `three`
+	}()
+
+	start := time.Now()
+	items, errs := WaitAllWithDeadline(start.Add(50*time.Millisecond), f1, f2, f3)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	assert.Equal(t, []interface{}{`one`, nil, `three`}, items)
+	assert.Equal(t, []error{nil, ErrDeadlineExceeded, nil}, errs)
+}
+
+func TestWaitAllWithDeadlineErrors(t *testing.T) {
+	f := Retry(func() (interface{}, error) {
+		return nil, fmt.Errorf(`fail`)
+	}, 1, nil)
+	f.GetResult()
+
+	// already completed futures are returned even past the deadline
+	items, errs := WaitAllWithDeadline(time.Now().Add(-time.Second), f)
+	assert.Equal(t, []interface{}{nil}, items)
+	assert.Equal(t, []error{fmt.Errorf(`fail`)}, errs)
+}
+
+func TestWaitAllWithDeadlinePassed(t *testing.T) {
+	f := New(make(chan interface{}), time.Minute)
+
+	items, errs := WaitAllWithDeadline(time.Now().Add(-time.Second), f)
+	assert.Equal(t, []interface{}{nil}, items)
+	assert.Equal(t, []error{ErrDeadlineExceeded}, errs)
+}
+
+func TestWaitAllWithDeadlineEmpty(t *testing.T) {
+	items, errs := WaitAllWithDeadline(time.Now())
+	assert.Len(t, items, 0)
+	assert.Len(t, errs, 0)
+}
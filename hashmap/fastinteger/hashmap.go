@@ -148,6 +148,33 @@ func (fi *FastIntegerHashMap) Delete(key uint64) {
 	}
 }
 
+// ForEachDeletable calls fn with every key and value in the hashmap
+// and deletes the entry if fn returns true.  Every entry is visited
+// exactly once even as entries are deleted.  fn must not otherwise
+// modify the hashmap.
+func (fi *FastIntegerHashMap) ForEachDeletable(fn func(key, value uint64) bool) {
+	// a delete shifts the rest of its cluster back toward the deleted
+	// bucket, so starting after an empty bucket means no cluster wraps
+	// past the start and a shifted entry never lands in a bucket that
+	// has already been visited
+	start := 0
+	for start < len(fi.packets) && fi.packets[start] != nil {
+		start++
+	}
+
+	mask := uint64(len(fi.packets)) - 1
+	for n := 0; n < len(fi.packets); {
+		p := fi.packets[(uint64(start+n))&mask]
+		if p == nil || !fn(p.key, p.value) {
+			n++
+			continue
+		}
+
+		// this bucket may now hold a shifted entry so look at it again
+		fi.Delete(p.key)
+	}
+}
+
 // Len returns the number of items in the hashmap.
 func (fi *FastIntegerHashMap) Len() uint64 {
 	return fi.count
@@ -158,6 +185,52 @@ func (fi *FastIntegerHashMap) Cap() uint64 {
 	return uint64(len(fi.packets))
 }
 
+// MapStats describes how well keys are distributed across the
+// buckets of a FastIntegerHashMap.  Probe length is the number of
+// buckets that must be examined to find a key, so a key sitting in
+// the bucket it hashes to has a probe length of 1.
+type MapStats struct {
+	// LoadFactor is the fraction of buckets that are occupied.
+	LoadFactor float64
+	// AverageProbeLength is the mean probe length over all keys.
+	AverageProbeLength float64
+	// MaxProbeLength is the longest probe length of any key.
+	MaxProbeLength uint64
+}
+
+// Stats scans the occupied buckets of the hashmap and returns the
+// resulting statistics.  This is O(n) in the capacity of the map
+// and is intended for tuning rather than regular use.
+func (fi *FastIntegerHashMap) Stats() MapStats {
+	var stats MapStats
+	if len(fi.packets) == 0 {
+		return stats
+	}
+
+	mask := uint64(len(fi.packets)) - 1
+	var occupied, total uint64
+	for i, packet := range fi.packets {
+		if packet == nil {
+			continue
+		}
+
+		occupied++
+		probe := ((uint64(i) - hash(packet.key)) & mask) + 1
+		total += probe
+		if probe > stats.MaxProbeLength {
+			stats.MaxProbeLength = probe
+		}
+	}
+
+	if occupied == 0 {
+		return stats
+	}
+
+	stats.LoadFactor = float64(occupied) / float64(len(fi.packets))
+	stats.AverageProbeLength = float64(total) / float64(occupied)
+	return stats
+}
+
 // New returns a new FastIntegerHashMap with a bucket size specified
 // by hint.
 func New(hint uint64) *FastIntegerHashMap {
@@ -138,6 +138,108 @@ func TestDeleteCollision(t *testing.T) {
 	assert.Equal(t, uint64(42), value)
 }
 
+func TestForEachDeletable(t *testing.T) {
+	m := New(10)
+	keys := generateKeys(1000)
+	expected := make(map[uint64]uint64, len(keys))
+	for i, key := range keys {
+		m.Set(key, uint64(i))
+		expected[key] = uint64(i)
+	}
+
+	visited := make(map[uint64]int, len(keys))
+	m.ForEachDeletable(func(key, value uint64) bool {
+		visited[key]++
+		assert.Equal(t, expected[key], value)
+		return value%2 == 0
+	})
+
+	assert.Len(t, visited, len(expected))
+	for key, value := range expected {
+		assert.Equal(t, 1, visited[key])
+		assert.Equal(t, value%2 != 0, m.Exists(key))
+	}
+	assert.Equal(t, uint64(len(expected)/2), m.Len())
+}
+
+func TestForEachDeletableWrappedCollisions(t *testing.T) {
+	m := New(32)
+	// keys that all hash to the last bucket so their cluster wraps
+	// around to the start of the buckets
+	keys := make([]uint64, 0, 4)
+	for key := uint64(0); len(keys) < cap(keys); key++ {
+		if hash(key)%32 == 31 {
+			keys = append(keys, key)
+			m.Set(key, key)
+		}
+	}
+
+	visited := make([]uint64, 0, len(keys))
+	m.ForEachDeletable(func(key, value uint64) bool {
+		visited = append(visited, key)
+		return key != keys[3]
+	})
+
+	assert.Len(t, visited, len(keys))
+	for _, key := range keys {
+		assert.Contains(t, visited, key)
+	}
+	assert.Equal(t, uint64(1), m.Len())
+	value, ok := m.Get(keys[3])
+	assert.True(t, ok)
+	assert.Equal(t, keys[3], value)
+}
+
+func TestForEachDeletableEmpty(t *testing.T) {
+	m := New(10)
+	m.ForEachDeletable(func(key, value uint64) bool {
+		t.Error(`no entries should be visited`)
+		return true
+	})
+}
+
+func TestStats(t *testing.T) {
+	hm := New(10)
+	assert.Equal(t, MapStats{}, hm.Stats())
+
+	hm.Set(5, 5)
+	stats := hm.Stats()
+	assert.Equal(t, float64(1)/16, stats.LoadFactor)
+	assert.Equal(t, float64(1), stats.AverageProbeLength)
+	assert.Equal(t, uint64(1), stats.MaxProbeLength)
+
+	// force keys into the same bucket
+	hm = New(16)
+	mask := hm.Cap() - 1
+	keys := make([]uint64, 0, 3)
+	for key := uint64(0); len(keys) < 3; key++ {
+		if hash(key)&mask == 0 {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		hm.Set(key, key)
+	}
+
+	stats = hm.Stats()
+	assert.Equal(t, float64(3)/16, stats.LoadFactor)
+	assert.Equal(t, float64(2), stats.AverageProbeLength)
+	assert.Equal(t, uint64(3), stats.MaxProbeLength)
+}
+
+func TestStatsAfterRebuild(t *testing.T) {
+	keys := generateKeys(1000)
+	hm := New(4)
+	for _, key := range keys {
+		hm.Set(key, key)
+	}
+
+	stats := hm.Stats()
+	assert.True(t, stats.LoadFactor > 0 && stats.LoadFactor <= ratio)
+	assert.True(t, stats.AverageProbeLength >= 1)
+	assert.True(t, float64(stats.MaxProbeLength) >= stats.AverageProbeLength)
+}
+
 func BenchmarkInsert(b *testing.B) {
 	numItems := uint64(1000)
 
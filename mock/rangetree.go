@@ -40,6 +40,26 @@ func (m *RangeTree) Query(interval rangetree.Interval) rangetree.Entries {
 	return ifc.(rangetree.Entries)
 }
 
+func (m *RangeTree) QueryLimit(interval rangetree.Interval, limit int) rangetree.Entries {
+	args := m.Called(interval, limit)
+	ifc := args.Get(0)
+	if ifc == nil {
+		return nil
+	}
+
+	return ifc.(rangetree.Entries)
+}
+
+func (m *RangeTree) QueryReverse(interval rangetree.Interval) rangetree.Entries {
+	args := m.Called(interval)
+	ifc := args.Get(0)
+	if ifc == nil {
+		return nil
+	}
+
+	return ifc.(rangetree.Entries)
+}
+
 func (m *RangeTree) InsertAtDimension(dimension uint64, index,
 	number int64) (rangetree.Entries, rangetree.Entries) {
 
@@ -47,6 +67,22 @@ func (m *RangeTree) InsertAtDimension(dimension uint64, index,
 	return args.Get(0).(rangetree.Entries), args.Get(1).(rangetree.Entries)
 }
 
+func (m *RangeTree) Histogram(dimension uint64, bucketSize int64) map[int64]uint64 {
+	ifc := m.Called(dimension, bucketSize).Get(0)
+	if ifc == nil {
+		return nil
+	}
+
+	return ifc.(map[int64]uint64)
+}
+
+func (m *RangeTree) InsertAtDimensionClamp(dimension uint64, index,
+	number int64) (rangetree.Entries, rangetree.Entries) {
+
+	args := m.Called(dimension, index, number)
+	return args.Get(0).(rangetree.Entries), args.Get(1).(rangetree.Entries)
+}
+
 func (m *RangeTree) Apply(interval rangetree.Interval, fn func(rangetree.Entry) bool) {
 	m.Called(interval, fn)
 }
@@ -0,0 +1,256 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// DropPolicy determines what a BroadcastQueue does when a subscriber's
+// buffer is full.
+type DropPolicy int
+
+const (
+	// Block causes Put to wait until every subscriber has room for
+	// the item.  A slow subscriber slows down all producers.
+	Block DropPolicy = iota
+	// DropNewest discards the item being put for any subscriber whose
+	// buffer is full.
+	DropNewest
+	// DropOldest discards the oldest buffered item of any subscriber
+	// whose buffer is full to make room for the item being put.
+	DropOldest
+)
+
+type subscriber struct {
+	ch   chan interface{}
+	quit chan struct{}
+	once sync.Once
+}
+
+func (s *subscriber) stop() {
+	s.once.Do(func() {
+		close(s.quit)
+	})
+}
+
+// BroadcastQueue delivers every item that is put to every subscriber,
+// as opposed to Queue which delivers each item to a single consumer.
+// Each subscriber receives items in the order in which they were put
+// on a buffered channel.  What happens when that buffer is full is set
+// by the queue's DropPolicy.
+type BroadcastQueue struct {
+	// putLock serializes puts so each subscriber sees the same
+	// order and guards closing subscriber channels.  lock guards
+	// the list of subscribers and is never held while blocking.
+	putLock     sync.Mutex
+	lock        sync.Mutex
+	subscribers []*subscriber
+	bufferSize  int
+	policy      DropPolicy
+	dropped     uint64
+	done        chan struct{}
+	disposed    uint64
+}
+
+// Subscribe registers a new consumer and returns the channel on which
+// it will receive every item put from now on.  The channel is closed
+// when the consumer unsubscribes or the queue is disposed.  Subscribing
+// to a disposed queue returns a closed channel.
+func (bq *BroadcastQueue) Subscribe() <-chan interface{} {
+	sub := &subscriber{
+		ch:   make(chan interface{}, bq.bufferSize),
+		quit: make(chan struct{}),
+	}
+
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	if bq.Disposed() {
+		close(sub.ch)
+		return sub.ch
+	}
+
+	bq.subscribers = append(bq.subscribers, sub)
+	return sub.ch
+}
+
+// Unsubscribe removes the consumer that owns the provided channel and
+// closes that channel.  Any Put blocked on that consumer is released.
+// Unsubscribing an unknown channel is a no-op.
+func (bq *BroadcastQueue) Unsubscribe(ch <-chan interface{}) {
+	sub := bq.remove(ch)
+	if sub == nil {
+		return
+	}
+
+	// releases a Put that is blocked on this subscriber so that
+	// we are able to acquire the put lock
+	sub.stop()
+
+	bq.putLock.Lock()
+	close(sub.ch)
+	bq.putLock.Unlock()
+}
+
+func (bq *BroadcastQueue) remove(ch <-chan interface{}) *subscriber {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	for i, s := range bq.subscribers {
+		if (<-chan interface{})(s.ch) == ch {
+			copy(bq.subscribers[i:], bq.subscribers[i+1:])
+			bq.subscribers[len(bq.subscribers)-1] = nil
+			bq.subscribers = bq.subscribers[:len(bq.subscribers)-1]
+			return s
+		}
+	}
+
+	return nil
+}
+
+// Put delivers the provided items, in order, to every current
+// subscriber.  Depending on the DropPolicy this may block until every
+// subscriber has room.  An error is returned if the queue is disposed.
+func (bq *BroadcastQueue) Put(items ...interface{}) error {
+	bq.putLock.Lock()
+	defer bq.putLock.Unlock()
+
+	if bq.Disposed() {
+		return ErrDisposed
+	}
+
+	bq.lock.Lock()
+	subscribers := make([]*subscriber, len(bq.subscribers))
+	copy(subscribers, bq.subscribers)
+	bq.lock.Unlock()
+
+	for _, item := range items {
+		for _, sub := range subscribers {
+			if !bq.deliver(sub, item) {
+				return ErrDisposed
+			}
+		}
+	}
+
+	return nil
+}
+
+// deliver sends item to the provided subscriber according to the drop
+// policy.  Returns false if the queue was disposed while blocked.
+func (bq *BroadcastQueue) deliver(sub *subscriber, item interface{}) bool {
+	switch bq.policy {
+	case DropNewest:
+		select {
+		case sub.ch <- item:
+		default:
+			atomic.AddUint64(&bq.dropped, 1)
+		}
+	case DropOldest:
+		if cap(sub.ch) == 0 { // there is no oldest item to drop
+			select {
+			case sub.ch <- item:
+			default:
+				atomic.AddUint64(&bq.dropped, 1)
+			}
+			return true
+		}
+		for {
+			select {
+			case sub.ch <- item:
+				return true
+			default:
+			}
+
+			select {
+			case <-sub.ch:
+				atomic.AddUint64(&bq.dropped, 1)
+			default:
+				// the consumer made room for us
+			}
+		}
+	default:
+		select {
+		case sub.ch <- item:
+		case <-sub.quit:
+			// this subscriber is leaving, skip it
+		case <-bq.done:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Subscribers returns the number of registered subscribers.
+func (bq *BroadcastQueue) Subscribers() int {
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	return len(bq.subscribers)
+}
+
+// Dropped returns the number of deliveries that have been discarded
+// because of the drop policy.  An item dropped for two subscribers
+// counts twice.
+func (bq *BroadcastQueue) Dropped() uint64 {
+	return atomic.LoadUint64(&bq.dropped)
+}
+
+// Disposed returns a bool indicating if this queue has had
+// Dispose called on it.
+func (bq *BroadcastQueue) Disposed() bool {
+	return atomic.LoadUint64(&bq.disposed) == 1
+}
+
+// Dispose will dispose of this queue, closing every subscriber's
+// channel and releasing any blocked Put.  Subscribers can still
+// receive items that were buffered before the queue was disposed.
+func (bq *BroadcastQueue) Dispose() {
+	if !atomic.CompareAndSwapUint64(&bq.disposed, 0, 1) {
+		return
+	}
+
+	close(bq.done)
+
+	bq.putLock.Lock()
+	defer bq.putLock.Unlock()
+	bq.lock.Lock()
+	defer bq.lock.Unlock()
+
+	for _, sub := range bq.subscribers {
+		sub.stop()
+		close(sub.ch)
+	}
+	bq.subscribers = nil
+}
+
+// NewBroadcastQueue returns a new BroadcastQueue.  Every subscriber is
+// given a buffer of bufferSize items and the policy determines what
+// happens when that buffer is full.
+func NewBroadcastQueue(bufferSize int, policy DropPolicy) *BroadcastQueue {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+
+	return &BroadcastQueue{
+		bufferSize: bufferSize,
+		policy:     policy,
+		done:       make(chan struct{}),
+	}
+}
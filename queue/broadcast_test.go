@@ -0,0 +1,177 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func drain(ch <-chan interface{}) []interface{} {
+	result := []interface{}{}
+	for item := range ch {
+		result = append(result, item)
+	}
+
+	return result
+}
+
+func TestBroadcastDeliversToAll(t *testing.T) {
+	bq := NewBroadcastQueue(10, Block)
+	s1, s2 := bq.Subscribe(), bq.Subscribe()
+	assert.Equal(t, 2, bq.Subscribers())
+
+	err := bq.Put(1, 2, 3)
+	assert.Nil(t, err)
+	bq.Dispose()
+
+	assert.Equal(t, []interface{}{1, 2, 3}, drain(s1))
+	assert.Equal(t, []interface{}{1, 2, 3}, drain(s2))
+	assert.Equal(t, uint64(0), bq.Dropped())
+}
+
+func TestBroadcastBlock(t *testing.T) {
+	bq := NewBroadcastQueue(1, Block)
+	s1 := bq.Subscribe()
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		assert.Nil(t, bq.Put(1, 2, 3))
+	}()
+
+	assert.Equal(t, 1, <-s1)
+	assert.Equal(t, 2, <-s1)
+	assert.Equal(t, 3, <-s1)
+	wg.Wait()
+}
+
+func TestBroadcastDropNewest(t *testing.T) {
+	bq := NewBroadcastQueue(2, DropNewest)
+	s1 := bq.Subscribe()
+
+	assert.Nil(t, bq.Put(1, 2, 3, 4))
+	assert.Equal(t, uint64(2), bq.Dropped())
+	bq.Dispose()
+	assert.Equal(t, []interface{}{1, 2}, drain(s1))
+}
+
+func TestBroadcastDropOldest(t *testing.T) {
+	bq := NewBroadcastQueue(2, DropOldest)
+	s1 := bq.Subscribe()
+
+	assert.Nil(t, bq.Put(1, 2, 3, 4))
+	assert.Equal(t, uint64(2), bq.Dropped())
+	bq.Dispose()
+	assert.Equal(t, []interface{}{3, 4}, drain(s1))
+}
+
+func TestBroadcastDropOldestUnbuffered(t *testing.T) {
+	bq := NewBroadcastQueue(0, DropOldest)
+	bq.Subscribe()
+
+	assert.Nil(t, bq.Put(1))
+	assert.Equal(t, uint64(1), bq.Dropped())
+}
+
+func TestBroadcastUnsubscribe(t *testing.T) {
+	bq := NewBroadcastQueue(2, Block)
+	s1, s2 := bq.Subscribe(), bq.Subscribe()
+	assert.Nil(t, bq.Put(1))
+
+	bq.Unsubscribe(s1)
+	assert.Equal(t, 1, bq.Subscribers())
+	assert.Equal(t, []interface{}{1}, drain(s1))
+
+	assert.Nil(t, bq.Put(2))
+	bq.Dispose()
+	assert.Equal(t, []interface{}{1, 2}, drain(s2))
+
+	// unknown channels are ignored
+	bq.Unsubscribe(make(chan interface{}))
+}
+
+func TestBroadcastUnsubscribeReleasesPut(t *testing.T) {
+	bq := NewBroadcastQueue(0, Block)
+	s1 := bq.Subscribe()
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		assert.Nil(t, bq.Put(1))
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	bq.Unsubscribe(s1)
+	wg.Wait()
+	assert.Equal(t, 0, bq.Subscribers())
+}
+
+func TestBroadcastDisposeReleasesPut(t *testing.T) {
+	bq := NewBroadcastQueue(0, Block)
+	bq.Subscribe()
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		err = bq.Put(1)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	bq.Dispose()
+	wg.Wait()
+
+	assert.Equal(t, ErrDisposed, err)
+	assert.True(t, bq.Disposed())
+	assert.Equal(t, ErrDisposed, bq.Put(2))
+
+	_, ok := <-bq.Subscribe()
+	assert.False(t, ok)
+
+	// disposing twice is a no-op
+	bq.Dispose()
+}
+
+func BenchmarkBroadcast(b *testing.B) {
+	bq := NewBroadcastQueue(64, Block)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		ch := bq.Subscribe()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range ch {
+			}
+		}()
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		bq.Put(i)
+	}
+
+	bq.Dispose()
+	wg.Wait()
+}
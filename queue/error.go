@@ -29,4 +29,8 @@ var (
 	// ErrEmptyQueue is returned when an non-applicable queue operation was called
 	// due to the queue's empty item state
 	ErrEmptyQueue = errors.New(`queue: empty queue`)
+
+	// ErrUnknownClass is returned when an item is put in a class that a
+	// weighted fair queue does not have.
+	ErrUnknownClass = errors.New(`queue: unknown class`)
 )
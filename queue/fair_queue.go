@@ -0,0 +1,227 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import "sync"
+
+// fairClass is one class of a WeightedFairQueue.
+type fairClass struct {
+	items  []interface{}
+	weight int
+}
+
+// WeightedFairQueue holds items in a number of classes, each with a
+// weight, and serves the classes in turn so that over time each is
+// given a share of the gets in proportion to its weight.  This uses
+// deficit round robin: on its turn a class may give up to its weight in
+// items before the turn passes to the next class.  A class that runs
+// out of items loses the rest of its turn, so empty classes never hold
+// up the others.  Within a class items are given in the order they were
+// put.
+type WeightedFairQueue struct {
+	waiters     waiters
+	classes     []fairClass
+	current     int // the class whose turn it is
+	remaining   int // items left in the current turn
+	number      int
+	lock        sync.Mutex
+	disposeLock sync.Mutex
+	disposed    bool
+}
+
+// Put adds items to the provided class, which is the index of its
+// weight as given to the constructor.
+func (wfq *WeightedFairQueue) Put(class int, items ...interface{}) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	wfq.lock.Lock()
+	defer wfq.lock.Unlock()
+
+	if wfq.disposed {
+		return ErrDisposed
+	}
+
+	if class < 0 || class >= len(wfq.classes) {
+		return ErrUnknownClass
+	}
+
+	wfq.classes[class].items = append(wfq.classes[class].items, items...)
+	wfq.number += len(items)
+
+	for {
+		sema := wfq.waiters.get()
+		if sema == nil {
+			break
+		}
+
+		sema.response.Add(1)
+		sema.ready <- true
+		sema.response.Wait()
+		if wfq.number == 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+// next removes and returns the next item to be served.  There must be
+// at least one item in the queue.
+func (wfq *WeightedFairQueue) next() interface{} {
+	for {
+		class := &wfq.classes[wfq.current]
+		if len(class.items) > 0 && wfq.remaining > 0 {
+			item := class.items[0]
+			class.items[0] = nil // let the item be collected
+			class.items = class.items[1:]
+			wfq.remaining--
+			wfq.number--
+			if wfq.remaining == 0 || len(class.items) == 0 {
+				wfq.advance()
+			}
+			return item
+		}
+
+		wfq.advance()
+	}
+}
+
+// advance passes the turn to the next class.
+func (wfq *WeightedFairQueue) advance() {
+	wfq.current = (wfq.current + 1) % len(wfq.classes)
+	wfq.remaining = wfq.classes[wfq.current].weight
+}
+
+func (wfq *WeightedFairQueue) get(number int) []interface{} {
+	if number > wfq.number {
+		number = wfq.number
+	}
+
+	items := make([]interface{}, 0, number)
+	for i := 0; i < number; i++ {
+		items = append(items, wfq.next())
+	}
+
+	return items
+}
+
+// Get retrieves up to number items from the queue, taking them from the
+// classes in turn.  If the queue is empty, this call blocks until the
+// next item is added to the queue.
+func (wfq *WeightedFairQueue) Get(number int) ([]interface{}, error) {
+	if number < 1 {
+		return nil, nil
+	}
+
+	wfq.lock.Lock()
+
+	if wfq.disposed {
+		wfq.lock.Unlock()
+		return nil, ErrDisposed
+	}
+
+	var items []interface{}
+
+	if wfq.number == 0 {
+		sema := newSema()
+		wfq.waiters.put(sema)
+		wfq.lock.Unlock()
+
+		<-sema.ready
+
+		if wfq.Disposed() {
+			return nil, ErrDisposed
+		}
+
+		items = wfq.get(number)
+		sema.response.Done()
+		return items, nil
+	}
+
+	items = wfq.get(number)
+	wfq.lock.Unlock()
+	return items, nil
+}
+
+// Empty returns a bool indicating if there are any items left
+// in the queue.
+func (wfq *WeightedFairQueue) Empty() bool {
+	wfq.lock.Lock()
+	defer wfq.lock.Unlock()
+
+	return wfq.number == 0
+}
+
+// Len returns a number indicating how many items are in the queue.
+func (wfq *WeightedFairQueue) Len() int {
+	wfq.lock.Lock()
+	defer wfq.lock.Unlock()
+
+	return wfq.number
+}
+
+// Disposed returns a bool indicating if this queue has been disposed.
+func (wfq *WeightedFairQueue) Disposed() bool {
+	wfq.disposeLock.Lock()
+	defer wfq.disposeLock.Unlock()
+
+	return wfq.disposed
+}
+
+// Dispose will prevent any further reads/writes to this queue
+// and frees available resources.
+func (wfq *WeightedFairQueue) Dispose() {
+	wfq.lock.Lock()
+	defer wfq.lock.Unlock()
+
+	wfq.disposeLock.Lock()
+	defer wfq.disposeLock.Unlock()
+
+	wfq.disposed = true
+	for _, waiter := range wfq.waiters {
+		waiter.response.Add(1)
+		waiter.ready <- true
+	}
+
+	wfq.classes = nil
+	wfq.number = 0
+	wfq.waiters = nil
+}
+
+// NewWeightedFairQueue is the constructor for a weighted fair queue
+// with a class for each of the provided weights.  A weight less than
+// one is treated as one.  This panics if no weights are provided.
+func NewWeightedFairQueue(weights ...int) *WeightedFairQueue {
+	if len(weights) == 0 {
+		panic(`queue: weighted fair queue needs at least one class`)
+	}
+
+	classes := make([]fairClass, len(weights))
+	for i, weight := range weights {
+		if weight < 1 {
+			weight = 1
+		}
+		classes[i].weight = weight
+	}
+
+	return &WeightedFairQueue{
+		classes:   classes,
+		remaining: classes[0].weight,
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedFairQueueProportional(t *testing.T) {
+	q := NewWeightedFairQueue(3, 1)
+	for i := 0; i < 6; i++ {
+		q.Put(0, `a`)
+		q.Put(1, `b`)
+	}
+	assert.Equal(t, 12, q.Len())
+
+	result, err := q.Get(8)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{`a`, `a`, `a`, `b`, `a`, `a`, `a`, `b`}, result)
+
+	// the first class is out of items so the second gets every turn
+	result, err = q.Get(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{`b`, `b`, `b`, `b`}, result)
+	assert.True(t, q.Empty())
+}
+
+func TestWeightedFairQueueSkipsEmpty(t *testing.T) {
+	q := NewWeightedFairQueue(2, 2, 2)
+	q.Put(0, 1, 2, 3)
+	q.Put(2, 7, 8, 9)
+
+	result, err := q.Get(6)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2, 7, 8, 3, 9}, result)
+}
+
+func TestWeightedFairQueueOrderWithinClass(t *testing.T) {
+	q := NewWeightedFairQueue(1, 0)
+	q.Put(1, 4, 5)
+	q.Put(0, 1, 2)
+
+	result, err := q.Get(4)
+	assert.Nil(t, err)
+	// the turn starts with the first class, weights below one count as one
+	assert.Equal(t, []interface{}{1, 4, 2, 5}, result)
+}
+
+func TestWeightedFairQueueUnknownClass(t *testing.T) {
+	q := NewWeightedFairQueue(1)
+
+	assert.Equal(t, ErrUnknownClass, q.Put(1, `a`))
+	assert.Equal(t, ErrUnknownClass, q.Put(-1, `a`))
+	assert.Nil(t, q.Put(1))
+	assert.True(t, q.Empty())
+}
+
+func TestWeightedFairQueueNoWeights(t *testing.T) {
+	assert.Panics(t, func() { NewWeightedFairQueue() })
+}
+
+func TestWeightedFairQueueGetEmpty(t *testing.T) {
+	q := NewWeightedFairQueue(1, 1)
+
+	go func() {
+		q.Put(1, `a`)
+	}()
+
+	result, err := q.Get(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{`a`}, result)
+
+	result, err = q.Get(0)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+}
+
+func TestWeightedFairQueueGetWithDispose(t *testing.T) {
+	q := NewWeightedFairQueue(1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	var err error
+	go func() {
+		wg.Done()
+		_, err = q.Get(1)
+		wg.Done()
+	}()
+
+	wg.Wait()
+	wg.Add(1)
+
+	q.Dispose()
+
+	wg.Wait()
+
+	assert.Equal(t, ErrDisposed, err)
+	assert.True(t, q.Disposed())
+	assert.Equal(t, ErrDisposed, q.Put(0, `a`))
+	_, err = q.Get(1)
+	assert.Equal(t, ErrDisposed, err)
+}
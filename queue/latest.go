@@ -0,0 +1,93 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import "sync"
+
+// LatestN is a circular buffer that keeps only the most recently put
+// items.  Unlike RingBuffer, Put never blocks: once the buffer is full
+// each new item overwrites the oldest one.  This is useful for keeping
+// a rolling window of recent events.  LatestN is safe for concurrent
+// use.
+type LatestN struct {
+	lock  sync.Mutex
+	items []interface{}
+	// next is the index the next item is written to and, once the
+	// buffer is full, the index of the oldest item
+	next int
+	full bool
+}
+
+// Put adds the provided items to the buffer in order, overwriting the
+// oldest items if there is no room for them.
+func (ln *LatestN) Put(items ...interface{}) {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	for _, item := range items {
+		ln.items[ln.next] = item
+		ln.next++
+		if ln.next == len(ln.items) {
+			ln.next = 0
+			ln.full = true
+		}
+	}
+}
+
+// Snapshot returns a copy of the items currently held, ordered from
+// oldest to newest.
+func (ln *LatestN) Snapshot() []interface{} {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if !ln.full {
+		result := make([]interface{}, ln.next)
+		copy(result, ln.items[:ln.next])
+		return result
+	}
+
+	result := make([]interface{}, 0, len(ln.items))
+	result = append(result, ln.items[ln.next:]...)
+	return append(result, ln.items[:ln.next]...)
+}
+
+// Len returns the number of items currently held.
+func (ln *LatestN) Len() int {
+	ln.lock.Lock()
+	defer ln.lock.Unlock()
+
+	if ln.full {
+		return len(ln.items)
+	}
+	return ln.next
+}
+
+// Cap returns the number of items this buffer is able to hold.
+func (ln *LatestN) Cap() int {
+	return len(ln.items)
+}
+
+// NewLatestN returns a buffer that holds the n most recently put items.
+// An n less than 1 is treated as 1.
+func NewLatestN(n int) *LatestN {
+	if n < 1 {
+		n = 1
+	}
+	return &LatestN{
+		items: make([]interface{}, n),
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestN(t *testing.T) {
+	ln := NewLatestN(3)
+	assert.Equal(t, 3, ln.Cap())
+	assert.Equal(t, []interface{}{}, ln.Snapshot())
+
+	ln.Put(1, 2)
+	assert.Equal(t, 2, ln.Len())
+	assert.Equal(t, []interface{}{1, 2}, ln.Snapshot())
+
+	ln.Put(3)
+	assert.Equal(t, []interface{}{1, 2, 3}, ln.Snapshot())
+
+	ln.Put(4)
+	assert.Equal(t, 3, ln.Len())
+	assert.Equal(t, []interface{}{2, 3, 4}, ln.Snapshot())
+
+	ln.Put(5, 6, 7, 8)
+	assert.Equal(t, []interface{}{6, 7, 8}, ln.Snapshot())
+}
+
+func TestLatestNSnapshotIsCopy(t *testing.T) {
+	ln := NewLatestN(2)
+	ln.Put(1, 2)
+
+	snapshot := ln.Snapshot()
+	ln.Put(3)
+	assert.Equal(t, []interface{}{1, 2}, snapshot)
+	assert.Equal(t, []interface{}{2, 3}, ln.Snapshot())
+}
+
+func TestLatestNZeroCapacity(t *testing.T) {
+	ln := NewLatestN(0)
+	assert.Equal(t, 1, ln.Cap())
+
+	ln.Put(1, 2)
+	assert.Equal(t, []interface{}{2}, ln.Snapshot())
+}
+
+func TestLatestNConcurrentPut(t *testing.T) {
+	ln := NewLatestN(10)
+	var wg sync.WaitGroup
+	wg.Add(4)
+
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				ln.Put(j)
+				ln.Snapshot()
+			}
+		}()
+	}
+
+	wg.Wait()
+	assert.Equal(t, 10, ln.Len())
+}
+
+func BenchmarkLatestNPut(b *testing.B) {
+	ln := NewLatestN(64)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ln.Put(i)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+// mergeHead is the next item of one of the queues being merged.
+type mergeHead struct {
+	key   int64
+	queue int // index of the queue, used to break ties
+	items []interface{}
+}
+
+func (mh *mergeHead) less(other *mergeHead) bool {
+	if mh.key == other.key {
+		return mh.queue < other.queue
+	}
+	return mh.key < other.key
+}
+
+// mergeHeads is a min heap of queue heads.
+type mergeHeads []*mergeHead
+
+func (mh mergeHeads) down(i int) {
+	for {
+		smallest := i
+		left, right := 2*i+1, 2*i+2
+		if left < len(mh) && mh[left].less(mh[smallest]) {
+			smallest = left
+		}
+		if right < len(mh) && mh[right].less(mh[smallest]) {
+			smallest = right
+		}
+		if smallest == i {
+			return
+		}
+		mh[i], mh[smallest] = mh[smallest], mh[i]
+		i = smallest
+	}
+}
+
+// MergeQueues will merge the items in the provided queues, each of
+// which must already be sorted by the provided key, into a single new
+// queue sorted by that key.  Items with equal keys keep the order of
+// the queues they came from.  Every item is taken from the provided
+// queues, which are left empty but are not disposed, so they may be
+// used again.  Nil and disposed queues are ignored.
+func MergeQueues(key func(interface{}) int64, queues ...*Queue) *Queue {
+	heads := make(mergeHeads, 0, len(queues))
+	total := 0
+	for i, q := range queues {
+		if q == nil {
+			continue
+		}
+
+		items, err := q.TakeUntil(func(interface{}) bool { return true })
+		if err != nil || len(items) == 0 {
+			continue
+		}
+
+		total += len(items)
+		heads = append(heads, &mergeHead{
+			key:   key(items[0]),
+			queue: i,
+			items: items,
+		})
+	}
+
+	for i := len(heads)/2 - 1; i >= 0; i-- {
+		heads.down(i)
+	}
+
+	merged := New(int64(total))
+	for len(heads) > 0 {
+		head := heads[0]
+		merged.items = append(merged.items, head.items[0])
+		head.items[0] = nil
+		head.items = head.items[1:]
+		if len(head.items) == 0 {
+			heads[0] = heads[len(heads)-1]
+			heads[len(heads)-1] = nil
+			heads = heads[:len(heads)-1]
+		} else {
+			head.key = key(head.items[0])
+		}
+		heads.down(0)
+	}
+
+	return merged
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intKey(item interface{}) int64 {
+	return int64(item.(int))
+}
+
+func TestMergeQueues(t *testing.T) {
+	q1 := New(10)
+	q1.Put(1, 4, 7, 10)
+	q2 := New(10)
+	q2.Put(2, 5, 8)
+	q3 := New(10)
+	q3.Put(3, 6, 9, 11, 12)
+
+	merged := MergeQueues(intKey, q1, q2, q3)
+	assert.Equal(t, int64(12), merged.Len())
+
+	result, err := merged.Get(12)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, result)
+
+	for _, q := range []*Queue{q1, q2, q3} {
+		assert.False(t, q.Disposed())
+		assert.True(t, q.Empty())
+	}
+
+	assert.Nil(t, q1.Put(13))
+	result, err = q1.Get(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{13}, result)
+}
+
+func TestMergeQueuesTies(t *testing.T) {
+	type keyed struct {
+		key    int
+		source string
+	}
+	key := func(item interface{}) int64 {
+		return int64(item.(keyed).key)
+	}
+	q1 := New(10)
+	q1.Put(keyed{1, `a`}, keyed{2, `a`})
+	q2 := New(10)
+	q2.Put(keyed{1, `b`}, keyed{2, `b`})
+
+	merged := MergeQueues(key, q2, q1)
+	result, err := merged.Get(4)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{
+		keyed{1, `b`}, keyed{1, `a`}, keyed{2, `b`}, keyed{2, `a`},
+	}, result)
+}
+
+func TestMergeQueuesEmpty(t *testing.T) {
+	merged := MergeQueues(intKey)
+	assert.True(t, merged.Empty())
+
+	q := New(10)
+	q.Put(1, 2)
+	disposed := New(10)
+	disposed.Put(3)
+	disposed.Dispose()
+	merged = MergeQueues(intKey, nil, New(10), q, disposed)
+	result, err := merged.Get(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2}, result)
+}
+
+func TestMergeQueuesUsable(t *testing.T) {
+	q := New(10)
+	q.Put(1)
+
+	merged := MergeQueues(intKey, q)
+	assert.Nil(t, merged.Put(2))
+	result, err := merged.Get(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2}, result)
+}
+
+func BenchmarkMergeQueues(b *testing.B) {
+	numQueues, numItems := 8, 1000
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		queues := make([]*Queue, 0, numQueues)
+		for j := 0; j < numQueues; j++ {
+			q := New(int64(numItems))
+			for k := 0; k < numItems; k++ {
+				q.Put(k*numQueues + j)
+			}
+			queues = append(queues, q)
+		}
+		b.StartTimer()
+
+		MergeQueues(intKey, queues...)
+	}
+}
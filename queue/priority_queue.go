@@ -39,20 +39,42 @@ type Item interface {
 
 type priorityItems []Item
 
-func (items *priorityItems) swap(i, j int) {
+// swap swaps the items at i and j.  If positions is not nil it is kept
+// up to date with the index of each item.
+func (items *priorityItems) swap(i, j int, positions map[Item]int) {
 	(*items)[i], (*items)[j] = (*items)[j], (*items)[i]
+	if positions != nil {
+		positions[(*items)[i]] = i
+		positions[(*items)[j]] = j
+	}
+}
+
+func (items *priorityItems) pop(positions map[Item]int) Item {
+	return items.removeAt(0, positions)
 }
 
-func (items *priorityItems) pop() Item {
-	size := len(*items)
+// removeAt removes the item at index i and restores the heap property.
+func (items *priorityItems) removeAt(i int, positions map[Item]int) Item {
+	last := len(*items) - 1
+
+	// Move last leaf to i, and 'pop' the last item.
+	items.swap(last, i, positions)
+	item := (*items)[last] // Item to return.
+	(*items)[last], *items = nil, (*items)[:last]
+	if positions != nil {
+		delete(positions, item)
+	}
+
+	if i < len(*items) {
+		items.down(i, positions)
+		items.up(i, positions)
+	}
 
-	// Move last leaf to root, and 'pop' the last item.
-	items.swap(size-1, 0)
-	item := (*items)[size-1] // Item to return.
-	(*items)[size-1], *items = nil, (*items)[:size-1]
+	return item
+}
 
-	// 'Bubble down' to restore heap property.
-	index := 0
+// down 'bubbles down' the item at index to restore heap property.
+func (items *priorityItems) down(index int, positions map[Item]int) {
 	childL, childR := 2*index+1, 2*index+2
 	for len(*items) > childL {
 		child := childL
@@ -61,7 +83,7 @@ func (items *priorityItems) pop() Item {
 		}
 
 		if (*items)[child].Compare((*items)[index]) < 0 {
-			items.swap(index, child)
+			items.swap(index, child, positions)
 
 			index = child
 			childL, childR = 2*index+1, 2*index+2
@@ -69,36 +91,41 @@ func (items *priorityItems) pop() Item {
 			break
 		}
 	}
+}
 
-	return item
+// up 'bubbles up' the item at index to restore heap property.
+func (items *priorityItems) up(index int, positions map[Item]int) {
+	item := (*items)[index]
+	parent := int((index - 1) / 2)
+	for parent >= 0 && (*items)[parent].Compare(item) > 0 {
+		items.swap(index, parent, positions)
+
+		index = parent
+		parent = int((index - 1) / 2)
+	}
 }
 
-func (items *priorityItems) get(number int) []Item {
+func (items *priorityItems) get(number int, positions map[Item]int) []Item {
 	returnItems := make([]Item, 0, number)
 	for i := 0; i < number; i++ {
 		if i >= len(*items) {
 			break
 		}
 
-		returnItems = append(returnItems, items.pop())
+		returnItems = append(returnItems, items.pop(positions))
 	}
 
 	return returnItems
 }
 
-func (items *priorityItems) push(item Item) {
+func (items *priorityItems) push(item Item, positions map[Item]int) {
 	// Stick the item as the end of the last level.
 	*items = append(*items, item)
-
-	// 'Bubble up' to restore heap property.
-	index := len(*items) - 1
-	parent := int((index - 1) / 2)
-	for parent >= 0 && (*items)[parent].Compare(item) > 0 {
-		items.swap(index, parent)
-
-		index = parent
-		parent = int((index - 1) / 2)
+	if positions != nil {
+		positions[item] = len(*items) - 1
 	}
+
+	items.up(len(*items)-1, positions)
 }
 
 // PriorityQueue is similar to queue except that it takes
@@ -107,7 +134,7 @@ func (items *priorityItems) push(item Item) {
 type PriorityQueue struct {
 	waiters         waiters
 	items           priorityItems
-	itemMap         map[Item]struct{}
+	itemMap         map[Item]int // heap position, unused with duplicates
 	lock            sync.Mutex
 	disposeLock     sync.Mutex
 	disposed        bool
@@ -129,10 +156,9 @@ func (pq *PriorityQueue) Put(items ...Item) error {
 
 	for _, item := range items {
 		if pq.allowDuplicates {
-			pq.items.push(item)
+			pq.items.push(item, nil)
 		} else if _, ok := pq.itemMap[item]; !ok {
-			pq.itemMap[item] = struct{}{}
-			pq.items.push(item)
+			pq.items.push(item, pq.itemMap)
 		}
 	}
 
@@ -170,13 +196,6 @@ func (pq *PriorityQueue) Get(number int) ([]Item, error) {
 
 	var items []Item
 
-	// Remove references to popped items.
-	deleteItems := func(items []Item) {
-		for _, item := range items {
-			delete(pq.itemMap, item)
-		}
-	}
-
 	if len(pq.items) == 0 {
 		sema := newSema()
 		pq.waiters.put(sema)
@@ -188,20 +207,91 @@ func (pq *PriorityQueue) Get(number int) ([]Item, error) {
 			return nil, ErrDisposed
 		}
 
-		items = pq.items.get(number)
-		if !pq.allowDuplicates {
-			deleteItems(items)
-		}
+		items = pq.items.get(number, pq.positions())
 		sema.response.Done()
 		return items, nil
 	}
 
-	items = pq.items.get(number)
-	deleteItems(items)
+	items = pq.items.get(number, pq.positions())
 	pq.lock.Unlock()
 	return items, nil
 }
 
+// DrainOrdered removes every item from the queue and returns them in
+// priority order, the order in which repeated calls to Get would have
+// returned them.  The heap is sorted in place, which is cheaper than
+// popping the items one at a time.  A disposed queue returns nil.
+func (pq *PriorityQueue) DrainOrdered() []Item {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	if pq.disposed {
+		return nil
+	}
+
+	items := pq.items
+	for end := len(items) - 1; end > 0; end-- {
+		// move the next item to the end of what remains of the heap
+		items[0], items[end] = items[end], items[0]
+		heap := items[:end]
+		heap.down(0, nil)
+	}
+
+	// the heap put the first item last
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+
+	pq.items = nil
+	pq.itemMap = make(map[Item]int)
+
+	return items
+}
+
+// Remove deletes the provided item from anywhere in the queue and
+// returns a bool indicating if it was found.  Items are matched by
+// identity, the same == comparison a map key uses, and not by Compare,
+// so pointer items only match the same pointer.  If duplicates are
+// allowed only one occurrence is removed and finding it is O(n),
+// otherwise an index of heap positions makes this O(log n).
+func (pq *PriorityQueue) Remove(item Item) bool {
+	pq.lock.Lock()
+	defer pq.lock.Unlock()
+
+	if pq.disposed {
+		return false
+	}
+
+	if !pq.allowDuplicates {
+		i, ok := pq.itemMap[item]
+		if !ok {
+			return false
+		}
+
+		pq.items.removeAt(i, pq.itemMap)
+		return true
+	}
+
+	for i, existing := range pq.items {
+		if existing == item {
+			pq.items.removeAt(i, nil)
+			return true
+		}
+	}
+
+	return false
+}
+
+// positions returns the map of heap positions to maintain, or nil
+// when duplicates are allowed.
+func (pq *PriorityQueue) positions() map[Item]int {
+	if pq.allowDuplicates {
+		return nil
+	}
+
+	return pq.itemMap
+}
+
 // Peek will look at the next item without removing it from the queue.
 func (pq *PriorityQueue) Peek() Item {
 	pq.lock.Lock()
@@ -260,7 +350,7 @@ func (pq *PriorityQueue) Dispose() {
 func NewPriorityQueue(hint int, allowDuplicates bool) *PriorityQueue {
 	return &PriorityQueue{
 		items:           make(priorityItems, 0, hint),
-		itemMap:         make(map[Item]struct{}, hint),
+		itemMap:         make(map[Item]int, hint),
 		allowDuplicates: allowDuplicates,
 	}
 }
@@ -253,3 +253,126 @@ func TestAllowDuplicates(t *testing.T) {
 
 	assert.Equal(t, 2, q.Len())
 }
+
+func TestPriorityRemove(t *testing.T) {
+	q := NewPriorityQueue(10, false)
+	for i := 10; i > 0; i-- {
+		q.Put(mockItem(i))
+	}
+
+	assert.True(t, q.Remove(mockItem(1)))
+	assert.True(t, q.Remove(mockItem(5)))
+	assert.True(t, q.Remove(mockItem(10)))
+	assert.False(t, q.Remove(mockItem(5)))
+	assert.False(t, q.Remove(mockItem(11)))
+	assert.Equal(t, 7, q.Len())
+
+	// a removed item can be put again
+	q.Put(mockItem(5))
+
+	result := []Item{}
+	for !q.Empty() {
+		items, err := q.Get(1)
+		assert.Nil(t, err)
+		result = append(result, items...)
+	}
+	assert.Equal(t, []Item{
+		mockItem(2), mockItem(3), mockItem(4), mockItem(5),
+		mockItem(6), mockItem(7), mockItem(8), mockItem(9),
+	}, result)
+	assert.False(t, q.Remove(mockItem(2)))
+}
+
+func TestPriorityRemoveKeepsHeap(t *testing.T) {
+	q := NewPriorityQueue(100, false)
+	for i := 0; i < 100; i++ {
+		q.Put(mockItem((i * 37) % 100))
+	}
+
+	for i := 0; i < 100; i += 3 {
+		assert.True(t, q.Remove(mockItem(i)))
+	}
+
+	last := -1
+	for !q.Empty() {
+		result, _ := q.Get(1)
+		assert.True(t, int(result[0].(mockItem)) > last)
+		assert.NotEqual(t, 0, int(result[0].(mockItem))%3)
+		last = int(result[0].(mockItem))
+	}
+}
+
+func TestPriorityRemoveDuplicates(t *testing.T) {
+	q := NewPriorityQueue(3, true)
+	q.Put(mockItem(1), mockItem(2), mockItem(1))
+
+	assert.True(t, q.Remove(mockItem(1)))
+	assert.Equal(t, 2, q.Len())
+	assert.True(t, q.Remove(mockItem(1)))
+	assert.False(t, q.Remove(mockItem(1)))
+
+	result, _ := q.Get(1)
+	assert.Equal(t, []Item{mockItem(2)}, result)
+}
+
+func TestPriorityRemoveDisposed(t *testing.T) {
+	q := NewPriorityQueue(1, false)
+	q.Put(mockItem(1))
+	q.Dispose()
+
+	assert.False(t, q.Remove(mockItem(1)))
+}
+
+func TestPriorityDrainOrdered(t *testing.T) {
+	q := NewPriorityQueue(100, false)
+	for i := 0; i < 100; i++ {
+		q.Put(mockItem((i * 37) % 100))
+	}
+
+	result := q.DrainOrdered()
+	if !assert.Len(t, result, 100) {
+		return
+	}
+	for i, item := range result {
+		assert.Equal(t, mockItem(i), item)
+	}
+
+	assert.True(t, q.Empty())
+	assert.Len(t, q.DrainOrdered(), 0)
+
+	// the queue is usable afterwards and forgot the drained items
+	q.Put(mockItem(5), mockItem(1))
+	assert.Equal(t, 2, q.Len())
+	assert.Equal(t, []Item{mockItem(1), mockItem(5)}, q.DrainOrdered())
+}
+
+func TestPriorityDrainOrderedDuplicates(t *testing.T) {
+	q := NewPriorityQueue(4, true)
+	q.Put(mockItem(2), mockItem(1), mockItem(2), mockItem(0))
+
+	assert.Equal(t, []Item{mockItem(0), mockItem(1), mockItem(2), mockItem(2)}, q.DrainOrdered())
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestPriorityDrainOrderedDisposed(t *testing.T) {
+	q := NewPriorityQueue(1, false)
+	q.Put(mockItem(1))
+	q.Dispose()
+
+	assert.Nil(t, q.DrainOrdered())
+}
+
+func BenchmarkPriorityDrainOrdered(b *testing.B) {
+	numItems := 1000
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		q := NewPriorityQueue(numItems, true)
+		for j := 0; j < numItems; j++ {
+			q.Put(mockItem((j * 37) % numItems))
+		}
+		b.StartTimer()
+
+		q.DrainOrdered()
+	}
+}
@@ -76,58 +76,153 @@ func (w *waiters) put(sema *sema) {
 	*w = append(*w, sema)
 }
 
+// expiringItem wraps an item that was put with an expiry.
+type expiringItem struct {
+	item     interface{}
+	deadline time.Time
+}
+
+// clock lazily reads the current time so that queues without expiring
+// items never call time.Now.
+type clock time.Time
+
+func (c *clock) now() time.Time {
+	if time.Time(*c).IsZero() {
+		*c = clock(time.Now())
+	}
+
+	return time.Time(*c)
+}
+
+// unwrap returns the item held at the provided index and a bool
+// indicating if it has expired.
+func (items items) unwrap(i int, c *clock) (interface{}, bool) {
+	ei, ok := items[i].(*expiringItem)
+	if !ok {
+		return items[i], false
+	}
+
+	return ei.item, !c.now().Before(ei.deadline)
+}
+
 type items []interface{}
 
-func (items *items) get(number int64) []interface{} {
+func (items *items) get(number int64) ([]interface{}, int64) {
 	returnItems := make([]interface{}, 0, number)
-	index := int64(0)
-	for i := int64(0); i < number; i++ {
-		if i >= int64(len(*items)) {
-			break
+	var c clock
+	index, expired := 0, int64(0)
+	for ; index < len(*items) && int64(len(returnItems)) < number; index++ {
+		item, isExpired := items.unwrap(index, &c)
+		(*items)[index] = nil
+		if isExpired {
+			expired++
+			continue
 		}
 
-		returnItems = append(returnItems, (*items)[i])
-		(*items)[i] = nil
-		index++
+		returnItems = append(returnItems, item)
 	}
 
 	*items = (*items)[index:]
-	return returnItems
+	return returnItems, expired
 }
 
-func (items *items) peek() (interface{}, bool) {
-	length := len(*items)
+func (items *items) peek() (interface{}, bool, int64) {
+	var c clock
+	expired := int64(0)
+	for len(*items) > 0 {
+		item, isExpired := items.unwrap(0, &c)
+		if !isExpired {
+			return item, true, expired
+		}
 
-	if length == 0 {
-		return nil, false
+		// expired items at the front are dropped
+		(*items)[0] = nil
+		*items = (*items)[1:]
+		expired++
 	}
 
-	return (*items)[0], true
+	return nil, false, expired
 }
 
-func (items *items) getUntil(checker func(item interface{}) bool) []interface{} {
+func (items *items) getUntil(checker func(item interface{}) bool) ([]interface{}, int64) {
 	length := len(*items)
 
 	if len(*items) == 0 {
 		// returning nil here actually wraps that nil in a list
 		// of interfaces... thanks go
-		return []interface{}{}
+		return []interface{}{}, 0
 	}
 
 	returnItems := make([]interface{}, 0, length)
-	index := -1
-	for i, item := range *items {
-		if !checker(item) {
+	var c clock
+	index, expired := -1, int64(0)
+	for i := range *items {
+		item, isExpired := items.unwrap(i, &c)
+		if !isExpired && !checker(item) {
 			break
 		}
 
-		returnItems = append(returnItems, item)
+		if isExpired {
+			expired++
+		} else {
+			returnItems = append(returnItems, item)
+		}
 		index = i
 		(*items)[i] = nil // prevent memory leak
 	}
 
 	*items = (*items)[index+1:]
-	return returnItems
+	return returnItems, expired
+}
+
+// takeWhere removes and returns the first unexpired item that matches
+// pred.  The other items are left where they are.
+func (items *items) takeWhere(pred func(interface{}) bool) (interface{}, bool) {
+	var c clock
+	for i := range *items {
+		item, isExpired := items.unwrap(i, &c)
+		if isExpired || !pred(item) {
+			continue
+		}
+
+		copy((*items)[i:], (*items)[i+1:])
+		(*items)[len(*items)-1] = nil // prevent memory leak
+		*items = (*items)[:len(*items)-1]
+		return item, true
+	}
+
+	return nil, false
+}
+
+// live returns the unexpired items, unwrapped.  The items are returned
+// as is if none of them can expire.
+func (items items) live() ([]interface{}, int64) {
+	i := 0
+	for ; i < len(items); i++ {
+		if _, ok := items[i].(*expiringItem); ok {
+			break
+		}
+	}
+
+	if i == len(items) {
+		return items, 0
+	}
+
+	var c clock
+	result := make([]interface{}, i, len(items))
+	copy(result, items[:i])
+	expired := int64(0)
+	for ; i < len(items); i++ {
+		item, isExpired := items.unwrap(i, &c)
+		if isExpired {
+			expired++
+			continue
+		}
+
+		result = append(result, item)
+	}
+
+	return result, expired
 }
 
 type sema struct {
@@ -149,6 +244,7 @@ type Queue struct {
 	items    items
 	lock     sync.Mutex
 	disposed bool
+	expired  int64
 }
 
 // Put will add the specified items to the queue.
@@ -165,6 +261,44 @@ func (q *Queue) Put(items ...interface{}) error {
 	}
 
 	q.items = append(q.items, items...)
+	q.notify()
+
+	q.lock.Unlock()
+	return nil
+}
+
+// PutWithExpiry will add the provided item to the queue.  If the item
+// is not retrieved within ttl it expires and is dropped instead of
+// being returned by Get, Poll, Peek or TakeUntil.  A non-positive ttl
+// drops the item immediately.  Expired items still count towards Len
+// until they are dropped.
+func (q *Queue) PutWithExpiry(item interface{}, ttl time.Duration) error {
+	q.lock.Lock()
+
+	if q.disposed {
+		q.lock.Unlock()
+		return ErrDisposed
+	}
+
+	if ttl <= 0 {
+		q.expired++
+		q.lock.Unlock()
+		return nil
+	}
+
+	q.items = append(q.items, &expiringItem{
+		item:     item,
+		deadline: time.Now().Add(ttl),
+	})
+	q.notify()
+
+	q.lock.Unlock()
+	return nil
+}
+
+// notify hands the queue's items to any waiting getters.  The lock
+// must be held.
+func (q *Queue) notify() {
 	for {
 		sema := q.waiters.get()
 		if sema == nil {
@@ -181,15 +315,12 @@ func (q *Queue) Put(items ...interface{}) error {
 			break
 		}
 	}
-
-	q.lock.Unlock()
-	return nil
 }
 
 // Get retrieves items from the queue.  If there are some items in the
 // queue, get will return a number UP TO the number passed in as a
 // parameter.  If no items are in the queue, this method will pause
-// until items are added to the queue.
+// until items are added to the queue.  Expired items are skipped.
 func (q *Queue) Get(number int64) ([]interface{}, error) {
 	return q.Poll(number, 0)
 }
@@ -207,20 +338,27 @@ func (q *Queue) Poll(number int64, timeout time.Duration) ([]interface{}, error)
 
 	q.lock.Lock()
 
-	if q.disposed {
-		q.lock.Unlock()
-		return nil, ErrDisposed
-	}
+	var timeoutC <-chan time.Time
+	for {
+		if q.disposed {
+			q.lock.Unlock()
+			return nil, ErrDisposed
+		}
 
-	var items []interface{}
+		if len(q.items) > 0 {
+			items := q.getItems(number)
+			if len(items) > 0 {
+				q.lock.Unlock()
+				return items, nil
+			}
+			// everything left in the queue had expired
+		}
 
-	if len(q.items) == 0 {
 		sema := newSema()
 		q.waiters.put(sema)
 		q.lock.Unlock()
 
-		var timeoutC <-chan time.Time
-		if timeout > 0 {
+		if timeout > 0 && timeoutC == nil {
 			timeoutC = time.After(timeout)
 		}
 		select {
@@ -229,9 +367,13 @@ func (q *Queue) Poll(number int64, timeout time.Duration) ([]interface{}, error)
 			if q.disposed {
 				return nil, ErrDisposed
 			}
-			items = q.items.get(number)
+			items := q.getItems(number)
 			sema.response.Done()
-			return items, nil
+			if len(items) > 0 {
+				return items, nil
+			}
+			// the items expired before we got them, wait again
+			q.lock.Lock()
 		case <-timeoutC:
 			// cleanup the sema that was added to waiters
 			select {
@@ -244,10 +386,88 @@ func (q *Queue) Poll(number int64, timeout time.Duration) ([]interface{}, error)
 			return nil, ErrTimeout
 		}
 	}
+}
 
-	items = q.items.get(number)
-	q.lock.Unlock()
-	return items, nil
+// GetBatch retrieves a batch of up to maxItems items from the queue.
+// This will pause until at least one item is available and then keep
+// collecting items until either maxItems have been collected or
+// maxWait has passed since the first was received, whichever comes
+// first.  If the queue is disposed while collecting, the items
+// collected so far are returned along with ErrDisposed.
+func (q *Queue) GetBatch(maxItems int64, maxWait time.Duration) ([]interface{}, error) {
+	batch, err := q.Get(maxItems)
+	if err != nil || int64(len(batch)) >= maxItems {
+		return batch, err
+	}
+
+	deadline := time.Now().Add(maxWait)
+	for int64(len(batch)) < maxItems {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+
+		items, err := q.Poll(maxItems-int64(len(batch)), remaining)
+		if err == ErrTimeout {
+			break
+		}
+		if err != nil {
+			return batch, err
+		}
+		batch = append(batch, items...)
+	}
+
+	return batch, nil
+}
+
+// GetWhere retrieves the first item in the queue for which pred returns
+// true.  Items that do not match are skipped but keep their place in
+// the queue, so they remain available, in order, to other consumers.
+// If no item matches, this method will pause until a matching item is
+// put.  Expired items are skipped.  Every call looks through the queue
+// from the front, so this is O(n) in the number of queued items.  A
+// waiting GetWhere is woken on every Put like any other getter and, if
+// nothing it was given matches, waits again behind the getters that
+// are already waiting, so a selective consumer may be overtaken by
+// others.  pred is called with the queue's lock held and must not call
+// methods on this queue.
+func (q *Queue) GetWhere(pred func(interface{}) bool) (interface{}, error) {
+	q.lock.Lock()
+
+	for {
+		if q.disposed {
+			q.lock.Unlock()
+			return nil, ErrDisposed
+		}
+
+		if item, ok := q.items.takeWhere(pred); ok {
+			q.lock.Unlock()
+			return item, nil
+		}
+
+		sema := newSema()
+		q.waiters.put(sema)
+		q.lock.Unlock()
+
+		<-sema.ready
+		// we are now inside the put's lock
+		if q.disposed {
+			return nil, ErrDisposed
+		}
+		item, ok := q.items.takeWhere(pred)
+		sema.response.Done()
+		if ok {
+			return item, nil
+		}
+		q.lock.Lock()
+	}
+}
+
+// getItems gets up to number unexpired items.  The lock must be held.
+func (q *Queue) getItems(number int64) []interface{} {
+	items, expired := q.items.get(number)
+	q.expired += expired
+	return items
 }
 
 // Peek returns a the first item in the queue by value
@@ -260,7 +480,8 @@ func (q *Queue) Peek() (interface{}, error) {
 		return nil, ErrDisposed
 	}
 
-	peekItem, ok := q.items.peek()
+	peekItem, ok, expired := q.items.peek()
+	q.expired += expired
 	if !ok {
 		return nil, ErrEmptyQueue
 	}
@@ -283,7 +504,8 @@ func (q *Queue) TakeUntil(checker func(item interface{}) bool) ([]interface{}, e
 		return nil, ErrDisposed
 	}
 
-	result := q.items.getUntil(checker)
+	result, expired := q.items.getUntil(checker)
+	q.expired += expired
 	q.lock.Unlock()
 	return result, nil
 }
@@ -304,6 +526,15 @@ func (q *Queue) Len() int64 {
 	return int64(len(q.items))
 }
 
+// Expired returns the number of items that have been dropped from this
+// queue because they expired before they were retrieved.
+func (q *Queue) Expired() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.expired
+}
+
 // Disposed returns a bool indicating if this queue
 // has had disposed called on it.
 func (q *Queue) Disposed() bool {
@@ -314,8 +545,8 @@ func (q *Queue) Disposed() bool {
 }
 
 // Dispose will dispose of this queue and returns
-// the items disposed. Any subsequent calls to Get
-// or Put will return an error.
+// the items disposed, skipping any that have expired.
+// Any subsequent calls to Get or Put will return an error.
 func (q *Queue) Dispose() []interface{} {
 	q.lock.Lock()
 	defer q.lock.Unlock()
@@ -331,7 +562,8 @@ func (q *Queue) Dispose() []interface{} {
 		}
 	}
 
-	disposedItems := q.items
+	disposedItems, expired := q.items.live()
+	q.expired += expired
 
 	q.items = nil
 	q.waiters = nil
@@ -371,9 +603,11 @@ func ExecuteInParallel(q *Queue, fn func(interface{})) {
 	var wg sync.WaitGroup
 	wg.Add(numCPU)
 	items := q.items
+	expired := int64(0)
 
 	for i := 0; i < numCPU; i++ {
 		go func() {
+			var c clock
 			for {
 				index := atomic.AddInt64(&done, 1)
 				if index >= int64(todo) {
@@ -381,12 +615,18 @@ func ExecuteInParallel(q *Queue, fn func(interface{})) {
 					break
 				}
 
-				fn(items[index])
+				item, isExpired := items.unwrap(int(index), &c)
 				items[index] = 0
+				if isExpired {
+					atomic.AddInt64(&expired, 1)
+					continue
+				}
+				fn(item)
 			}
 		}()
 	}
 	wg.Wait()
+	q.expired += expired
 	q.lock.Unlock()
 	q.Dispose()
 }
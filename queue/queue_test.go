@@ -355,6 +355,173 @@ func TestPeekOnDisposedQueue(t *testing.T) {
 	assert.IsType(t, ErrDisposed, err)
 }
 
+func isEven(item interface{}) bool {
+	return item.(int)%2 == 0
+}
+
+func TestGetWhere(t *testing.T) {
+	q := New(10)
+	q.Put(1, 3, 4, 5, 6)
+
+	result, err := q.GetWhere(isEven)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, result)
+
+	result, err = q.GetWhere(isEven)
+	assert.Nil(t, err)
+	assert.Equal(t, 6, result)
+
+	// the skipped items are still there in order
+	assert.Equal(t, int64(3), q.Len())
+	items, err := q.Get(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 3, 5}, items)
+}
+
+func TestGetWhereSkipsExpired(t *testing.T) {
+	q := New(10)
+	q.PutWithExpiry(2, time.Nanosecond)
+	q.Put(4)
+	time.Sleep(time.Millisecond)
+
+	result, err := q.GetWhere(isEven)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, result)
+}
+
+func TestGetWhereBlocks(t *testing.T) {
+	q := New(10)
+	q.Put(1)
+
+	var result interface{}
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result, err = q.GetWhere(isEven)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Put(3)
+	time.Sleep(10 * time.Millisecond)
+	q.Put(8)
+	wg.Wait()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 8, result)
+	items, _ := q.Get(10)
+	assert.Equal(t, []interface{}{1, 3}, items)
+}
+
+func TestGetWhereLeavesItemsForOthers(t *testing.T) {
+	q := New(10)
+
+	var even, odd interface{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		even, _ = q.GetWhere(isEven)
+	}()
+	go func() {
+		defer wg.Done()
+		odd, _ = q.GetWhere(func(item interface{}) bool { return !isEven(item) })
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Put(7)
+	q.Put(2)
+	wg.Wait()
+
+	assert.Equal(t, 2, even)
+	assert.Equal(t, 7, odd)
+	assert.True(t, q.Empty())
+}
+
+func TestGetWhereDisposed(t *testing.T) {
+	q := New(10)
+	q.Put(1)
+
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err = q.GetWhere(isEven)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Dispose()
+	wg.Wait()
+
+	assert.Equal(t, ErrDisposed, err)
+	_, err = q.GetWhere(isEven)
+	assert.Equal(t, ErrDisposed, err)
+}
+
+func TestGetBatchFull(t *testing.T) {
+	q := New(10)
+	q.Put(1, 2, 3, 4)
+
+	result, err := q.GetBatch(3, time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 2, 3}, result)
+	assert.Equal(t, int64(1), q.Len())
+}
+
+func TestGetBatchCollects(t *testing.T) {
+	q := New(10)
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(time.Millisecond)
+			q.Put(i)
+		}
+	}()
+
+	result, err := q.GetBatch(3, time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{0, 1, 2}, result)
+}
+
+func TestGetBatchMaxWait(t *testing.T) {
+	q := New(10)
+	q.Put(1)
+
+	start := time.Now()
+	result, err := q.GetBatch(5, 20*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1}, result)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+}
+
+func TestGetBatchDisposed(t *testing.T) {
+	q := New(10)
+	q.Put(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Dispose()
+	}()
+
+	result, err := q.GetBatch(5, time.Second)
+	assert.Equal(t, ErrDisposed, err)
+	assert.Equal(t, []interface{}{1}, result)
+
+	result, err = q.GetBatch(5, time.Second)
+	assert.Equal(t, ErrDisposed, err)
+	assert.Nil(t, result)
+}
+
+func TestGetBatchNoItems(t *testing.T) {
+	q := New(10)
+	q.Put(1)
+
+	result, err := q.GetBatch(0, time.Second)
+	assert.Nil(t, err)
+	assert.Len(t, result, 0)
+	assert.Equal(t, int64(1), q.Len())
+}
+
 func TestTakeUntil(t *testing.T) {
 	q := New(10)
 	q.Put(`a`, `b`, `c`)
@@ -445,6 +612,146 @@ func TestExecuteInParallelEmptyQueue(t *testing.T) {
 	})
 }
 
+func TestPutWithExpiry(t *testing.T) {
+	q := New(10)
+	assert.Nil(t, q.PutWithExpiry(`a`, time.Hour))
+	assert.Nil(t, q.PutWithExpiry(`b`, time.Millisecond))
+	q.Put(`c`)
+	assert.Equal(t, int64(3), q.Len())
+
+	time.Sleep(5 * time.Millisecond)
+
+	result, err := q.Get(10)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{`a`, `c`}, result)
+	assert.Equal(t, int64(1), q.Expired())
+	assert.Equal(t, int64(0), q.Len())
+}
+
+func TestPutWithExpiryNonPositive(t *testing.T) {
+	q := New(10)
+	assert.Nil(t, q.PutWithExpiry(`a`, 0))
+
+	assert.Equal(t, int64(0), q.Len())
+	assert.Equal(t, int64(1), q.Expired())
+}
+
+func TestPutWithExpiryGetRespectsNumber(t *testing.T) {
+	q := New(10)
+	q.PutWithExpiry(`a`, time.Millisecond)
+	q.Put(`b`, `c`)
+
+	time.Sleep(5 * time.Millisecond)
+
+	result, err := q.Get(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{`b`}, result)
+	assert.Equal(t, int64(1), q.Len())
+}
+
+func TestPollOnlyExpired(t *testing.T) {
+	q := New(10)
+	q.PutWithExpiry(`a`, time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+
+	result, err := q.Poll(1, 10*time.Millisecond)
+	assert.Nil(t, result)
+	assert.Equal(t, ErrTimeout, err)
+	assert.Equal(t, int64(1), q.Expired())
+	assert.True(t, q.Empty())
+}
+
+func TestGetWaitsPastExpired(t *testing.T) {
+	q := New(10)
+	q.PutWithExpiry(`a`, time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.Put(`b`)
+	}()
+
+	result, err := q.Get(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{`b`}, result)
+}
+
+func TestPeekSkipsExpired(t *testing.T) {
+	q := New(10)
+	q.PutWithExpiry(`a`, time.Millisecond)
+	q.PutWithExpiry(`b`, time.Hour)
+
+	time.Sleep(5 * time.Millisecond)
+
+	result, err := q.Peek()
+	assert.Nil(t, err)
+	assert.Equal(t, `b`, result)
+	assert.Equal(t, int64(1), q.Expired())
+	assert.Equal(t, int64(1), q.Len())
+
+	q.Get(1)
+	q.PutWithExpiry(`c`, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = q.Peek()
+	assert.Equal(t, ErrEmptyQueue, err)
+}
+
+func TestTakeUntilSkipsExpired(t *testing.T) {
+	q := New(10)
+	q.Put(1)
+	q.PutWithExpiry(2, time.Millisecond)
+	q.PutWithExpiry(3, time.Hour)
+	q.Put(10)
+
+	time.Sleep(5 * time.Millisecond)
+
+	result, err := q.TakeUntil(func(item interface{}) bool {
+		return item.(int) < 5
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{1, 3}, result)
+	assert.Equal(t, int64(1), q.Expired())
+	assert.Equal(t, int64(1), q.Len())
+}
+
+func TestDisposeSkipsExpired(t *testing.T) {
+	q := New(10)
+	q.Put(`a`)
+	q.PutWithExpiry(`b`, time.Millisecond)
+	q.PutWithExpiry(`c`, time.Hour)
+
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, []interface{}{`a`, `c`}, q.Dispose())
+	assert.Equal(t, int64(1), q.Expired())
+	assert.Equal(t, ErrDisposed, q.PutWithExpiry(`d`, time.Hour))
+}
+
+func TestExecuteInParallelSkipsExpired(t *testing.T) {
+	q := New(10)
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			q.PutWithExpiry(i, time.Millisecond)
+		} else {
+			q.PutWithExpiry(i, time.Hour)
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	numCalls := uint64(0)
+	ExecuteInParallel(q, func(item interface{}) {
+		assert.Equal(t, 1, item.(int)%2)
+		atomic.AddUint64(&numCalls, 1)
+	})
+
+	assert.Equal(t, uint64(5), numCalls)
+	assert.Equal(t, int64(5), q.Expired())
+}
+
 func BenchmarkQueuePut(b *testing.B) {
 	numItems := int64(1000)
 
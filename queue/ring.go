@@ -18,6 +18,7 @@ package queue
 
 import (
 	"runtime"
+	"sync"
 	"sync/atomic"
 )
 
@@ -42,6 +43,25 @@ type node struct {
 
 type nodes []*node
 
+// watermarks holds the marks and callbacks set by SetWatermarks.
+type watermarks struct {
+	high, low     uint64
+	onHigh, onLow func()
+	// lock guards the fields below and is held for each crossing, but
+	// never while a callback is called
+	lock sync.Mutex
+	// above is 1 from when the high mark is reached until the low mark
+	// is, so each callback only fires on a crossing.  It is only changed
+	// under lock.
+	above uint32
+	// pending holds the crossings whose callbacks are still to be
+	// called in order, true for the high mark
+	pending []bool
+	// notifying is true while a goroutine is calling the callbacks
+	// for pending
+	notifying bool
+}
+
 // RingBuffer is a MPMC buffer that achieves threadsafety with CAS operations
 // only.  A put on full or get on empty call will block until an item
 // is put or retrieved.  Calling Dispose on the RingBuffer will unblock
@@ -57,6 +77,7 @@ type RingBuffer struct {
 	mask, disposed uint64
 	_padding3      [8]uint64
 	nodes          nodes
+	watermarks     atomic.Value // *watermarks
 }
 
 func (rb *RingBuffer) init(size uint64) {
@@ -120,6 +141,9 @@ L:
 
 	n.data = item
 	atomic.StoreUint64(&n.position, pos+1)
+	if wm := rb.loadWatermarks(); wm != nil {
+		wm.check(rb)
+	}
 	return true, nil
 }
 
@@ -160,12 +184,124 @@ L:
 	data := n.data
 	n.data = nil
 	atomic.StoreUint64(&n.position, pos+rb.mask+1)
+	if wm := rb.loadWatermarks(); wm != nil {
+		wm.check(rb)
+	}
 	return data, nil
 }
 
 // Len returns the number of items in the queue.
 func (rb *RingBuffer) Len() uint64 {
-	return atomic.LoadUint64(&rb.queue) - atomic.LoadUint64(&rb.dequeue)
+	// dequeue never passes queue, so loading it first ensures the
+	// difference cannot underflow
+	dequeue := atomic.LoadUint64(&rb.dequeue)
+	return atomic.LoadUint64(&rb.queue) - dequeue
+}
+
+// SetWatermarks sets callbacks for backpressure.  onHigh is called when
+// the length of the queue reaches high or above, and onLow when it then
+// drops back down to low or below.  Each is only called on such a
+// crossing, after which the other must be called before it is called
+// again.  They are called one at a time and in the order of the
+// crossings, by the Put, Offer or Get that saw the crossing or, if a
+// callback is already running, by the goroutine running it once it
+// returns.  No lock is held while they run, so they may use the queue
+// and run concurrently with other operations on it.  A queue already at
+// or above high when this is called does not call onHigh until it has
+// dropped to low.  Passing nil for both callbacks removes the
+// watermarks.  This panics if low is negative or not less than high.
+func (rb *RingBuffer) SetWatermarks(high, low int, onHigh, onLow func()) {
+	if onHigh == nil && onLow == nil {
+		rb.watermarks.Store((*watermarks)(nil))
+		return
+	}
+
+	if low < 0 || low >= high {
+		panic(`Ring buffer watermarks must have 0 <= low < high.`)
+	}
+
+	wm := &watermarks{
+		high:   uint64(high),
+		low:    uint64(low),
+		onHigh: onHigh,
+		onLow:  onLow,
+	}
+	if rb.Len() >= wm.high {
+		wm.above = 1
+	}
+	rb.watermarks.Store(wm)
+}
+
+// crossing returns a bool indicating if the provided length crosses a
+// mark from the side last crossed to.
+func (wm *watermarks) crossing(length uint64) bool {
+	if atomic.LoadUint32(&wm.above) == 0 {
+		return length >= wm.high
+	}
+
+	return length <= wm.low
+}
+
+// check calls the callback for any crossing of the marks by the length
+// of the provided queue.  Another goroutine may move the length back
+// across a mark before a crossing is acted on, so each crossing is made
+// under lock with the length read again, and is repeated until the
+// length agrees with the side last crossed to.
+func (wm *watermarks) check(rb *RingBuffer) {
+	crossed := false
+	for wm.crossing(rb.Len()) {
+		wm.lock.Lock()
+		if wm.crossing(rb.Len()) {
+			high := wm.above == 0
+			if high {
+				atomic.StoreUint32(&wm.above, 1)
+			} else {
+				atomic.StoreUint32(&wm.above, 0)
+			}
+			wm.pending = append(wm.pending, high)
+			crossed = true
+		}
+		wm.lock.Unlock()
+	}
+
+	if crossed {
+		wm.notify()
+	}
+}
+
+// notify calls the callbacks for the pending crossings in order, unless
+// another goroutine is already doing so, in which case it will call
+// them.  The lock is released for each call, so a callback may use the
+// queue and only holds up the goroutine calling it.
+func (wm *watermarks) notify() {
+	wm.lock.Lock()
+	if wm.notifying {
+		wm.lock.Unlock()
+		return
+	}
+
+	wm.notifying = true
+	for len(wm.pending) > 0 {
+		high := wm.pending[0]
+		wm.pending = wm.pending[1:]
+		wm.lock.Unlock()
+
+		if high && wm.onHigh != nil {
+			wm.onHigh()
+		} else if !high && wm.onLow != nil {
+			wm.onLow()
+		}
+
+		wm.lock.Lock()
+	}
+	wm.pending = nil
+	wm.notifying = false
+	wm.lock.Unlock()
+}
+
+func (rb *RingBuffer) loadWatermarks() *watermarks {
+	wm, _ := rb.watermarks.Load().(*watermarks)
+	return wm
 }
 
 // Cap returns the capacity of this ring buffer.
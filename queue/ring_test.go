@@ -17,9 +17,11 @@ limitations under the License.
 package queue
 
 import (
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -309,3 +311,189 @@ func BenchmarkRBGet(b *testing.B) {
 		rb.Get()
 	}
 }
+
+func TestRingWatermarks(t *testing.T) {
+	rb := NewRingBuffer(8)
+	var highs, lows int
+	rb.SetWatermarks(4, 1, func() { highs++ }, func() { lows++ })
+
+	for i := 0; i < 3; i++ {
+		rb.Put(i)
+	}
+	assert.Equal(t, 0, highs)
+
+	rb.Put(3)
+	assert.Equal(t, 1, highs)
+	rb.Put(4)
+	ok, _ := rb.Offer(5)
+	assert.True(t, ok)
+	assert.Equal(t, 1, highs)
+
+	for i := 0; i < 4; i++ {
+		rb.Get()
+	}
+	assert.Equal(t, 0, lows)
+	rb.Get()
+	assert.Equal(t, 1, lows)
+	rb.Get()
+	assert.Equal(t, 1, lows)
+
+	// rising past low again does not call onLow or onHigh
+	rb.Put(6)
+	rb.Put(7)
+	rb.Get()
+	assert.Equal(t, 1, lows)
+	assert.Equal(t, 1, highs)
+
+	for i := 0; i < 3; i++ {
+		rb.Put(i)
+	}
+	assert.Equal(t, 2, highs)
+
+	rb.SetWatermarks(0, 0, nil, nil)
+	for rb.Len() > 0 {
+		rb.Get()
+	}
+	assert.Equal(t, 1, lows)
+}
+
+func TestRingWatermarksReentrant(t *testing.T) {
+	rb := NewRingBuffer(8)
+	var highLen, lowLen uint64
+	var calls []string
+	rb.SetWatermarks(4, 1,
+		func() {
+			calls = append(calls, `high`)
+			highLen = rb.Len()
+			// putting from the callback must neither deadlock nor
+			// call onHigh again
+			rb.Put(`extra`)
+		},
+		func() {
+			calls = append(calls, `low`)
+			lowLen = rb.Len()
+		},
+	)
+
+	for i := 0; i < 4; i++ {
+		rb.Put(i)
+	}
+	assert.Equal(t, []string{`high`}, calls)
+	assert.Equal(t, uint64(4), highLen)
+	assert.Equal(t, uint64(5), rb.Len())
+
+	for i := 0; i < 4; i++ {
+		rb.Get()
+	}
+	assert.Equal(t, []string{`high`, `low`}, calls)
+	assert.Equal(t, uint64(1), lowLen)
+}
+
+func TestRingWatermarksAlreadyHigh(t *testing.T) {
+	rb := NewRingBuffer(8)
+	for i := 0; i < 5; i++ {
+		rb.Put(i)
+	}
+
+	var highs, lows int
+	rb.SetWatermarks(4, 2, func() { highs++ }, func() { lows++ })
+	rb.Put(5)
+	assert.Equal(t, 0, highs)
+
+	for i := 0; i < 4; i++ {
+		rb.Get()
+	}
+	assert.Equal(t, 1, lows)
+}
+
+func TestRingWatermarksInvalid(t *testing.T) {
+	rb := NewRingBuffer(8)
+	assert.Panics(t, func() { rb.SetWatermarks(2, 2, func() {}, nil) })
+	assert.Panics(t, func() { rb.SetWatermarks(2, -1, func() {}, nil) })
+}
+
+func TestRingWatermarksConcurrent(t *testing.T) {
+	rb := NewRingBuffer(64)
+	var highs, lows int64
+	rb.SetWatermarks(48, 16,
+		func() { atomic.AddInt64(&highs, 1) },
+		func() { atomic.AddInt64(&lows, 1) },
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(8)
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				rb.Put(j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				rb.Get()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(0), rb.Len())
+	// the queue ended below the low mark so every onHigh was followed
+	// by an onLow
+	assert.Equal(t, atomic.LoadInt64(&highs), atomic.LoadInt64(&lows))
+}
+
+func TestRingWatermarksThrottle(t *testing.T) {
+	rb := NewRingBuffer(64)
+	// above is set by onHigh and cleared by onLow, a producer waits
+	// while it is set so a missed onLow would stall it forever
+	var above, highs, lows int64
+	rb.SetWatermarks(8, 2,
+		func() {
+			atomic.AddInt64(&highs, 1)
+			atomic.StoreInt64(&above, 1)
+		},
+		func() {
+			atomic.AddInt64(&lows, 1)
+			atomic.StoreInt64(&above, 0)
+		},
+	)
+
+	const producers, perProducer = 4, 2000
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2 * producers)
+	for i := 0; i < producers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perProducer; j++ {
+				for atomic.LoadInt64(&above) == 1 {
+					runtime.Gosched()
+				}
+				rb.Put(j)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perProducer; j++ {
+				rb.Get()
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		rb.Dispose()
+		t.Fatal(`producers stalled waiting for onLow`)
+	}
+
+	assert.Equal(t, uint64(0), rb.Len())
+	assert.Equal(t, atomic.LoadInt64(&highs), atomic.LoadInt64(&lows))
+	assert.Equal(t, int64(0), atomic.LoadInt64(&above))
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"runtime"
+	"sync/atomic"
+)
+
+// SPSCRingBuffer is a bounded ring buffer that is safe for exactly one
+// producing goroutine and one consuming goroutine.  Because only the
+// producer moves the tail and only the consumer moves the head, no CAS
+// operations are needed and puts and gets are a single atomic store.
+// Like RingBuffer, a put on full or get on empty call will block until
+// space or an item is available, and Dispose will unblock any blocked
+// threads with an error.  Using this buffer with more than one producer
+// or more than one consumer will corrupt it.
+type SPSCRingBuffer struct {
+	_padding0 [8]uint64
+	tail      uint64 // written only by the producer
+	_padding1 [8]uint64
+	head      uint64 // written only by the consumer
+	_padding2 [8]uint64
+	mask      uint64
+	disposed  uint64
+	_padding3 [8]uint64
+	items     []interface{}
+}
+
+// Put adds the provided item to the queue.  If the queue is full, this
+// call will block until an item is retrieved or Dispose is called on the
+// queue.  An error will be returned if the queue is disposed.  Put must
+// only be called from the single producing goroutine.
+func (rb *SPSCRingBuffer) Put(item interface{}) error {
+	_, err := rb.put(item, false)
+	return err
+}
+
+// Offer adds the provided item to the queue if there is space.  If the
+// queue is full, this call will return false.  An error will be returned
+// if the queue is disposed.  Offer must only be called from the single
+// producing goroutine.
+func (rb *SPSCRingBuffer) Offer(item interface{}) (bool, error) {
+	return rb.put(item, true)
+}
+
+func (rb *SPSCRingBuffer) put(item interface{}, offer bool) (bool, error) {
+	tail := atomic.LoadUint64(&rb.tail)
+	i := 0
+	for {
+		if atomic.LoadUint64(&rb.disposed) == 1 {
+			return false, ErrDisposed
+		}
+
+		if tail-atomic.LoadUint64(&rb.head) <= rb.mask {
+			break
+		}
+
+		if offer {
+			return false, nil
+		}
+
+		if i == 10000 {
+			runtime.Gosched() // free up the cpu before the next iteration
+			i = 0
+		} else {
+			i++
+		}
+	}
+
+	rb.items[tail&rb.mask] = item
+	atomic.StoreUint64(&rb.tail, tail+1)
+	return true, nil
+}
+
+// Get will return the next item in the queue.  This call will block
+// if the queue is empty.  This call will unblock when an item is added
+// to the queue or Dispose is called on the queue.  An error will be
+// returned if the queue is disposed.  Get must only be called from the
+// single consuming goroutine.
+func (rb *SPSCRingBuffer) Get() (interface{}, error) {
+	head := atomic.LoadUint64(&rb.head)
+	i := 0
+	for {
+		if atomic.LoadUint64(&rb.disposed) == 1 {
+			return nil, ErrDisposed
+		}
+
+		if atomic.LoadUint64(&rb.tail) != head {
+			break
+		}
+
+		if i == 10000 {
+			runtime.Gosched() // free up the cpu before the next iteration
+			i = 0
+		} else {
+			i++
+		}
+	}
+
+	data := rb.items[head&rb.mask]
+	rb.items[head&rb.mask] = nil
+	atomic.StoreUint64(&rb.head, head+1)
+	return data, nil
+}
+
+// Len returns the number of items in the queue.
+func (rb *SPSCRingBuffer) Len() uint64 {
+	// head never passes tail, so loading it first ensures the
+	// difference cannot underflow
+	head := atomic.LoadUint64(&rb.head)
+	return atomic.LoadUint64(&rb.tail) - head
+}
+
+// Cap returns the capacity of this ring buffer.
+func (rb *SPSCRingBuffer) Cap() uint64 {
+	return uint64(len(rb.items))
+}
+
+// Dispose will dispose of this queue and free any blocked threads
+// in the Put and/or Get methods.  Calling those methods on a disposed
+// queue will return an error.
+func (rb *SPSCRingBuffer) Dispose() {
+	atomic.CompareAndSwapUint64(&rb.disposed, 0, 1)
+}
+
+// IsDisposed will return a bool indicating if this queue has been
+// disposed.
+func (rb *SPSCRingBuffer) IsDisposed() bool {
+	return atomic.LoadUint64(&rb.disposed) == 1
+}
+
+// NewSPSC will allocate, initialize, and return a single-producer
+// single-consumer ring buffer.  The capacity is rounded up to the
+// next power of 2 with a minimum of 1.
+func NewSPSC(capacity uint64) *SPSCRingBuffer {
+	if capacity == 0 {
+		capacity = 1
+	}
+	capacity = roundUp(capacity)
+	return &SPSCRingBuffer{
+		items: make([]interface{}, capacity),
+		mask:  capacity - 1,
+	}
+}
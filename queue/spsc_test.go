@@ -0,0 +1,241 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSPSCInsert(t *testing.T) {
+	rb := NewSPSC(5)
+	assert.Equal(t, uint64(8), rb.Cap())
+
+	err := rb.Put(5)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, uint64(1), rb.Len())
+
+	result, err := rb.Get()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, 5, result)
+	assert.Equal(t, uint64(0), rb.Len())
+}
+
+func TestSPSCZeroCapacity(t *testing.T) {
+	rb := NewSPSC(0)
+	assert.Equal(t, uint64(1), rb.Cap())
+
+	ok, err := rb.Offer(1)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+
+	ok, err = rb.Offer(2)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+}
+
+func TestSPSCOfferFull(t *testing.T) {
+	rb := NewSPSC(2)
+
+	for i := 0; i < 2; i++ {
+		ok, err := rb.Offer(i)
+		assert.True(t, ok)
+		assert.Nil(t, err)
+	}
+
+	ok, err := rb.Offer(2)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	result, err := rb.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, result)
+
+	ok, err = rb.Offer(2)
+	assert.True(t, ok)
+	assert.Nil(t, err)
+}
+
+func TestSPSCOrdering(t *testing.T) {
+	rb := NewSPSC(4)
+	numItems := 10000
+	results := make([]interface{}, 0, numItems)
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numItems; i++ {
+			result, err := rb.Get()
+			assert.Nil(t, err)
+			results = append(results, result)
+		}
+	}()
+
+	for i := 0; i < numItems; i++ {
+		assert.Nil(t, rb.Put(i))
+	}
+
+	wg.Wait()
+	for i := 0; i < numItems; i++ {
+		assert.Equal(t, i, results[i])
+	}
+}
+
+func TestSPSCLenConcurrent(t *testing.T) {
+	rb := NewSPSC(4)
+	numItems := 2000
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numItems; i++ {
+			rb.Get()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// a stale head may overstate the length but must never
+			// let it underflow
+			if l := rb.Len(); l > uint64(numItems) {
+				t.Errorf(`length %d underflowed`, l)
+				return
+			}
+			runtime.Gosched()
+		}
+	}()
+
+	for i := 0; i < numItems; i++ {
+		assert.Nil(t, rb.Put(i))
+	}
+
+	for rb.Len() > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestSPSCGetOnDisposed(t *testing.T) {
+	rb := NewSPSC(3)
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		_, err = rb.Get()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	rb.Dispose()
+	wg.Wait()
+
+	assert.Equal(t, ErrDisposed, err)
+	assert.True(t, rb.IsDisposed())
+}
+
+func TestSPSCPutOnDisposed(t *testing.T) {
+	rb := NewSPSC(1)
+	rb.Put(1)
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		err = rb.Put(2)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	rb.Dispose()
+	wg.Wait()
+
+	assert.Equal(t, ErrDisposed, err)
+}
+
+func BenchmarkSPSCLifeCycle(b *testing.B) {
+	rb := NewSPSC(64)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < b.N; i++ {
+			_, err := rb.Get()
+			assert.Nil(b, err)
+		}
+	}()
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rb.Put(i)
+	}
+
+	wg.Wait()
+}
+
+func BenchmarkSPSCPut(b *testing.B) {
+	rb := NewSPSC(uint64(b.N))
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ok, err := rb.Offer(i)
+		if !ok {
+			b.Fail()
+		}
+		if err != nil {
+			b.Log(err)
+			b.Fail()
+		}
+	}
+}
+
+func BenchmarkSPSCGet(b *testing.B) {
+	rb := NewSPSC(uint64(b.N))
+
+	for i := 0; i < b.N; i++ {
+		rb.Offer(i)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		rb.Get()
+	}
+}
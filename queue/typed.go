@@ -0,0 +1,200 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// TypedQueue is like Queue but holds items of a single type, so they
+// are not boxed in an interface on the way in or out.
+type TypedQueue[T any] struct {
+	waiters  waiters
+	items    []T
+	lock     sync.Mutex
+	disposed bool
+}
+
+// Put will add the specified items to the queue.
+func (q *TypedQueue[T]) Put(items ...T) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	q.lock.Lock()
+
+	if q.disposed {
+		q.lock.Unlock()
+		return ErrDisposed
+	}
+
+	q.items = append(q.items, items...)
+	for {
+		sema := q.waiters.get()
+		if sema == nil {
+			break
+		}
+		sema.response.Add(1)
+		select {
+		case sema.ready <- true:
+			sema.response.Wait()
+		default:
+			// This semaphore timed out.
+		}
+		if len(q.items) == 0 {
+			break
+		}
+	}
+
+	q.lock.Unlock()
+	return nil
+}
+
+// Get retrieves items from the queue.  If there are some items in the
+// queue, get will return a number UP TO the number passed in as a
+// parameter.  If no items are in the queue, this method will pause
+// until items are added to the queue.
+func (q *TypedQueue[T]) Get(number int64) ([]T, error) {
+	return q.Poll(number, 0)
+}
+
+// Poll retrieves items from the queue like Get, waiting no longer than
+// the provided timeout for items to be added.  A non-positive timeout
+// will block until items are added.  If a timeout occurs, ErrTimeout
+// is returned.
+func (q *TypedQueue[T]) Poll(number int64, timeout time.Duration) ([]T, error) {
+	if number < 1 {
+		return []T{}, nil
+	}
+
+	q.lock.Lock()
+
+	if q.disposed {
+		q.lock.Unlock()
+		return nil, ErrDisposed
+	}
+
+	if len(q.items) > 0 {
+		items := q.getItems(number)
+		q.lock.Unlock()
+		return items, nil
+	}
+
+	sema := newSema()
+	q.waiters.put(sema)
+	q.lock.Unlock()
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timeoutC = time.After(timeout)
+	}
+	select {
+	case <-sema.ready:
+		// we are now inside the put's lock
+		if q.disposed {
+			return nil, ErrDisposed
+		}
+		items := q.getItems(number)
+		sema.response.Done()
+		return items, nil
+	case <-timeoutC:
+		// cleanup the sema that was added to waiters
+		select {
+		case sema.ready <- true:
+			// we called this before Put() could
+		default:
+			// Put() got it already, we need to call Done() so Put() can move on
+			sema.response.Done()
+		}
+		return nil, ErrTimeout
+	}
+}
+
+// getItems removes and returns up to number items.  The lock must be
+// held.
+func (q *TypedQueue[T]) getItems(number int64) []T {
+	if number > int64(len(q.items)) {
+		number = int64(len(q.items))
+	}
+
+	items := make([]T, number)
+	copy(items, q.items)
+	var zero T
+	for i := range q.items[:number] {
+		q.items[i] = zero // prevent memory leak
+	}
+	q.items = q.items[number:]
+	return items
+}
+
+// Empty returns a bool indicating if this queue is empty.
+func (q *TypedQueue[T]) Empty() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return len(q.items) == 0
+}
+
+// Len returns the number of items in this queue.
+func (q *TypedQueue[T]) Len() int64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return int64(len(q.items))
+}
+
+// Disposed returns a bool indicating if this queue
+// has had disposed called on it.
+func (q *TypedQueue[T]) Disposed() bool {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.disposed
+}
+
+// Dispose will dispose of this queue and returns the items disposed.
+// Any subsequent calls to Get or Put will return an error.
+func (q *TypedQueue[T]) Dispose() []T {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.disposed = true
+	for _, waiter := range q.waiters {
+		waiter.response.Add(1)
+		select {
+		case waiter.ready <- true:
+			// release Poll immediately
+		default:
+			// ignore if it's a timeout or in the get
+		}
+	}
+
+	disposedItems := q.items
+
+	q.items = nil
+	q.waiters = nil
+
+	return disposedItems
+}
+
+// NewTyped is a constructor for a new threadsafe typed queue.
+func NewTyped[T any](hint int64) *TypedQueue[T] {
+	return &TypedQueue[T]{
+		items: make([]T, 0, hint),
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queue
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedPutGet(t *testing.T) {
+	q := NewTyped[int](10)
+
+	q.Put(1, 2, 3)
+	assert.Equal(t, int64(3), q.Len())
+
+	result, err := q.Get(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, result)
+	assert.Equal(t, int64(1), q.Len())
+
+	result, err = q.Get(2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3}, result)
+	assert.True(t, q.Empty())
+
+	result, err = q.Get(0)
+	assert.Nil(t, err)
+	assert.Len(t, result, 0)
+}
+
+func TestTypedGetBlocks(t *testing.T) {
+	q := NewTyped[string](10)
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	var result []string
+	go func() {
+		result, _ = q.Get(1)
+		wg.Done()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Put(`a`)
+	wg.Wait()
+
+	assert.Equal(t, []string{`a`}, result)
+}
+
+func TestTypedPoll(t *testing.T) {
+	q := NewTyped[int](10)
+
+	_, err := q.Poll(1, time.Millisecond)
+	assert.Equal(t, ErrTimeout, err)
+
+	q.Put(1)
+	result, err := q.Poll(1, time.Millisecond)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1}, result)
+}
+
+func TestTypedDispose(t *testing.T) {
+	q := NewTyped[int](10)
+	q.Put(1)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var err error
+	empty := NewTyped[int](10)
+	go func() {
+		_, err = empty.Get(1)
+		wg.Done()
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	empty.Dispose()
+	wg.Wait()
+	assert.Equal(t, ErrDisposed, err)
+
+	assert.Equal(t, []int{1}, q.Dispose())
+	assert.True(t, q.Disposed())
+	_, err = q.Get(1)
+	assert.Equal(t, ErrDisposed, err)
+	assert.Equal(t, ErrDisposed, q.Put(2))
+}
+
+func BenchmarkTypedQueue(b *testing.B) {
+	q := NewTyped[int](int64(b.N))
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		for i := 0; i < b.N; i++ {
+			q.Get(1)
+		}
+		wg.Done()
+	}()
+
+	for i := 0; i < b.N; i++ {
+		q.Put(i)
+	}
+
+	wg.Wait()
+}
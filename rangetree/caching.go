@@ -0,0 +1,237 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"container/list"
+	"encoding/binary"
+	"sync"
+)
+
+// cachedQuery is a query result held in a CachingRangeTree's cache.
+type cachedQuery struct {
+	key     string
+	entries Entries
+}
+
+// CachingRangeTree wraps a RangeTree, making it threadsafe and
+// remembering the results of recent queries so that repeating a query
+// does not walk the tree again.  Any change to the tree clears every
+// remembered result.  At most a fixed number of results are kept, the
+// least recently used being dropped first.
+type CachingRangeTree struct {
+	// lock guards the tree and is held for writing to change it, so
+	// a result is never cached for a tree that has since changed.
+	// cacheLock guards the cache, which queries sharing a read lock
+	// all update.
+	lock       sync.RWMutex
+	cacheLock  sync.Mutex
+	tree       RangeTree
+	dimensions uint64
+	size       int
+	order      *list.List // most recently used at the front
+	cache      map[string]*list.Element
+}
+
+// key encodes the bounds of the provided interval.
+func (crt *CachingRangeTree) key(interval Interval) string {
+	buf := make([]byte, crt.dimensions*2*binary.MaxVarintLen64)
+	n := 0
+	for i := uint64(1); i <= crt.dimensions; i++ {
+		n += binary.PutVarint(buf[n:], interval.LowAtDimension(i))
+		n += binary.PutVarint(buf[n:], interval.HighAtDimension(i))
+	}
+
+	return string(buf[:n])
+}
+
+func (crt *CachingRangeTree) get(key string) (Entries, bool) {
+	crt.cacheLock.Lock()
+	defer crt.cacheLock.Unlock()
+
+	element, ok := crt.cache[key]
+	if !ok {
+		return nil, false
+	}
+
+	crt.order.MoveToFront(element)
+	return element.Value.(*cachedQuery).entries, true
+}
+
+func (crt *CachingRangeTree) put(key string, entries Entries) {
+	crt.cacheLock.Lock()
+	defer crt.cacheLock.Unlock()
+
+	if _, ok := crt.cache[key]; ok { // another query beat us to it
+		return
+	}
+
+	crt.cache[key] = crt.order.PushFront(&cachedQuery{key: key, entries: entries})
+	if crt.order.Len() > crt.size {
+		oldest := crt.order.Back()
+		crt.order.Remove(oldest)
+		delete(crt.cache, oldest.Value.(*cachedQuery).key)
+	}
+}
+
+// clear drops every cached result.  The write lock must be held.
+func (crt *CachingRangeTree) clear() {
+	crt.cacheLock.Lock()
+	defer crt.cacheLock.Unlock()
+
+	crt.order.Init()
+	crt.cache = make(map[string]*list.Element, crt.size)
+}
+
+// Add will add the provided entries to the tree and clear the cache.
+func (crt *CachingRangeTree) Add(entries ...Entry) Entries {
+	crt.lock.Lock()
+	defer crt.lock.Unlock()
+
+	crt.clear()
+	return crt.tree.Add(entries...)
+}
+
+// Len returns the number of entries in the tree.
+func (crt *CachingRangeTree) Len() uint64 {
+	crt.lock.RLock()
+	defer crt.lock.RUnlock()
+
+	return crt.tree.Len()
+}
+
+// Delete will remove the provided entries from the tree and clear the
+// cache.
+func (crt *CachingRangeTree) Delete(entries ...Entry) Entries {
+	crt.lock.Lock()
+	defer crt.lock.Unlock()
+
+	crt.clear()
+	return crt.tree.Delete(entries...)
+}
+
+// Query will return a list of entries that fall within the provided
+// interval, from the cache if the same interval has been queried since
+// the tree last changed.  The returned list belongs to the caller.
+func (crt *CachingRangeTree) Query(interval Interval) Entries {
+	crt.lock.RLock()
+	defer crt.lock.RUnlock()
+
+	key := crt.key(interval)
+	entries, ok := crt.get(key)
+	if !ok {
+		entries = crt.tree.Query(interval)
+		// the cache keeps its own copy so the caller may dispose of or
+		// modify what is returned
+		cached := make(Entries, len(entries))
+		copy(cached, entries)
+		crt.put(key, cached)
+		return entries
+	}
+
+	result := make(Entries, len(entries))
+	copy(result, entries)
+	return result
+}
+
+// QueryLimit is like Query but stops after limit entries have been
+// found.  These results are not cached.
+func (crt *CachingRangeTree) QueryLimit(interval Interval, limit int) Entries {
+	crt.lock.RLock()
+	defer crt.lock.RUnlock()
+
+	return crt.tree.QueryLimit(interval, limit)
+}
+
+// QueryReverse is like Query but returns the entries in descending
+// order.  These results are not cached.
+func (crt *CachingRangeTree) QueryReverse(interval Interval) Entries {
+	crt.lock.RLock()
+	defer crt.lock.RUnlock()
+
+	return crt.tree.QueryReverse(interval)
+}
+
+// Apply will call the provided function with each entry that exists
+// within the provided range, in order.  The tree cannot be changed
+// until Apply returns, including by the provided function.
+func (crt *CachingRangeTree) Apply(interval Interval, fn func(Entry) bool) {
+	crt.lock.RLock()
+	defer crt.lock.RUnlock()
+
+	crt.tree.Apply(interval, fn)
+}
+
+// Get returns any entries that exist at the addresses provided by the
+// given entries.
+func (crt *CachingRangeTree) Get(entries ...Entry) Entries {
+	crt.lock.RLock()
+	defer crt.lock.RUnlock()
+
+	return crt.tree.Get(entries...)
+}
+
+// InsertAtDimension will shift entries in the tree like the wrapped
+// tree's InsertAtDimension and clear the cache.
+func (crt *CachingRangeTree) InsertAtDimension(dimension uint64,
+	index, number int64) (Entries, Entries) {
+
+	crt.lock.Lock()
+	defer crt.lock.Unlock()
+
+	crt.clear()
+	return crt.tree.InsertAtDimension(dimension, index, number)
+}
+
+// Histogram counts the entries in the tree by fixed width buckets of
+// the values at the provided dimension.
+func (crt *CachingRangeTree) Histogram(dimension uint64, bucketSize int64) map[int64]uint64 {
+	crt.lock.RLock()
+	defer crt.lock.RUnlock()
+
+	return crt.tree.Histogram(dimension, bucketSize)
+}
+
+// InsertAtDimensionClamp will shift entries in the tree like the
+// wrapped tree's InsertAtDimensionClamp and clear the cache.
+func (crt *CachingRangeTree) InsertAtDimensionClamp(dimension uint64,
+	index, number int64) (Entries, Entries) {
+
+	crt.lock.Lock()
+	defer crt.lock.Unlock()
+
+	crt.clear()
+	return crt.tree.InsertAtDimensionClamp(dimension, index, number)
+}
+
+// NewCachingRangeTree wraps the provided tree, which has the provided
+// number of dimensions, and caches the results of up to size distinct
+// queries.  A size less than one is treated as one.  The wrapped tree
+// must not be used directly afterward.
+func NewCachingRangeTree(tree RangeTree, dimensions uint64, size int) *CachingRangeTree {
+	if size < 1 {
+		size = 1
+	}
+
+	return &CachingRangeTree{
+		tree:       tree,
+		dimensions: dimensions,
+		size:       size,
+		order:      list.New(),
+		cache:      make(map[string]*list.Element, size),
+	}
+}
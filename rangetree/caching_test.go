@@ -0,0 +1,159 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingTree counts the queries that reach the wrapped tree.
+type countingTree struct {
+	RangeTree
+	queries int
+}
+
+func (ct *countingTree) Query(interval Interval) Entries {
+	ct.queries++
+	return ct.RangeTree.Query(interval)
+}
+
+func newCountingCachingTree(size int) (*CachingRangeTree, *countingTree) {
+	ct := &countingTree{RangeTree: New(2)}
+	return NewCachingRangeTree(ct, 2, size), ct
+}
+
+func TestCachingQuery(t *testing.T) {
+	crt, ct := newCountingCachingTree(10)
+	e1, e2 := constructMockEntry(0, 1, 1), constructMockEntry(1, 5, 5)
+	crt.Add(e1, e2)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	assert.Equal(t, Entries{e1, e2}, crt.Query(iv))
+	assert.Equal(t, Entries{e1, e2}, crt.Query(iv))
+	assert.Equal(t, 1, ct.queries)
+
+	// a different interval is a different query
+	assert.Equal(t, Entries{e1}, crt.Query(constructMockInterval(dimension{0, 2}, dimension{0, 10})))
+	assert.Equal(t, 2, ct.queries)
+}
+
+func TestCachingResultsBelongToCaller(t *testing.T) {
+	crt, _ := newCountingCachingTree(10)
+	e1 := constructMockEntry(0, 1, 1)
+	crt.Add(e1)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	result := crt.Query(iv)
+	result[0] = nil
+	result.Dispose()
+
+	assert.Equal(t, Entries{e1}, crt.Query(iv))
+	result = crt.Query(iv)
+	result[0] = nil
+	assert.Equal(t, Entries{e1}, crt.Query(iv))
+}
+
+func TestCachingInvalidation(t *testing.T) {
+	crt, ct := newCountingCachingTree(10)
+	e1, e2 := constructMockEntry(0, 1, 1), constructMockEntry(1, 5, 5)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+	crt.Add(e1)
+	crt.Query(iv)
+
+	crt.Add(e2)
+	assert.Equal(t, Entries{e1, e2}, crt.Query(iv))
+	assert.Equal(t, 2, ct.queries)
+
+	crt.Delete(e1)
+	assert.Equal(t, Entries{e2}, crt.Query(iv))
+	assert.Equal(t, 3, ct.queries)
+
+	crt.InsertAtDimension(1, 0, 1)
+	crt.Query(iv)
+	assert.Equal(t, 4, ct.queries)
+
+	crt.InsertAtDimensionClamp(1, 0, -1)
+	crt.Query(iv)
+	assert.Equal(t, 5, ct.queries)
+	assert.Equal(t, uint64(1), crt.Len())
+}
+
+func TestCachingEviction(t *testing.T) {
+	crt, ct := newCountingCachingTree(2)
+	crt.Add(constructMockEntry(0, 1, 1))
+	iv1 := constructMockInterval(dimension{0, 1}, dimension{0, 10})
+	iv2 := constructMockInterval(dimension{0, 2}, dimension{0, 10})
+	iv3 := constructMockInterval(dimension{0, 3}, dimension{0, 10})
+
+	crt.Query(iv1)
+	crt.Query(iv2)
+	crt.Query(iv1) // iv2 is now the least recently used
+	assert.Equal(t, 2, ct.queries)
+
+	crt.Query(iv3)
+	assert.Equal(t, 3, ct.queries)
+
+	crt.Query(iv1)
+	crt.Query(iv3)
+	assert.Equal(t, 3, ct.queries)
+
+	crt.Query(iv2)
+	assert.Equal(t, 4, ct.queries)
+}
+
+func TestCachingConcurrent(t *testing.T) {
+	crt := NewCachingRangeTree(New(2), 2, 4)
+	iv := constructMockInterval(dimension{0, 100}, dimension{0, 100})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				crt.Add(constructMockEntry(uint64(i*25+j), int64(i*25+j), 0))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				crt.Query(iv)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, crt.Query(iv), 100)
+}
+
+func BenchmarkCachingQuery(b *testing.B) {
+	numItems := int64(1000)
+	tree := New(2)
+	for i := int64(0); i < numItems; i++ {
+		tree.Add(constructMockEntry(uint64(i), i, i))
+	}
+	crt := NewCachingRangeTree(tree, 2, 10)
+	iv := constructMockInterval(dimension{0, numItems}, dimension{0, numItems})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		crt.Query(iv)
+	}
+}
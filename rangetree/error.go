@@ -38,3 +38,21 @@ func (oode OutOfDimensionError) Error() string {
 		oode.provided, oode.max,
 	)
 }
+
+// InvalidEntryError is returned when an entry cannot provide a value
+// at one of a tree's dimensions.
+type InvalidEntryError struct {
+	index     int
+	dimension uint64
+	cause     interface{}
+}
+
+func (iee InvalidEntryError) Error() string {
+	if iee.dimension == 0 {
+		return fmt.Sprintf(`Entry at index: %d is nil.`, iee.index)
+	}
+
+	return fmt.Sprintf(`Entry at index: %d panicked at dimension: %d: %v`,
+		iee.index, iee.dimension, iee.cause,
+	)
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+var (
+	_ gob.GobEncoder = (*ImmutableRangeTree)(nil)
+	_ gob.GobDecoder = (*ImmutableRangeTree)(nil)
+)
+
+// gobImmutableRangeTree is what is encoded for an immutable range tree.
+// The entries are in the order returned by Query, the structure of the
+// tree is rebuilt from that order when decoding.
+type gobImmutableRangeTree struct {
+	Dimensions uint64
+	Entries    Entries
+}
+
+// GobEncode implements gob.GobEncoder.  Entries are encoded as interface
+// values, so every concrete type used as an Entry must be registered
+// with gob.Register, and be encodable by gob, before a tree holding it
+// is encoded or decoded.
+func (irt *ImmutableRangeTree) GobEncode() ([]byte, error) {
+	entries := make(Entries, 0, irt.number)
+	irt.top.flatten(&entries)
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(gobImmutableRangeTree{
+		Dimensions: irt.dimensions,
+		Entries:    entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements gob.GobDecoder.  The decoded tree replaces the
+// contents of this tree and holds the same entries, in the same
+// structure, as the tree that was encoded.  Comparators cannot be
+// encoded, so a tree that has them must be decoded into a tree with the
+// same comparators, which are kept.
+func (irt *ImmutableRangeTree) GobDecode(data []byte) error {
+	var decoded gobImmutableRangeTree
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return err
+	}
+
+	if irt.comparators != nil {
+		tree := irt.newTree()
+		tree.dimensions = decoded.Dimensions
+		*irt = *tree.Add(decoded.Entries...)
+		return nil
+	}
+
+	*irt = *NewImmutableRangeTreeFromSorted(decoded.Dimensions, decoded.Entries)
+	return nil
+}
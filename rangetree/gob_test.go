@@ -0,0 +1,103 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// gobEntry is an entry that gob is able to encode.
+type gobEntry struct {
+	ID     uint64
+	Values []int64
+}
+
+func (ge *gobEntry) ValueAtDimension(dimension uint64) int64 {
+	return ge.Values[dimension-1]
+}
+
+func init() {
+	gob.Register(&gobEntry{})
+}
+
+func roundTrip(t *testing.T, tree *ImmutableRangeTree) *ImmutableRangeTree {
+	var buf bytes.Buffer
+	if !assert.Nil(t, gob.NewEncoder(&buf).Encode(tree)) {
+		return nil
+	}
+
+	decoded := NewImmutableRangeTree(1)
+	if !assert.Nil(t, gob.NewDecoder(&buf).Decode(decoded)) {
+		return nil
+	}
+	return decoded
+}
+
+func TestImmutableGobRoundTrip(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	for i := int64(0); i < 20; i++ {
+		tree = tree.Add(&gobEntry{ID: uint64(i), Values: []int64{i % 4, 10 - i}})
+	}
+	tree = tree.Delete(&gobEntry{Values: []int64{1, 5}})
+
+	decoded := roundTrip(t, tree)
+	if decoded == nil {
+		return
+	}
+
+	assert.Equal(t, tree.Len(), decoded.Len())
+	assert.Equal(t, tree.dimensions, decoded.dimensions)
+	assert.Equal(t, tree.top, decoded.top)
+
+	for _, iv := range []*mockInterval{
+		constructMockInterval(dimension{0, 10}, dimension{-10, 10}),
+		constructMockInterval(dimension{1, 2}, dimension{0, 5}),
+		constructMockInterval(dimension{3, 3}, dimension{-10, 0}),
+	} {
+		assert.Equal(t, tree.Query(iv), decoded.Query(iv))
+	}
+
+	// the decoded tree is usable
+	decoded = decoded.Add(&gobEntry{ID: 100, Values: []int64{1, 5}})
+	assert.Equal(t, tree.Len()+1, decoded.Len())
+}
+
+func TestImmutableGobEmpty(t *testing.T) {
+	decoded := roundTrip(t, NewImmutableRangeTree(3))
+	if decoded == nil {
+		return
+	}
+
+	assert.Equal(t, uint64(0), decoded.Len())
+	assert.Equal(t, uint64(3), decoded.dimensions)
+}
+
+func TestImmutableGobUnregisteredEntry(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(2)
+
+	var buf bytes.Buffer
+	assert.NotNil(t, gob.NewEncoder(&buf).Encode(tree))
+}
+
+func TestImmutableGobDecodeInvalid(t *testing.T) {
+	tree := NewImmutableRangeTree(1)
+	assert.NotNil(t, tree.GobDecode([]byte{1, 2, 3}))
+}
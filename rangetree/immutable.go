@@ -16,12 +16,41 @@ limitations under the License.
 
 package rangetree
 
-import "github.com/Workiva/go-datastructures/slice"
+import (
+	"sort"
+	"sync"
 
-type immutableRangeTree struct {
+	"github.com/Workiva/go-datastructures/slice"
+)
+
+// ImmutableRangeTree is a range tree that is never changed once built.
+// Every operation that would change it instead returns a new tree,
+// which shares with this one everything the operation left untouched.
+// Trees may be read from any number of goroutines without locking.
+type ImmutableRangeTree struct {
 	number     uint64
 	top        orderedNodes
 	dimensions uint64
+	// comparators order the values at each dimension, indexed by
+	// dimension - 1.  A missing or nil comparator orders ascending.
+	comparators []comparator
+}
+
+// comparator returns the comparator for the provided dimension.
+func (irt *ImmutableRangeTree) comparator(dimension uint64) comparator {
+	if dimension == 0 || dimension > uint64(len(irt.comparators)) {
+		return nil
+	}
+
+	return irt.comparators[dimension-1]
+}
+
+// newTree returns an empty tree with the dimensions and comparators of
+// this tree.
+func (irt *ImmutableRangeTree) newTree() *ImmutableRangeTree {
+	tree := NewImmutableRangeTree(irt.dimensions)
+	tree.comparators = irt.comparators
+	return tree
 }
 
 func newCache(dimensions uint64) []slice.Int64Slice {
@@ -32,93 +61,375 @@ func newCache(dimensions uint64) []slice.Int64Slice {
 	return cache
 }
 
-func (irt *immutableRangeTree) needNextDimension() bool {
+func (irt *ImmutableRangeTree) needNextDimension() bool {
 	return irt.dimensions > 1
 }
 
-func (irt *immutableRangeTree) add(nodes *orderedNodes, cache []slice.Int64Slice, entry Entry, added *uint64) {
-	var node *node
+// add adds the entry beneath the provided nodes, which must already be
+// a copy.  Any node on the way down that is not in copied may be shared
+// with another tree, so it is replaced with a copy before its list is
+// changed.  copied lets a batch of adds copy each node only once.
+func (irt *ImmutableRangeTree) add(nodes *orderedNodes, copied map[*node]bool, entry Entry, added *uint64) {
 	list := nodes
 
 	for i := uint64(1); i <= irt.dimensions; i++ {
+		c := irt.comparator(i)
 		if isLastDimension(irt.dimensions, i) {
-			if i != 1 && !cache[i-1].Exists(node.value) {
-				nodes := make(orderedNodes, len(*list))
-				copy(nodes, *list)
-				list = &nodes
-				cache[i-1].Insert(node.value)
-			}
-
 			newNode := newNode(entry.ValueAtDimension(i), entry, false)
-			overwritten := list.add(newNode)
+			overwritten := list.addBy(newNode, c)
 			if overwritten == nil {
 				*added++
 			}
-			if node != nil {
-				node.orderedNodes = *list
-			}
 			break
 		}
 
-		if i != 1 && !cache[i-1].Exists(node.value) {
-			nodes := make(orderedNodes, len(*list))
-			copy(nodes, *list)
-			list = &nodes
-			cache[i-1].Insert(node.value)
-			node.orderedNodes = *list
+		node, created := list.getOrAddBy(entry, i, irt.dimensions, c)
+		if !created && !copied[node] {
+			nn := newNode(node.value, nil, true)
+			nn.orderedNodes = make(orderedNodes, len(node.orderedNodes))
+			copy(nn.orderedNodes, node.orderedNodes)
+			(*list)[list.searchBy(node.value, c)] = nn
+			node = nn
 		}
-
-		node, _ = list.getOrAdd(entry, i, irt.dimensions)
+		copied[node] = true
 		list = &node.orderedNodes
 	}
 }
 
 // Add will add the provided entries into the tree and return
 // a new tree with those entries added.
-func (irt *immutableRangeTree) Add(entries ...Entry) *immutableRangeTree {
+func (irt *ImmutableRangeTree) Add(entries ...Entry) *ImmutableRangeTree {
 	if len(entries) == 0 {
 		return irt
 	}
 
-	cache := newCache(irt.dimensions)
+	copied := make(map[*node]bool)
+	top := make(orderedNodes, len(irt.top))
+	copy(top, irt.top)
+	added := uint64(0)
+	for _, entry := range entries {
+		irt.add(&top, copied, entry, &added)
+	}
+
+	tree := irt.newTree()
+	tree.top = top
+	tree.number = irt.number + added
+	return tree
+}
+
+// TryAdd will add the provided entries like Add, but only once each has
+// been checked to provide a value at every dimension of this tree.  If
+// any entry is nil or panics when asked for a value, none are added and
+// this tree is returned along with an InvalidEntryError.
+func (irt *ImmutableRangeTree) TryAdd(entries ...Entry) (*ImmutableRangeTree, error) {
+	for i, entry := range entries {
+		if err := irt.validate(i, entry); err != nil {
+			return irt, err
+		}
+	}
+
+	return irt.Add(entries...), nil
+}
+
+// validate asks the provided entry, which is at index i of the entries
+// given to TryAdd, for its value at every dimension.
+func (irt *ImmutableRangeTree) validate(i int, entry Entry) (err error) {
+	if entry == nil {
+		return InvalidEntryError{index: i}
+	}
+
+	var dimension uint64
+	defer func() {
+		if r := recover(); r != nil {
+			err = InvalidEntryError{index: i, dimension: dimension, cause: r}
+		}
+	}()
+
+	for dimension = 1; dimension <= irt.dimensions; dimension++ {
+		entry.ValueAtDimension(dimension)
+	}
+
+	return nil
+}
+
+// AddMerge will add the provided entries like Add, except that when an
+// entry collides with one already at the same point, that point is
+// given the entry returned by merge instead of the incoming entry.
+// Collisions between the provided entries are merged in order.  The
+// merged entry must be at the same point and this panics if it is not.
+func (irt *ImmutableRangeTree) AddMerge(merge func(existing, incoming Entry) Entry,
+	entries ...Entry) *ImmutableRangeTree {
+
+	if len(entries) == 0 {
+		return irt
+	}
+
+	copied := make(map[*node]bool)
 	top := make(orderedNodes, len(irt.top))
 	copy(top, irt.top)
 	added := uint64(0)
 	for _, entry := range entries {
-		irt.add(&top, cache, entry, &added)
+		if existing := irt.find(top, entry); existing != nil {
+			merged := merge(existing, entry)
+			irt.checkReplacement(existing, merged)
+			entry = merged
+		}
+		irt.add(&top, copied, entry, &added)
 	}
 
-	tree := newImmutableRangeTree(irt.dimensions)
+	tree := irt.newTree()
 	tree.top = top
 	tree.number = irt.number + added
 	return tree
 }
 
+// AddIfVersion will add the provided entry like Add, but only if the
+// version of the entry stored at the same point is expectedVersion.  A
+// point without an entry, or whose entry is not a VersionedEntry, has a
+// version of zero.  The tree never sets a version, so the provided
+// entry should carry the version that follows expectedVersion for the
+// next caller to check against.  If the versions differ this tree is
+// returned unchanged along with false.
+func (irt *ImmutableRangeTree) AddIfVersion(entry Entry,
+	expectedVersion uint64) (*ImmutableRangeTree, bool) {
+
+	if entryVersion(irt.find(irt.top, entry)) != expectedVersion {
+		return irt, false
+	}
+
+	return irt.Add(entry), true
+}
+
+// entryVersion returns the version of the provided entry, which is zero
+// if it is nil or not versioned.
+func entryVersion(entry Entry) uint64 {
+	if ve, ok := entry.(VersionedEntry); ok {
+		return ve.Version()
+	}
+
+	return 0
+}
+
 // InsertAtDimension will increment items at and above the given index
 // by the number provided.  Provide a negative number to to decrement.
 // Returned are two lists and the modified tree.  The first list is a
 // list of entries that were moved.  The second is a list entries that
 // were deleted.  These lists are exclusive.
-func (irt *immutableRangeTree) InsertAtDimension(dimension uint64,
-	index, number int64) (*immutableRangeTree, Entries, Entries) {
+func (irt *ImmutableRangeTree) InsertAtDimension(dimension uint64,
+	index, number int64) (*ImmutableRangeTree, Entries, Entries) {
 
-	if dimension > irt.dimensions || number == 0 {
+	if dimension < 1 || dimension > irt.dimensions || number == 0 {
 		return irt, nil, nil
 	}
 
-	modified, deleted := make(Entries, 0, 100), make(Entries, 0, 100)
+	deleted := make(Entries, 0, 100)
+	tree, modified := irt.InsertAtDimensionFunc(dimension, index, number,
+		func(entry Entry) {
+			deleted = append(deleted, entry)
+		},
+	)
+
+	return tree, modified, deleted
+}
+
+// InsertAtDimensionFunc is like InsertAtDimension except that, rather
+// than returning the deleted entries, it calls onDelete with each one as
+// it is deleted.
+func (irt *ImmutableRangeTree) InsertAtDimensionFunc(dimension uint64,
+	index, number int64, onDelete func(Entry)) (*ImmutableRangeTree, Entries) {
+
+	if dimension < 1 || dimension > irt.dimensions || number == 0 {
+		return irt, nil
+	}
+
+	modified := make(Entries, 0, 100)
+	deleted := uint64(0)
 
-	tree := newImmutableRangeTree(irt.dimensions)
+	tree := irt.newTree()
 	tree.top = irt.top.immutableInsert(
 		dimension, 1, irt.dimensions,
-		index, number,
-		&modified, &deleted,
+		index, number, irt.comparator(dimension),
+		&modified, func(entry Entry) {
+			deleted++
+			onDelete(entry)
+		},
 	)
+	tree.number = irt.number - deleted
+
+	return tree, modified
+}
+
+// InsertAtDimensionRange is like InsertAtDimension except that only the
+// entries with a value in the inclusive band [from, to] at the provided
+// dimension are moved, and any entries moved outside of the band are
+// deleted.  Entries outside of the band are untouched, so no entry can
+// be moved onto another.  Branches holding no entry in the band are
+// shared with this tree, which is returned as is if nothing is moved.
+func (irt *ImmutableRangeTree) InsertAtDimensionRange(dimension uint64,
+	from, to, number int64) (*ImmutableRangeTree, Entries, Entries) {
+
+	if dimension < 1 || dimension > irt.dimensions || number == 0 {
+		return irt, nil, nil
+	}
+
+	c := irt.comparator(dimension)
+	if c.compare(from, to) > 0 {
+		return irt, nil, nil
+	}
+
+	modified, deleted := make(Entries, 0, 100), make(Entries, 0, 100)
+
+	top, changed := irt.top.immutableInsertRange(
+		dimension, 1, irt.dimensions,
+		from, to, number, c,
+		&modified, func(entry Entry) {
+			deleted = append(deleted, entry)
+		},
+	)
+	if !changed {
+		return irt, modified, deleted
+	}
+
+	tree := irt.newTree()
+	tree.top = top
 	tree.number = irt.number - uint64(len(deleted))
 
 	return tree, modified, deleted
 }
 
+// ShiftOp is a single shift of InsertAtDimensionBatch: values at and
+// above Index are moved by Number.
+type ShiftOp struct {
+	Index, Number int64
+}
+
+// InsertAtDimensionBatch applies several shifts at the provided
+// dimension in a single traversal.  The ops are sorted by ascending
+// Index, keeping the given order of ops with the same Index, and
+// applied one after another, so each op's Index is in terms of the
+// values left by the ops before it.  The result is the same as calling
+// InsertAtDimension with each op in that order.  Returned are the
+// modified tree and two lists.  The first list is a list of entries
+// whose value at the dimension has changed, entries that are moved
+// back to where they started are not included.  The second is a list
+// of entries that were deleted by any of the ops.  These lists are
+// exclusive.
+func (irt *ImmutableRangeTree) InsertAtDimensionBatch(dimension uint64,
+	ops []ShiftOp) (*ImmutableRangeTree, Entries, Entries) {
+
+	if dimension < 1 || dimension > irt.dimensions {
+		return irt, nil, nil
+	}
+
+	sorted := make([]ShiftOp, 0, len(ops))
+	for _, op := range ops {
+		if op.Number != 0 {
+			sorted = append(sorted, op)
+		}
+	}
+	if len(sorted) == 0 {
+		return irt, nil, nil
+	}
+	c := irt.comparator(dimension)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return c.compare(sorted[i].Index, sorted[j].Index) < 0
+	})
+
+	modified, deleted := NewEntries(), NewEntries()
+	tree := irt.newTree()
+	tree.top = irt.shiftBatch(irt.top, dimension, 1, sorted, &modified, &deleted)
+	tree.number = irt.number - uint64(len(deleted))
+
+	return tree, modified, deleted
+}
+
+// shift returns the value the provided value is moved to by the ops,
+// sorted by the provided comparator, and a bool indicating if it was
+// deleted by one of them.
+func shift(value int64, ops []ShiftOp, c comparator) (int64, bool) {
+	for _, op := range ops {
+		if c.compare(value, op.Index) < 0 {
+			continue
+		}
+		value += op.Number
+		if c.compare(value, op.Index) < 0 {
+			return value, true
+		}
+	}
+
+	return value, false
+}
+
+// shiftBatch returns the nodes in list with the sorted ops applied at
+// the insert dimension.  Branches left empty are removed and list is
+// returned as is if nothing changed.
+func (irt *ImmutableRangeTree) shiftBatch(list orderedNodes, insertDimension, dimension uint64,
+	ops []ShiftOp, modified, deleted *Entries) orderedNodes {
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	var result orderedNodes // only allocated once something changes
+
+	start := 0
+	if dimension == insertDimension {
+		// no op moves a value below the lowest index
+		start = list.searchBy(ops[0].Index, irt.comparator(dimension))
+	}
+
+	for i := start; i < len(list); i++ {
+		n := list[i]
+		keep := n
+		if dimension == insertDimension {
+			value, isDeleted := shift(n.value, ops, irt.comparator(dimension))
+			switch {
+			case isDeleted:
+				keep = nil
+				if lastDimension {
+					*deleted = append(*deleted, n.entry)
+				} else {
+					n.orderedNodes.flatten(deleted)
+				}
+			case value != n.value:
+				keep = &node{value: value, entry: n.entry, orderedNodes: n.orderedNodes}
+				if lastDimension {
+					*modified = append(*modified, n.entry)
+				} else {
+					n.orderedNodes.flatten(modified)
+				}
+			}
+		} else {
+			changes := len(*modified) + len(*deleted)
+			nodes := irt.shiftBatch(n.orderedNodes, insertDimension, dimension+1,
+				ops, modified, deleted,
+			)
+			if len(*modified)+len(*deleted) != changes {
+				keep = nil
+				if len(nodes) > 0 {
+					keep = newNode(n.value, nil, true)
+					keep.orderedNodes = nodes
+				}
+			}
+		}
+
+		if keep == n && result == nil {
+			continue
+		}
+
+		if result == nil {
+			result = make(orderedNodes, i, len(list))
+			copy(result, list[:i])
+		}
+		if keep != nil {
+			result = append(result, keep)
+		}
+	}
+
+	if result == nil {
+		return list
+	}
+
+	return result
+}
+
 type immutableNodeBundle struct {
 	list         *orderedNodes
 	index        int
@@ -126,7 +437,7 @@ type immutableNodeBundle struct {
 	newNode      *node
 }
 
-func (irt *immutableRangeTree) Delete(entries ...Entry) *immutableRangeTree {
+func (irt *ImmutableRangeTree) Delete(entries ...Entry) *ImmutableRangeTree {
 	cache := newCache(irt.dimensions)
 	top := make(orderedNodes, len(irt.top))
 	copy(top, irt.top)
@@ -135,13 +446,77 @@ func (irt *immutableRangeTree) Delete(entries ...Entry) *immutableRangeTree {
 		irt.delete(&top, cache, entry, &deleted)
 	}
 
-	tree := newImmutableRangeTree(irt.dimensions)
+	tree := irt.newTree()
 	tree.top = top
 	tree.number = irt.number - deleted
 	return tree
 }
 
-func (irt *immutableRangeTree) delete(top *orderedNodes,
+// DeleteInterval returns a new tree without the entries in the provided
+// interval and the entries that were removed, in the order of Query.
+// This is done in a single pass, branches left empty are removed and
+// unchanged branches are shared with this tree.
+func (irt *ImmutableRangeTree) DeleteInterval(interval Interval) (*ImmutableRangeTree, Entries) {
+	deleted := NewEntries()
+	top := irt.deleteInterval(irt.top, interval, 1, &deleted)
+	if len(deleted) == 0 {
+		return irt, deleted
+	}
+
+	tree := irt.newTree()
+	tree.top = top
+	tree.number = irt.number - uint64(len(deleted))
+	return tree, deleted
+}
+
+// deleteInterval returns the nodes in list without the entries in the
+// provided interval, appending the removed entries to deleted.  list is
+// returned as is if nothing was removed.
+func (irt *ImmutableRangeTree) deleteInterval(list orderedNodes, interval Interval,
+	dimension uint64, deleted *Entries) orderedNodes {
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	var result orderedNodes // only allocated once something changes
+
+	start, end := irt.span(list, interval, dimension)
+	i := start
+	for ; i < end; i++ {
+		n := list[i]
+		var keep *node
+		if !lastDimension {
+			before := len(*deleted)
+			nodes := irt.deleteInterval(n.orderedNodes, interval, dimension+1, deleted)
+			if len(*deleted) == before {
+				keep = n
+			} else if len(nodes) > 0 {
+				keep = newNode(n.value, nil, true)
+				keep.orderedNodes = nodes
+			}
+		} else {
+			*deleted = append(*deleted, n.entry)
+		}
+
+		if keep == n && result == nil {
+			continue
+		}
+
+		if result == nil {
+			result = make(orderedNodes, i, len(list)-1)
+			copy(result, list[:i])
+		}
+		if keep != nil {
+			result = append(result, keep)
+		}
+	}
+
+	if result == nil {
+		return list
+	}
+
+	return append(result, list[i:]...)
+}
+
+func (irt *ImmutableRangeTree) delete(top *orderedNodes,
 	cache []slice.Int64Slice, entry Entry, deleted *uint64) {
 
 	path := make([]*immutableNodeBundle, 0, 5)
@@ -152,7 +527,7 @@ func (irt *immutableRangeTree) delete(top *orderedNodes,
 
 	for i := uint64(1); i <= irt.dimensions; i++ {
 		value := entry.ValueAtDimension(i)
-		local, index = list.get(value)
+		local, index = list.getBy(value, irt.comparator(i))
 		if local == nil { // there's nothing to delete
 			return
 		}
@@ -175,7 +550,9 @@ func (irt *immutableRangeTree) delete(top *orderedNodes,
 			nodes := make(orderedNodes, len(*nb.list))
 			copy(nodes, *nb.list)
 			nb.list = &nodes
-			if len(*nb.list) == 1 {
+			// the list is only left empty if its last node is deleted
+			// rather than replaced
+			if len(*nb.list) == 1 && nb.newNode == nil {
 				continue
 			}
 			nn := newNode(
@@ -184,6 +561,8 @@ func (irt *immutableRangeTree) delete(top *orderedNodes,
 				!isLastDimension(irt.dimensions, uint64(i)+1),
 			)
 			nn.orderedNodes = nodes
+			// modify the new node's list so it sees the delete below
+			nb.list = &nn.orderedNodes
 			path[i-1].newNode = nn
 		}
 	}
@@ -197,33 +576,56 @@ func (irt *immutableRangeTree) delete(top *orderedNodes,
 	}
 }
 
-func (irt *immutableRangeTree) apply(list orderedNodes, interval Interval,
-	dimension uint64, fn func(*node) bool) bool {
+// span returns the range of indices, [start, end), of the nodes in list
+// within the interval at the provided dimension.  The nodes are found
+// by binary search in the dimension's order, so a bound removed by an
+// unbounded interval needs no search at all.
+func (irt *ImmutableRangeTree) span(list orderedNodes, interval Interval, dimension uint64) (int, int) {
+	b := boundsAt(interval, dimension)
+	c := irt.comparator(dimension)
+	start, end := list.span(b.low, b.high, b.openLow, b.openHigh, c)
+	// values are unique in a list, so an excluded bound is at most the
+	// first or last node found
+	if b.exclusiveLow && start < end && c.compare(list[start].value, b.low) == 0 {
+		start++
+	}
+	if b.exclusiveHigh && start < end && c.compare(list[end-1].value, b.high) == 0 {
+		end--
+	}
+
+	return start, end
+}
 
-	low, high := interval.LowAtDimension(dimension), interval.HighAtDimension(dimension)
+func (irt *ImmutableRangeTree) apply(list orderedNodes, interval Interval,
+	dimension uint64, fn func(*node) bool) bool {
 
-	if isLastDimension(irt.dimensions, dimension) {
-		if !list.apply(low, high, fn) {
-			return false
-		}
-	} else {
-		if !list.apply(low, high, func(n *node) bool {
-			if !irt.apply(n.orderedNodes, interval, dimension+1, fn) {
+	start, end := irt.span(list, interval, dimension)
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	for _, n := range list[start:end] {
+		if lastDimension {
+			if !fn(n) {
 				return false
 			}
-			return true
-		}) {
+		} else if !irt.apply(n.orderedNodes, interval, dimension+1, fn) {
 			return false
 		}
-		return true
 	}
 
 	return true
 }
 
+// Apply will call the provided function with each entry that exists
+// within the provided range, in the order of Query.  Return false at
+// any time to stop the traversal.
+func (irt *ImmutableRangeTree) Apply(interval Interval, fn func(Entry) bool) {
+	irt.apply(irt.top, interval, 1, func(n *node) bool {
+		return fn(n.entry)
+	})
+}
+
 // Query will return an ordered list of results in the given
 // interval.
-func (irt *immutableRangeTree) Query(interval Interval) Entries {
+func (irt *ImmutableRangeTree) Query(interval Interval) Entries {
 	entries := NewEntries()
 
 	irt.apply(irt.top, interval, 1, func(n *node) bool {
@@ -234,10 +636,207 @@ func (irt *immutableRangeTree) Query(interval Interval) Entries {
 	return entries
 }
 
-func (irt *immutableRangeTree) get(entry Entry) Entry {
-	on := irt.top
+// applyReverse is like apply but visits the nodes in the reverse order
+// at every dimension.
+func (irt *ImmutableRangeTree) applyReverse(list orderedNodes, interval Interval,
+	dimension uint64, fn func(*node) bool) bool {
+
+	start, end := irt.span(list, interval, dimension)
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	for i := end - 1; i >= start; i-- {
+		if lastDimension {
+			if !fn(list[i]) {
+				return false
+			}
+		} else if !irt.applyReverse(list[i].orderedNodes, interval, dimension+1, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApplyReverse is like Apply but visits the entries in the reverse of
+// the order of Query.
+func (irt *ImmutableRangeTree) ApplyReverse(interval Interval, fn func(Entry) bool) {
+	irt.applyReverse(irt.top, interval, 1, func(n *node) bool {
+		return fn(n.entry)
+	})
+}
+
+// QueryReverse is like Query but returns the entries in descending
+// order of the value at the first dimension, then the second, and so
+// on, the exact reverse of the order of Query.
+func (irt *ImmutableRangeTree) QueryReverse(interval Interval) Entries {
+	entries := NewEntries()
+
+	irt.applyReverse(irt.top, interval, 1, func(n *node) bool {
+		entries = append(entries, n.entry)
+		return true
+	})
+
+	return entries
+}
+
+// QueryParallel will return the same entries as Query, in the same
+// order, querying up to the provided number of chunks of the tree
+// concurrently.  Chunks are chosen from the nodes of the first
+// dimension within the interval, which are split into runs of equal
+// length, one per worker, so chunks are even in the number of values at
+// the first dimension rather than in the number of entries they hold.
+// The results of the chunks are concatenated in order.  Fewer than two
+// workers, or fewer first dimension nodes than workers, queries as
+// Query does.
+func (irt *ImmutableRangeTree) QueryParallel(interval Interval, workers int) Entries {
+	start, end := irt.span(irt.top, interval, 1)
+	if workers < 2 || end-start < workers {
+		return irt.Query(interval)
+	}
+
+	chunk := (end - start + workers - 1) / workers
+	results := make([]Entries, 0, workers)
+	for i := start; i < end; i += chunk {
+		results = append(results, nil)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(results))
+	for i := range results {
+		from := start + i*chunk
+		to := from + chunk
+		if to > end {
+			to = end
+		}
+		go func(i int, list orderedNodes) {
+			defer wg.Done()
+			irt.apply(list, interval, 1, func(n *node) bool {
+				results[i] = append(results[i], n.entry)
+				return true
+			})
+		}(i, irt.top[from:to])
+	}
+	wg.Wait()
+
+	total := 0
+	for _, result := range results {
+		total += len(result)
+	}
+
+	entries := make(Entries, 0, total)
+	for _, result := range results {
+		entries = append(entries, result...)
+	}
+
+	return entries
+}
+
+// QueryAll will return the entries that fall within any of the provided
+// intervals in the order of Query.  The tree is walked once and an
+// entry within several of the intervals is only returned once.
+func (irt *ImmutableRangeTree) QueryAll(intervals ...Interval) Entries {
+	entries := NewEntries()
+	if len(intervals) == 0 {
+		return entries
+	}
+
+	// the intervals matching a node are kept for each dimension below,
+	// so only one list per dimension is allocated
+	matching := make([][]Interval, irt.dimensions+1)
+	matching[0] = intervals
+	irt.queryAll(irt.top, 1, matching, &entries)
+
+	return entries
+}
+
+// withinAt returns a bool indicating if the provided value is within the
+// interval at the provided dimension in the order of the comparator.
+func withinAt(interval Interval, dimension uint64, value int64, c comparator) bool {
+	return boundsAt(interval, dimension).within(value, c)
+}
+
+func (irt *ImmutableRangeTree) queryAll(list orderedNodes, dimension uint64,
+	matching [][]Interval, entries *Entries) {
+
+	intervals := matching[dimension-1]
+	start, end := irt.span(list, intervals[0], dimension)
+	for _, interval := range intervals[1:] {
+		s, e := irt.span(list, interval, dimension)
+		if s < start {
+			start = s
+		}
+		if e > end {
+			end = e
+		}
+	}
+
+	c := irt.comparator(dimension)
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	for _, n := range list[start:end] {
+		within := matching[dimension][:0]
+		for _, interval := range intervals {
+			if withinAt(interval, dimension, n.value, c) {
+				within = append(within, interval)
+			}
+		}
+		matching[dimension] = within
+
+		if len(within) == 0 {
+			continue
+		}
+
+		if lastDimension {
+			*entries = append(*entries, n.entry)
+		} else {
+			irt.queryAll(n.orderedNodes, dimension+1, matching, entries)
+		}
+	}
+}
+
+// CountInInterval returns the number of entries in the given interval
+// without collecting them.  An interval that is inverted at any
+// dimension contains no entries.
+func (irt *ImmutableRangeTree) CountInInterval(interval Interval) uint64 {
+	count := uint64(0)
+	irt.apply(irt.top, interval, 1, func(n *node) bool {
+		count++
+		return true
+	})
+
+	return count
+}
+
+// QueryLimit will return the results in the given interval in the
+// order of Query, skipping the first offset results and stopping once
+// limit results have been found, so a page of results is found without
+// visiting the rest of the tree.  A limit less than one means there is
+// no limit and a negative offset is treated as zero.  Note that this
+// differs from QueryLimit of RangeTree, which takes no offset and
+// returns nothing for a limit less than one.
+func (irt *ImmutableRangeTree) QueryLimit(interval Interval, offset, limit int) Entries {
+	entries := NewEntries()
+
+	irt.apply(irt.top, interval, 1, func(n *node) bool {
+		if offset > 0 {
+			offset--
+			return true
+		}
+
+		entries = append(entries, n.entry)
+		return limit < 1 || len(entries) < limit
+	})
+
+	return entries
+}
+
+func (irt *ImmutableRangeTree) get(entry Entry) Entry {
+	return irt.find(irt.top, entry)
+}
+
+// find returns the entry in the provided nodes at the same point as
+// the provided entry, or nil if there isn't one.
+func (irt *ImmutableRangeTree) find(on orderedNodes, entry Entry) Entry {
 	for i := uint64(1); i <= irt.dimensions; i++ {
-		n, _ := on.get(entry.ValueAtDimension(i))
+		n, _ := on.getBy(entry.ValueAtDimension(i), irt.comparator(i))
 		if n == nil {
 			return nil
 		}
@@ -254,7 +853,7 @@ func (irt *immutableRangeTree) get(entry Entry) Entry {
 // given entries.  Entries are returned in the order in which they are
 // received.  If an entry cannot be found, a nil is returned in its
 // place.
-func (irt *immutableRangeTree) Get(entries ...Entry) Entries {
+func (irt *ImmutableRangeTree) Get(entries ...Entry) Entries {
 	result := make(Entries, 0, len(entries))
 	for _, entry := range entries {
 		result = append(result, irt.get(entry))
@@ -263,13 +862,814 @@ func (irt *immutableRangeTree) Get(entries ...Entry) Entries {
 	return result
 }
 
+// GetAt returns the entry at the point with the provided value at each
+// dimension, in order, and a bool indicating if there is one.  Unlike
+// Get and Query this needs no entry or interval and does not allocate.
+// If the number of values does not match the tree's dimensions nil and
+// false are returned.
+func (irt *ImmutableRangeTree) GetAt(values ...int64) (Entry, bool) {
+	if uint64(len(values)) != irt.dimensions {
+		return nil, false
+	}
+
+	on := irt.top
+	for i, value := range values {
+		n, _ := on.getBy(value, irt.comparator(uint64(i)+1))
+		if n == nil {
+			return nil, false
+		}
+		if i == len(values)-1 {
+			return n.entry, true
+		}
+		on = n.orderedNodes
+	}
+
+	return nil, false
+}
+
+// Contains returns a bool indicating if there is an entry at the point
+// with the provided value at each dimension, in order.  If the number
+// of values does not match the tree's dimensions false is returned.
+func (irt *ImmutableRangeTree) Contains(values ...int64) bool {
+	_, ok := irt.GetAt(values...)
+	return ok
+}
+
+// Mutate returns a new tree in which every entry in the provided
+// interval has been replaced with what fn returns for it, and the
+// number of entries that were replaced.  Returning the same entry
+// leaves it in place.  As only the leaves change, fn must not change
+// an entry's value at any dimension and this panics if it does.  This
+// tree is not modified and unchanged branches are shared with it.
+func (irt *ImmutableRangeTree) Mutate(interval Interval,
+	fn func(Entry) Entry) (*ImmutableRangeTree, uint64) {
+
+	modified := uint64(0)
+	tree := irt.newTree()
+	tree.top = irt.mutate(irt.top, interval, 1, fn, &modified)
+	tree.number = irt.number
+	return tree, modified
+}
+
+// mutate returns list with the entries in the interval replaced,
+// copying only the nodes on the path to a replaced entry.  list is
+// returned as is if nothing was replaced.
+func (irt *ImmutableRangeTree) mutate(list orderedNodes, interval Interval,
+	dimension uint64, fn func(Entry) Entry, modified *uint64) orderedNodes {
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	var result orderedNodes // only allocated once something changes
+	start, end := irt.span(list, interval, dimension)
+	for i := start; i < end; i++ {
+		n := list[i]
+		var replacement *node
+		if lastDimension {
+			entry := fn(n.entry)
+			if entry == n.entry {
+				continue
+			}
+			irt.checkReplacement(n.entry, entry)
+			replacement = newNode(n.value, entry, false)
+			*modified++
+		} else {
+			before := *modified
+			nodes := irt.mutate(n.orderedNodes, interval, dimension+1, fn, modified)
+			if *modified == before {
+				continue
+			}
+			replacement = newNode(n.value, nil, true)
+			replacement.orderedNodes = nodes
+		}
+
+		if result == nil {
+			result = make(orderedNodes, len(list))
+			copy(result, list)
+		}
+		result[i] = replacement
+	}
+
+	if result == nil {
+		return list
+	}
+
+	return result
+}
+
+// checkReplacement panics if the replacement entry is not at the same
+// point as the original.
+func (irt *ImmutableRangeTree) checkReplacement(original, replacement Entry) {
+	if replacement == nil {
+		panic(`rangetree: cannot replace an entry with nil`)
+	}
+
+	for i := uint64(1); i <= irt.dimensions; i++ {
+		if original.ValueAtDimension(i) != replacement.ValueAtDimension(i) {
+			panic(`rangetree: cannot replace an entry with one at a different point`)
+		}
+	}
+}
+
+// Difference returns a new tree with the entries in this tree that have
+// no entry at the same point, the same value at every dimension, in the
+// other tree.  Neither tree is modified and unchanged branches are shared
+// with this tree.  This panics if the trees' dimensions do not match.
+func (irt *ImmutableRangeTree) Difference(other *ImmutableRangeTree) *ImmutableRangeTree {
+	if irt.dimensions != other.dimensions {
+		panic(`rangetree: cannot take difference of trees with different dimensions`)
+	}
+
+	removed := uint64(0)
+	tree := irt.newTree()
+	tree.top = irt.difference(irt.top, other.top, 1, &removed)
+	tree.number = irt.number - removed
+	return tree
+}
+
+// difference returns the nodes in list that are not in other, counting
+// the entries left out in removed.  list is returned as is if nothing
+// was left out.
+func (irt *ImmutableRangeTree) difference(list, other orderedNodes,
+	dimension uint64, removed *uint64) orderedNodes {
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	c := irt.comparator(dimension)
+	var result orderedNodes // only allocated once something changes
+	j := 0
+	for i, n := range list {
+		for j < len(other) && c.compare(other[j].value, n.value) < 0 {
+			j++
+		}
+
+		keep := n
+		if j < len(other) && other[j].value == n.value {
+			if lastDimension {
+				keep = nil
+				*removed++
+			} else {
+				before := *removed
+				nodes := irt.difference(n.orderedNodes, other[j].orderedNodes,
+					dimension+1, removed,
+				)
+				if *removed != before {
+					keep = nil
+					if len(nodes) > 0 {
+						keep = newNode(n.value, nil, true)
+						keep.orderedNodes = nodes
+					}
+				}
+			}
+		}
+
+		if keep == n && result == nil {
+			continue
+		}
+
+		if result == nil {
+			result = make(orderedNodes, i, len(list))
+			copy(result, list[:i])
+		}
+		if keep != nil {
+			result = append(result, keep)
+		}
+	}
+
+	if result == nil {
+		return list
+	}
+
+	return result
+}
+
+// Join calls fn with every pair of entries, a from this tree and b from
+// the other tree, that are at the same point.  Pairs are visited in the
+// order of Query.  Only the branches present in both trees are
+// traversed.  This panics if the trees' dimensions do not match.
+func (irt *ImmutableRangeTree) Join(other *ImmutableRangeTree, fn func(a, b Entry)) {
+	if irt.dimensions != other.dimensions {
+		panic(`rangetree: cannot join trees with different dimensions`)
+	}
+
+	irt.join(irt.top, other.top, 1, fn)
+}
+
+// join calls fn with the entries of every pair of nodes in list and
+// other at the same point.
+func (irt *ImmutableRangeTree) join(list, other orderedNodes,
+	dimension uint64, fn func(a, b Entry)) {
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	j := 0
+	for _, n := range list {
+		j += other[j:].searchBy(n.value, irt.comparator(dimension))
+		if j == len(other) {
+			return
+		}
+		if other[j].value != n.value {
+			continue
+		}
+
+		if lastDimension {
+			fn(n.entry, other[j].entry)
+		} else {
+			irt.join(n.orderedNodes, other[j].orderedNodes, dimension+1, fn)
+		}
+	}
+}
+
+// Merge returns a new tree with the entries of both this tree and the
+// other tree.  Where both trees have an entry at the same point the
+// entry from the other tree is kept, as if it had been added to this
+// tree, and it is only counted once.  Neither tree is modified and the
+// branches found in only one of the trees are shared with it.  This
+// panics if the trees' dimensions do not match.
+func (irt *ImmutableRangeTree) Merge(other *ImmutableRangeTree) *ImmutableRangeTree {
+	if irt.dimensions != other.dimensions {
+		panic(`rangetree: cannot merge trees with different dimensions`)
+	}
+
+	collided := uint64(0)
+	tree := irt.newTree()
+	tree.top = irt.merge(irt.top, other.top, 1, &collided)
+	tree.number = irt.number + other.number - collided
+	return tree
+}
+
+// merge returns the union of the nodes in list and other, preferring
+// other at the last dimension and counting those collisions in
+// collided.
+func (irt *ImmutableRangeTree) merge(list, other orderedNodes,
+	dimension uint64, collided *uint64) orderedNodes {
+
+	if len(other) == 0 {
+		return list
+	}
+	if len(list) == 0 {
+		return other
+	}
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	c := irt.comparator(dimension)
+	result := make(orderedNodes, 0, len(list)+len(other))
+	i, j := 0, 0
+	for i < len(list) && j < len(other) {
+		switch {
+		case c.compare(list[i].value, other[j].value) < 0:
+			result = append(result, list[i])
+			i++
+		case c.compare(list[i].value, other[j].value) > 0:
+			result = append(result, other[j])
+			j++
+		default:
+			if lastDimension {
+				result = append(result, other[j])
+				*collided++
+			} else {
+				n := newNode(list[i].value, nil, false)
+				n.orderedNodes = irt.merge(list[i].orderedNodes, other[j].orderedNodes,
+					dimension+1, collided,
+				)
+				result = append(result, n)
+			}
+			i++
+			j++
+		}
+	}
+
+	result = append(result, list[i:]...)
+	return append(result, other[j:]...)
+}
+
+// Intersect returns a new tree with the entries of this tree that are
+// at a point where the other tree also has an entry.  The entries in
+// the returned tree are always this tree's, the other tree's entries
+// only decide which points are kept.  Neither tree is modified and
+// branches kept whole are shared with this tree.  This panics if the
+// trees' dimensions do not match.
+func (irt *ImmutableRangeTree) Intersect(other *ImmutableRangeTree) *ImmutableRangeTree {
+	if irt.dimensions != other.dimensions {
+		panic(`rangetree: cannot intersect trees with different dimensions`)
+	}
+
+	kept, dropped := uint64(0), uint64(0)
+	tree := irt.newTree()
+	tree.top = irt.intersect(irt.top, other.top, 1, &kept, &dropped)
+	tree.number = kept
+	return tree
+}
+
+// intersect returns the nodes in list that are also in other, counting
+// the entries kept in kept and the nodes of list left out in dropped.
+// A branch is only shared if nothing below it was left out.
+func (irt *ImmutableRangeTree) intersect(list, other orderedNodes,
+	dimension uint64, kept, dropped *uint64) orderedNodes {
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	c := irt.comparator(dimension)
+	var result orderedNodes
+	i, j := 0, 0
+	for i < len(list) && j < len(other) {
+		switch {
+		case c.compare(list[i].value, other[j].value) < 0:
+			*dropped++
+			i++
+		case c.compare(list[i].value, other[j].value) > 0:
+			j++
+		default:
+			if lastDimension {
+				result = append(result, list[i])
+				*kept++
+				i++
+				j++
+				continue
+			}
+
+			before := *dropped
+			nodes := irt.intersect(list[i].orderedNodes, other[j].orderedNodes,
+				dimension+1, kept, dropped,
+			)
+			if *dropped == before {
+				result = append(result, list[i])
+			} else if len(nodes) > 0 {
+				n := newNode(list[i].value, nil, true)
+				n.orderedNodes = nodes
+				result = append(result, n)
+			}
+			i++
+			j++
+		}
+	}
+	*dropped += uint64(len(list) - i)
+
+	return result
+}
+
+// Equal returns a bool indicating if both trees have the same
+// dimensions and entries at the same points for which eq returns true.
+// If eq is nil entries are compared with ==.  The comparison stops at
+// the first difference found and branches shared by both trees are not
+// walked.
+func (irt *ImmutableRangeTree) Equal(other *ImmutableRangeTree, eq func(a, b Entry) bool) bool {
+	if irt.dimensions != other.dimensions || irt.number != other.number {
+		return false
+	}
+	if eq == nil {
+		eq = func(a, b Entry) bool { return a == b }
+	}
+
+	return irt.equal(irt.top, other.top, 1, eq)
+}
+
+func (irt *ImmutableRangeTree) equal(list, other orderedNodes,
+	dimension uint64, eq func(a, b Entry) bool) bool {
+
+	if len(list) != len(other) {
+		return false
+	}
+
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+	for i, n := range list {
+		o := other[i]
+		if n == o {
+			continue
+		}
+		if n.value != o.value {
+			return false
+		}
+
+		if lastDimension {
+			if !eq(n.entry, o.entry) {
+				return false
+			}
+		} else if !irt.equal(n.orderedNodes, o.orderedNodes, dimension+1, eq) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Clone returns a copy of this tree with the same entries.  Only the
+// list of nodes at the first dimension is copied, every node and the
+// lists below them are shared with this tree, as are the entries.  This
+// is safe because neither tree changes a shared node; Add, Delete and
+// the other operations copy each node they change and return a new
+// tree, so changes to either tree are never seen by the other.
+func (irt *ImmutableRangeTree) Clone() *ImmutableRangeTree {
+	tree := irt.newTree()
+	if irt.top != nil {
+		tree.top = make(orderedNodes, len(irt.top))
+		copy(tree.top, irt.top)
+	}
+	tree.number = irt.number
+	return tree
+}
+
+// Compact returns a copy of this tree with every list of nodes sized
+// to exactly what it holds.  Copy-on-write adds and deletes leave
+// behind lists with spare capacity, and shifts can leave branches
+// without any entries below them, so compacting a long-lived tree
+// bounds its memory.  Those empty branches are dropped.  The compacted
+// tree holds the same entries and this tree is left unchanged.
+func (irt *ImmutableRangeTree) Compact() *ImmutableRangeTree {
+	tree := irt.newTree()
+	tree.top = irt.compact(irt.top, 1)
+	tree.number = irt.number
+	return tree
+}
+
+func (irt *ImmutableRangeTree) compact(list orderedNodes, dimension uint64) orderedNodes {
+	if len(list) == 0 {
+		return nil
+	}
+
+	if isLastDimension(irt.dimensions, dimension) {
+		// these nodes have no lists of their own so can be shared
+		nodes := make(orderedNodes, len(list))
+		copy(nodes, list)
+		return nodes
+	}
+
+	nodes := make(orderedNodes, 0, len(list))
+	for _, n := range list {
+		compacted := irt.compact(n.orderedNodes, dimension+1)
+		if len(compacted) == 0 { // an empty branch
+			continue
+		}
+		nodes = append(nodes, &node{
+			value:        n.value,
+			orderedNodes: compacted,
+		})
+	}
+
+	switch len(nodes) {
+	case 0:
+		return nil
+	case len(list):
+		return nodes
+	}
+
+	trimmed := make(orderedNodes, len(nodes))
+	copy(trimmed, nodes)
+	return trimmed
+}
+
+// MinAtDimension returns the smallest value at the provided dimension
+// of any entry in this tree.  False is returned if the tree is empty or
+// the dimension is not one of this tree's dimensions.
+func (irt *ImmutableRangeTree) MinAtDimension(dimension uint64) (int64, bool) {
+	return irt.extremeAtDimension(dimension, false)
+}
+
+// MaxAtDimension returns the largest value at the provided dimension
+// of any entry in this tree.  False is returned if the tree is empty or
+// the dimension is not one of this tree's dimensions.
+func (irt *ImmutableRangeTree) MaxAtDimension(dimension uint64) (int64, bool) {
+	return irt.extremeAtDimension(dimension, true)
+}
+
+func (irt *ImmutableRangeTree) extremeAtDimension(dimension uint64, max bool) (int64, bool) {
+	if dimension < 1 || dimension > irt.dimensions {
+		return 0, false
+	}
+
+	return irt.extreme(irt.top, dimension, 1, max)
+}
+
+// extreme returns the smallest or largest value found at the target
+// dimension below list.  Every branch has to be checked before the
+// target dimension as each is ordered independently, and branches left
+// without entries are skipped at it.
+func (irt *ImmutableRangeTree) extreme(list orderedNodes, target, dimension uint64, max bool) (int64, bool) {
+	if len(list) == 0 {
+		return 0, false
+	}
+
+	if dimension == target {
+		lastDimension := isLastDimension(irt.dimensions, dimension)
+		for i := range list {
+			n := list[i]
+			if max {
+				n = list[len(list)-1-i]
+			}
+			if lastDimension || hasEntries(n.orderedNodes) {
+				return n.value, true
+			}
+		}
+		return 0, false
+	}
+
+	c := irt.comparator(target)
+	var result int64
+	found := false
+	for _, n := range list {
+		value, ok := irt.extreme(n.orderedNodes, target, dimension+1, max)
+		if !ok {
+			continue
+		}
+		if !found || (max && c.compare(value, result) > 0) || (!max && c.compare(value, result) < 0) {
+			result, found = value, true
+		}
+	}
+
+	return result, found
+}
+
+// hasEntries returns a bool indicating if any entry exists below list.
+func hasEntries(list orderedNodes) bool {
+	for _, n := range list {
+		if n.entry != nil || hasEntries(n.orderedNodes) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// edgeEntry returns the first entry below list, or the last if last is
+// true.  Nil is returned if there are none.
+func edgeEntry(list orderedNodes, last bool) Entry {
+	for i := range list {
+		n := list[i]
+		if last {
+			n = list[len(list)-1-i]
+		}
+		if n.entry != nil {
+			return n.entry
+		}
+		if entry := edgeEntry(n.orderedNodes, last); entry != nil {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// FloorAtDimension returns the entry with the greatest value at the
+// provided dimension that is not greater than the provided value.  Of
+// several entries sharing that value, the last in the tree's order is
+// returned.  False is returned if there is no such entry or the
+// dimension is not one of this tree's dimensions.
+func (irt *ImmutableRangeTree) FloorAtDimension(dimension uint64, value int64) (Entry, bool) {
+	return irt.nearestAtDimension(dimension, value, true)
+}
+
+// CeilAtDimension returns the entry with the smallest value at the
+// provided dimension that is not less than the provided value.  Of
+// several entries sharing that value, the first in the tree's order is
+// returned.  False is returned if there is no such entry or the
+// dimension is not one of this tree's dimensions.
+func (irt *ImmutableRangeTree) CeilAtDimension(dimension uint64, value int64) (Entry, bool) {
+	return irt.nearestAtDimension(dimension, value, false)
+}
+
+func (irt *ImmutableRangeTree) nearestAtDimension(dimension uint64, value int64, floor bool) (Entry, bool) {
+	if dimension < 1 || dimension > irt.dimensions {
+		return nil, false
+	}
+
+	entry := irt.nearest(irt.top, dimension, 1, value, floor)
+	return entry, entry != nil
+}
+
+// nearest returns the floor or ceiling entry at the target dimension
+// below list.  Like extreme, every branch has to be checked before the
+// target dimension, at which the nodes are searched for the value.
+func (irt *ImmutableRangeTree) nearest(list orderedNodes, target, dimension uint64,
+	value int64, floor bool) Entry {
+
+	if len(list) == 0 {
+		return nil
+	}
+
+	c := irt.comparator(target)
+	if dimension == target {
+		i := list.searchBy(value, c)
+		if floor {
+			if i == len(list) || list[i].value != value {
+				i--
+			}
+			for ; i >= 0; i-- {
+				if entry := edgeEntry(list[i:i+1], true); entry != nil {
+					return entry
+				}
+			}
+			return nil
+		}
+
+		for ; i < len(list); i++ {
+			if entry := edgeEntry(list[i:i+1], false); entry != nil {
+				return entry
+			}
+		}
+		return nil
+	}
+
+	var result Entry
+	for _, n := range list {
+		entry := irt.nearest(n.orderedNodes, target, dimension+1, value, floor)
+		if entry == nil {
+			continue
+		}
+		if result == nil {
+			result = entry
+			continue
+		}
+
+		cmp := c.compare(entry.ValueAtDimension(target), result.ValueAtDimension(target))
+		// later branches follow earlier ones in the tree's order
+		if (floor && cmp >= 0) || (!floor && cmp < 0) {
+			result = entry
+		}
+	}
+
+	return result
+}
+
 // Len returns the number of items in this tree.
-func (irt *immutableRangeTree) Len() uint64 {
+func (irt *ImmutableRangeTree) Len() uint64 {
 	return irt.number
 }
 
-func newImmutableRangeTree(dimensions uint64) *immutableRangeTree {
-	return &immutableRangeTree{
+// LevelStats describes the nodes at a single dimension of a tree.
+// Every node of the previous dimension, or the tree itself at the first
+// dimension, holds one list of nodes at this dimension.
+type LevelStats struct {
+	// Nodes is the number of nodes at this dimension.
+	Nodes uint64
+	// Lists is the number of lists holding those nodes, including
+	// any left empty.
+	Lists uint64
+	// MaxFanOut is the length of the longest list.
+	MaxFanOut uint64
+	// AverageFanOut is the mean length of the lists.
+	AverageFanOut float64
+}
+
+// TreeStats describes the structure of a tree.
+type TreeStats struct {
+	// Levels holds one entry for each dimension, the first dimension
+	// being at index 0.
+	Levels []LevelStats
+	// Leaves is the number of nodes at the last dimension.
+	Leaves uint64
+}
+
+// Stats walks the tree once and returns statistics about its
+// structure.  This is O(n) in the number of nodes and is intended for
+// tuning rather than regular use.
+func (irt *ImmutableRangeTree) Stats() TreeStats {
+	stats := TreeStats{Levels: make([]LevelStats, irt.dimensions)}
+	if irt.dimensions == 0 {
+		return stats
+	}
+
+	irt.stats(irt.top, 1, stats.Levels)
+	for i := range stats.Levels {
+		level := &stats.Levels[i]
+		if level.Lists > 0 {
+			level.AverageFanOut = float64(level.Nodes) / float64(level.Lists)
+		}
+	}
+
+	stats.Leaves = stats.Levels[irt.dimensions-1].Nodes
+	return stats
+}
+
+func (irt *ImmutableRangeTree) stats(list orderedNodes, dimension uint64, levels []LevelStats) {
+	level := &levels[dimension-1]
+	level.Lists++
+	level.Nodes += uint64(len(list))
+	if uint64(len(list)) > level.MaxFanOut {
+		level.MaxFanOut = uint64(len(list))
+	}
+
+	if isLastDimension(irt.dimensions, dimension) {
+		return
+	}
+
+	for _, n := range list {
+		irt.stats(n.orderedNodes, dimension+1, levels)
+	}
+}
+
+// NewImmutableRangeTree returns an empty immutable range tree with the
+// provided number of dimensions.
+func NewImmutableRangeTree(dimensions uint64) *ImmutableRangeTree {
+	return &ImmutableRangeTree{
 		dimensions: dimensions,
 	}
 }
+
+// NewImmutableRangeTreeWithComparators returns an empty tree with one
+// dimension for each of the provided comparators, which order the values
+// at that dimension in place of ascending order.  A nil comparator keeps
+// ascending order.  Each must return a negative number if a comes before
+// b, a positive number if a comes after b and zero only if a == b.
+//
+// Every order and interval of the tree's methods is then in terms of
+// the comparators, so an interval at a dimension holds the values from
+// its low through its high in the comparator's order, Query returns
+// entries in that order and the minimum at a dimension is the first
+// value in it.  InsertAtDimension and the other shifts move the values
+// in the comparator's order after the index and require that adding
+// the same number to two values never changes their order, which holds
+// for descending order.  Trees combined by Merge, Difference,
+// Intersect, Join or Equal must have the same comparators.
+func NewImmutableRangeTreeWithComparators(cmps []func(a, b int64) int) *ImmutableRangeTree {
+	tree := NewImmutableRangeTree(uint64(len(cmps)))
+	tree.comparators = make([]comparator, len(cmps))
+	for i, cmp := range cmps {
+		tree.comparators[i] = cmp
+	}
+
+	return tree
+}
+
+// firstDifference returns the first dimension at which the two entries
+// differ and whether a comes after b at that dimension.  A dimension
+// past the last one is returned if they are at the same point.
+func firstDifference(a, b Entry, dimensions uint64) (uint64, bool) {
+	for d := uint64(1); d <= dimensions; d++ {
+		av, bv := a.ValueAtDimension(d), b.ValueAtDimension(d)
+		if av != bv {
+			return d, av > bv
+		}
+	}
+
+	return dimensions + 1, false
+}
+
+// NewImmutableRangeTreeFromSorted returns a tree holding the provided
+// entries, which must be sorted in the order returned by Query:
+// ascending by the value at the first dimension, then the second, and
+// so on.  Entries at the same point must be adjacent and, as with Add,
+// the last of them is kept.  The lists of nodes are built directly with
+// a single allocation of nodes and pointers per dimension, which is much
+// cheaper than Add.  The order is checked before anything is built and
+// if the entries turn out not to be sorted the tree is built with Add
+// instead, so the result is correct either way.
+func NewImmutableRangeTreeFromSorted(dimensions uint64, entries Entries) *ImmutableRangeTree {
+	tree := NewImmutableRangeTree(dimensions)
+	if len(entries) == 0 {
+		return tree
+	}
+
+	// levels[i] is the first dimension at which entries[i] needs a new
+	// node, counts the number of nodes needed at each dimension
+	levels := make([]uint64, len(entries))
+	counts := make([]int, dimensions+1)
+	levels[0] = 1
+	for i := range entries {
+		if i > 0 {
+			d, after := firstDifference(entries[i-1], entries[i], dimensions)
+			if after {
+				return tree.Add(entries...)
+			}
+			levels[i] = d
+		}
+		for d := levels[i]; d <= dimensions; d++ {
+			counts[d]++
+		}
+	}
+
+	allocated := make([][]node, dimensions+1)
+	lists := make([]orderedNodes, dimensions+1)
+	for d := uint64(1); d <= dimensions; d++ {
+		allocated[d] = make([]node, counts[d])
+		lists[d] = make(orderedNodes, counts[d])
+	}
+
+	// starts[d] is where the list of the node last created at dimension
+	// d begins in the list of the next dimension
+	starts := make([]int, dimensions+1)
+	positions := make([]int, dimensions+1)
+	for i, entry := range entries {
+		if levels[i] > dimensions {
+			allocated[dimensions][positions[dimensions]-1].entry = entry
+			continue
+		}
+
+		for d := levels[i]; d <= dimensions; d++ {
+			n := &allocated[d][positions[d]]
+			n.value = entry.ValueAtDimension(d)
+			if d == dimensions {
+				n.entry = entry
+			} else {
+				starts[d] = positions[d+1]
+			}
+			lists[d][positions[d]] = n
+			positions[d]++
+
+			if d > 1 {
+				// the list is capped so appending to it can never
+				// write over the next node's list
+				parent := &allocated[d-1][positions[d-1]-1]
+				parent.orderedNodes = lists[d][starts[d-1]:positions[d]:positions[d]]
+			}
+		}
+	}
+
+	tree.top = lists[1]
+	tree.number = uint64(counts[dimensions])
+	return tree
+}
@@ -17,13 +17,14 @@ limitations under the License.
 package rangetree
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestImmutableSingleDimensionAdd(t *testing.T) {
-	tree := newImmutableRangeTree(1)
+	tree := NewImmutableRangeTree(1)
 	entry := constructMockEntry(0, int64(0), int64(0))
 	tree2 := tree.Add(entry)
 
@@ -39,7 +40,7 @@ func TestImmutableSingleDimensionAdd(t *testing.T) {
 }
 
 func TestImmutableSingleDimensionMultipleAdds(t *testing.T) {
-	tree := newImmutableRangeTree(1)
+	tree := NewImmutableRangeTree(1)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -64,7 +65,7 @@ func TestImmutableSingleDimensionMultipleAdds(t *testing.T) {
 }
 
 func TestImmutableSingleDimensionBulkAdd(t *testing.T) {
-	tree := newImmutableRangeTree(1)
+	tree := NewImmutableRangeTree(1)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -79,7 +80,7 @@ func TestImmutableSingleDimensionBulkAdd(t *testing.T) {
 }
 
 func TestImmutableMultiDimensionAdd(t *testing.T) {
-	tree := newImmutableRangeTree(2)
+	tree := NewImmutableRangeTree(2)
 	entry := constructMockEntry(0, int64(0), int64(0))
 	tree2 := tree.Add(entry)
 
@@ -95,7 +96,7 @@ func TestImmutableMultiDimensionAdd(t *testing.T) {
 }
 
 func TestImmutableMultiDimensionMultipleAdds(t *testing.T) {
-	tree := newImmutableRangeTree(2)
+	tree := NewImmutableRangeTree(2)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -119,8 +120,34 @@ func TestImmutableMultiDimensionMultipleAdds(t *testing.T) {
 	assert.Equal(t, uint64(3), tree3.Len())
 }
 
+func TestImmutableMultiDimensionAddSharedNode(t *testing.T) {
+	e1 := constructMockEntry(0, int64(1), int64(1))
+	e2 := constructMockEntry(1, int64(1), int64(2))
+	e3 := constructMockEntry(2, int64(1), int64(1))
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	tree := NewImmutableRangeTree(2).Add(e1)
+	tree1 := tree.Add(e2, e3)
+
+	assert.Equal(t, Entries{e1}, tree.Query(iv))
+	assert.Equal(t, Entries{e3, e2}, tree1.Query(iv))
+	assert.Equal(t, uint64(2), tree1.Len())
+}
+
+func TestImmutableAddSharedNodeThreeDimensions(t *testing.T) {
+	e1 := constructMockEntry(0, 1, 5, 1)
+	e2 := constructMockEntry(1, 2, 5, 1)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10}, dimension{0, 10})
+
+	tree := NewImmutableRangeTree(3).Add(e1, e2)
+	tree1 := tree.Add(constructMockEntry(2, 1, 5, 2), constructMockEntry(3, 2, 5, 2))
+
+	assert.Equal(t, Entries{e1, e2}, tree.Query(iv))
+	assert.Len(t, tree1.Query(iv), 4)
+}
+
 func TestImmutableMultiDimensionBulkAdd(t *testing.T) {
-	tree := newImmutableRangeTree(2)
+	tree := NewImmutableRangeTree(2)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -146,7 +173,7 @@ func BenchmarkImmutableMultiDimensionInserts(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		tree := newImmutableRangeTree(2)
+		tree := NewImmutableRangeTree(2)
 		for _, e := range entries {
 			tree = tree.Add(e)
 		}
@@ -165,7 +192,7 @@ func BenchmarkImmutableMultiDimensionBulkInsert(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		tree := newImmutableRangeTree(2)
+		tree := NewImmutableRangeTree(2)
 		tree.Add(entries...)
 	}
 }
@@ -188,7 +215,7 @@ func BenchmarkMultiDimensionBulkInsert(b *testing.B) {
 }
 
 func TestImmutableSingleDimensionDelete(t *testing.T) {
-	tree := newImmutableRangeTree(1)
+	tree := NewImmutableRangeTree(1)
 	entry := constructMockEntry(0, int64(0), int64(0))
 	tree2 := tree.Add(entry)
 	tree3 := tree2.Delete(entry)
@@ -200,7 +227,7 @@ func TestImmutableSingleDimensionDelete(t *testing.T) {
 }
 
 func TestImmutableSingleDimensionMultipleDeletes(t *testing.T) {
-	tree := newImmutableRangeTree(1)
+	tree := NewImmutableRangeTree(1)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -235,7 +262,7 @@ func TestImmutableSingleDimensionMultipleDeletes(t *testing.T) {
 }
 
 func TestImmutableSingleDimensionBulkDeletes(t *testing.T) {
-	tree := newImmutableRangeTree(1)
+	tree := NewImmutableRangeTree(1)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -257,7 +284,7 @@ func TestImmutableSingleDimensionBulkDeletes(t *testing.T) {
 }
 
 func TestImmutableMultiDimensionDelete(t *testing.T) {
-	tree := newImmutableRangeTree(2)
+	tree := NewImmutableRangeTree(2)
 	entry := constructMockEntry(0, int64(0), int64(0))
 	tree2 := tree.Add(entry)
 	tree3 := tree2.Delete(entry)
@@ -270,7 +297,7 @@ func TestImmutableMultiDimensionDelete(t *testing.T) {
 }
 
 func TestImmutableMultiDimensionMultipleDeletes(t *testing.T) {
-	tree := newImmutableRangeTree(2)
+	tree := NewImmutableRangeTree(2)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -304,8 +331,46 @@ func TestImmutableMultiDimensionMultipleDeletes(t *testing.T) {
 	assert.Equal(t, tree3, tree7)
 }
 
+func TestImmutableMultiDimensionDeleteSibling(t *testing.T) {
+	e1 := constructMockEntry(0, 1, 1)
+	e2 := constructMockEntry(1, 1, 2)
+	tree := NewImmutableRangeTree(2).Add(e1, e2)
+
+	tree1 := tree.Delete(e2)
+	assert.Equal(t, uint64(1), tree1.Len())
+	assert.Equal(t, Entries{e1}, tree1.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})))
+	assert.Equal(t, Entries{e1, e2}, tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})))
+
+	// adding to the same list again must not find a stale node
+	tree2 := tree1.Add(constructMockEntry(2, 1, 3))
+	assert.Equal(t, uint64(2), tree2.Len())
+}
+
+func TestImmutableThreeDimensionDelete(t *testing.T) {
+	e1 := constructMockEntry(0, 1, 1, 1)
+	e2 := constructMockEntry(1, 1, 1, 2)
+	e3 := constructMockEntry(2, 1, 2, 1)
+	tree := NewImmutableRangeTree(3).Add(e1, e2)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10}, dimension{0, 10})
+
+	// the middle list holds a single node that must be kept
+	tree1 := tree.Delete(e1)
+	assert.Equal(t, uint64(1), tree1.Len())
+	assert.Equal(t, Entries{e2}, tree1.Query(iv))
+	assert.Equal(t, Entries{e1, e2}, tree.Query(iv))
+
+	tree2 := tree1.Delete(e2)
+	assert.Equal(t, uint64(0), tree2.Len())
+	assert.Len(t, tree2.Query(iv), 0)
+	assert.Len(t, tree2.top, 0)
+
+	tree3 := tree.Add(e3).Delete(e1, e3)
+	assert.Equal(t, uint64(1), tree3.Len())
+	assert.Equal(t, Entries{e2}, tree3.Query(iv))
+}
+
 func TestImmutableMultiDimensionBulkDeletes(t *testing.T) {
-	tree := newImmutableRangeTree(2)
+	tree := NewImmutableRangeTree(2)
 	e1 := constructMockEntry(0, int64(0), int64(0))
 	e2 := constructMockEntry(0, int64(1), int64(1))
 	e3 := constructMockEntry(0, int64(2), int64(2))
@@ -326,8 +391,8 @@ func TestImmutableMultiDimensionBulkDeletes(t *testing.T) {
 	assert.Equal(t, uint64(0), tree3.Len())
 }
 
-func constructMultiDimensionalImmutableTree(number int64) (*immutableRangeTree, Entries) {
-	tree := newImmutableRangeTree(2)
+func constructMultiDimensionalImmutableTree(number int64) (*ImmutableRangeTree, Entries) {
+	tree := NewImmutableRangeTree(2)
 	entries := make(Entries, 0, number)
 	for i := int64(0); i < number; i++ {
 		entries = append(entries, constructMockEntry(uint64(i), i, i))
@@ -496,6 +561,73 @@ func TestImmutableInsertMultipleNegativeIndexSecondDimension(t *testing.T) {
 	assert.Equal(t, entries[1:], result)
 }
 
+func TestImmutableInsertAtDimensionFunc(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+
+	deleted := Entries{}
+	tree1, modified := tree.InsertAtDimensionFunc(1, 1, -1, func(entry Entry) {
+		deleted = append(deleted, entry)
+	})
+	assert.Equal(t, entries[1:2], deleted)
+	assert.Equal(t, entries[2:], modified)
+	assert.Equal(t, uint64(2), tree1.Len())
+
+	called := false
+	tree2, modified := tree.InsertAtDimensionFunc(1, 0, 0, func(Entry) {
+		called = true
+	})
+	assert.False(t, called)
+	assert.Len(t, modified, 0)
+	assert.Equal(t, tree, tree2)
+}
+
+func TestImmutableInsertAtDimensionRange(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(10)
+
+	tree1, modified, deleted := tree.InsertAtDimensionRange(2, 3, 6, 2)
+	assert.Equal(t, entries[3:5], modified)
+	assert.Equal(t, entries[5:7], deleted)
+	assert.Equal(t, uint64(8), tree1.Len())
+
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+	result := tree1.Query(iv)
+	assert.Equal(t, Entries{
+		entries[0], entries[1], entries[2], entries[3], entries[4],
+		entries[7], entries[8], entries[9],
+	}, result)
+	assert.Equal(t, Entries{entries[3]}, tree1.Query(
+		constructMockInterval(dimension{3, 3}, dimension{5, 5}),
+	))
+	assert.Len(t, tree1.Query(constructMockInterval(dimension{5, 6}, dimension{0, 10})), 0)
+	assert.Equal(t, entries, tree.Query(iv))
+	// branches without an entry in the band are shared
+	for i, j := range []int{0, 1, 2, -1, -1, 7, 8, 9} {
+		if j >= 0 {
+			assert.True(t, tree1.top[i] == tree.top[j])
+		}
+	}
+
+	same, modified, deleted := tree.InsertAtDimensionRange(2, 20, 30, 1)
+	assert.True(t, same == tree)
+	assert.Len(t, modified, 0)
+	assert.Len(t, deleted, 0)
+
+	tree2, modified, deleted := tree.InsertAtDimensionRange(1, 3, 6, -1)
+	assert.Equal(t, entries[4:7], modified)
+	assert.Equal(t, entries[3:4], deleted)
+	assert.Equal(t, Entries{entries[4]}, tree2.Query(
+		constructMockInterval(dimension{3, 3}, dimension{0, 10}),
+	))
+	assert.Equal(t, Entries{entries[7]}, tree2.Query(
+		constructMockInterval(dimension{6, 7}, dimension{0, 10}),
+	))
+
+	tree3, modified, deleted := tree.InsertAtDimensionRange(1, 6, 3, 1)
+	assert.Len(t, modified, 0)
+	assert.Len(t, deleted, 0)
+	assert.Equal(t, tree, tree3)
+}
+
 func TestImmutableInsertInvalidDimension(t *testing.T) {
 	tree, _ := constructMultiDimensionalImmutableTree(3)
 
@@ -514,6 +646,352 @@ func TestImmutableInsertInvalidNumber(t *testing.T) {
 	assert.Equal(t, tree, tree1)
 }
 
+func TestImmutableInsertMultipleDeletes(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(6)
+
+	tree1, modified, deleted := tree.InsertAtDimension(1, 1, -3)
+	assert.Equal(t, entries[1:4], deleted)
+	assert.Equal(t, entries[4:], modified)
+	assert.Equal(t, uint64(3), tree1.Len())
+
+	result := tree1.Query(constructMockInterval(dimension{1, 10}, dimension{0, 10}))
+	assert.Equal(t, entries[4:], result)
+}
+
+func TestImmutableInsertBatch(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(10)
+	ops := []ShiftOp{{Index: 8, Number: -2}, {Index: 2, Number: 3}, {Index: 5, Number: 1}}
+
+	for _, d := range []uint64{1, 2} {
+		tree1, _, _ := tree.InsertAtDimensionBatch(d, ops)
+
+		expected := tree
+		for _, op := range []ShiftOp{ops[1], ops[2], ops[0]} {
+			expected, _, _ = expected.InsertAtDimension(d, op.Index, op.Number)
+		}
+
+		for i := int64(0); i < 15; i++ {
+			iv := constructMockInterval(dimension{i, i}, dimension{0, 15})
+			if d == 2 {
+				iv = constructMockInterval(dimension{0, 15}, dimension{i, i})
+			}
+			assert.Equal(t, expected.Query(iv), tree1.Query(iv))
+		}
+		assert.Equal(t, expected.Len(), tree1.Len())
+	}
+	assert.Equal(t, uint64(10), tree.Len())
+}
+
+func TestImmutableInsertBatchExclusive(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(10)
+
+	// 2, 3 and 4 are moved to 4, 5 and 6 and then deleted
+	tree1, modified, deleted := tree.InsertAtDimensionBatch(1,
+		[]ShiftOp{{Index: 4, Number: -3}, {Index: 1, Number: 2}},
+	)
+	assert.Equal(t, entries[2:5], deleted)
+	assert.Equal(t, append(entries[1:2:2], entries[5:]...), modified)
+	assert.Equal(t, uint64(7), tree1.Len())
+
+	result := tree1.Query(constructMockInterval(dimension{3, 3}, dimension{0, 10}))
+	assert.Equal(t, Entries{entries[1]}, result)
+	result = tree1.Query(constructMockInterval(dimension{4, 9}, dimension{0, 10}))
+	assert.Equal(t, entries[5:], result)
+}
+
+func TestImmutableInsertBatchNetZero(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(10)
+
+	// 5 through 9 are moved up and back down again
+	tree1, modified, deleted := tree.InsertAtDimensionBatch(2,
+		[]ShiftOp{{Index: 5, Number: -1}, {Index: 0, Number: 1}},
+	)
+	assert.Equal(t, Entries{entries[4]}, deleted)
+	assert.Equal(t, entries[:4], modified)
+
+	result := tree1.Query(constructMockInterval(dimension{0, 10}, dimension{5, 10}))
+	assert.Equal(t, entries[5:], result)
+	result = tree1.Query(constructMockInterval(dimension{0, 10}, dimension{1, 4}))
+	assert.Equal(t, entries[:4], result)
+}
+
+func TestImmutableInsertBatchNothing(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(3)
+
+	tree1, modified, deleted := tree.InsertAtDimensionBatch(1, []ShiftOp{{Index: 1, Number: 0}})
+	assert.Len(t, modified, 0)
+	assert.Len(t, deleted, 0)
+	assert.Equal(t, tree, tree1)
+
+	tree1, modified, deleted = tree.InsertAtDimensionBatch(3, []ShiftOp{{Index: 1, Number: 1}})
+	assert.Len(t, modified, 0)
+	assert.Len(t, deleted, 0)
+	assert.Equal(t, tree, tree1)
+}
+
+func TestImmutableTryAdd(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	e1 := constructMockEntry(0, 1, 1)
+	e2 := constructMockEntry(1, 2, 2)
+
+	tree1, err := tree.TryAdd(e1, e2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), tree1.Len())
+	assert.Equal(t, Entries{e1, e2}, tree1.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})))
+
+	tree2, err := tree1.TryAdd(constructMockEntry(2, 3, 3), constructMockEntry(3, 4))
+	iee, ok := err.(InvalidEntryError)
+	assert.True(t, ok)
+	assert.Equal(t, 1, iee.index)
+	assert.Equal(t, uint64(2), iee.dimension)
+	assert.NotNil(t, iee.cause)
+	assert.True(t, tree1 == tree2)
+	assert.Equal(t, uint64(2), tree1.Len())
+	assert.Len(t, tree1.Query(constructMockInterval(dimension{3, 3}, dimension{0, 10})), 0)
+
+	tree2, err = tree1.TryAdd(nil)
+	assert.Equal(t, InvalidEntryError{}, err)
+	assert.True(t, tree1 == tree2)
+}
+
+func TestImmutableMinMaxAtDimension(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	_, ok := tree.MinAtDimension(1)
+	assert.False(t, ok)
+	_, ok = tree.MaxAtDimension(2)
+	assert.False(t, ok)
+
+	tree = tree.Add(
+		constructMockEntry(0, 3, 7),
+		constructMockEntry(1, 5, -2),
+		constructMockEntry(2, 9, 4),
+	)
+
+	min, ok := tree.MinAtDimension(1)
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), min)
+	max, ok := tree.MaxAtDimension(1)
+	assert.True(t, ok)
+	assert.Equal(t, int64(9), max)
+
+	min, ok = tree.MinAtDimension(2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(-2), min)
+	max, ok = tree.MaxAtDimension(2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), max)
+
+	_, ok = tree.MinAtDimension(0)
+	assert.False(t, ok)
+	_, ok = tree.MaxAtDimension(3)
+	assert.False(t, ok)
+}
+
+func TestImmutableMinMaxAtDimensionEmptyBranch(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(3)
+	// leaves the branch at 2 in the first dimension without entries
+	tree, _, _ = tree.InsertAtDimension(2, 2, -1)
+
+	max, ok := tree.MaxAtDimension(2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), max)
+	max, ok = tree.MaxAtDimension(1)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), max)
+}
+
+func TestImmutableFloorCeilAtDimension(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	_, ok := tree.FloorAtDimension(1, 0)
+	assert.False(t, ok)
+	_, ok = tree.CeilAtDimension(2, 0)
+	assert.False(t, ok)
+
+	e1 := constructMockEntry(0, 3, 7)
+	e2 := constructMockEntry(1, 5, -2)
+	e3 := constructMockEntry(2, 5, 4)
+	e4 := constructMockEntry(3, 9, 4)
+	tree = tree.Add(e1, e2, e3, e4)
+
+	entry, ok := tree.FloorAtDimension(1, 4)
+	assert.True(t, ok)
+	assert.Equal(t, e1, entry)
+	entry, ok = tree.FloorAtDimension(1, 5)
+	assert.True(t, ok)
+	assert.Equal(t, e3, entry)
+	entry, ok = tree.CeilAtDimension(1, 4)
+	assert.True(t, ok)
+	assert.Equal(t, e2, entry)
+	entry, ok = tree.CeilAtDimension(1, 9)
+	assert.True(t, ok)
+	assert.Equal(t, e4, entry)
+	_, ok = tree.FloorAtDimension(1, 2)
+	assert.False(t, ok)
+	_, ok = tree.CeilAtDimension(1, 10)
+	assert.False(t, ok)
+
+	entry, ok = tree.FloorAtDimension(2, 6)
+	assert.True(t, ok)
+	assert.Equal(t, e4, entry)
+	entry, ok = tree.CeilAtDimension(2, 0)
+	assert.True(t, ok)
+	assert.Equal(t, e3, entry)
+	entry, ok = tree.CeilAtDimension(2, -5)
+	assert.True(t, ok)
+	assert.Equal(t, e2, entry)
+	_, ok = tree.CeilAtDimension(2, 8)
+	assert.False(t, ok)
+
+	_, ok = tree.FloorAtDimension(0, 5)
+	assert.False(t, ok)
+	_, ok = tree.CeilAtDimension(3, 5)
+	assert.False(t, ok)
+}
+
+func TestImmutableFloorCeilAtDimensionEmptyBranch(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+	// leaves the branch at 2 in the first dimension without entries
+	tree, _, _ = tree.InsertAtDimension(2, 2, -1)
+
+	entry, ok := tree.FloorAtDimension(1, 2)
+	assert.True(t, ok)
+	assert.Equal(t, entries[1], entry)
+	_, ok = tree.CeilAtDimension(1, 2)
+	assert.False(t, ok)
+}
+
+func TestImmutableStats(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	stats := tree.Stats()
+	assert.Equal(t, TreeStats{Levels: []LevelStats{{Lists: 1}, {}}}, stats)
+
+	tree = tree.Add(
+		constructMockEntry(0, 0, 0),
+		constructMockEntry(1, 0, 1),
+		constructMockEntry(2, 0, 2),
+		constructMockEntry(3, 1, 0),
+	)
+	stats = tree.Stats()
+	assert.Equal(t, uint64(4), stats.Leaves)
+	assert.Equal(t, LevelStats{Nodes: 2, Lists: 1, MaxFanOut: 2, AverageFanOut: 2}, stats.Levels[0])
+	assert.Equal(t, LevelStats{Nodes: 4, Lists: 2, MaxFanOut: 3, AverageFanOut: 2}, stats.Levels[1])
+	assert.Equal(t, uint64(4), tree.Len())
+
+	// the branch emptied by the shift is still counted
+	tree, _, _ = tree.InsertAtDimension(2, 0, -1)
+	stats = tree.Stats()
+	assert.Equal(t, uint64(2), stats.Leaves)
+	assert.Equal(t, LevelStats{Nodes: 2, Lists: 2, MaxFanOut: 2, AverageFanOut: 1}, stats.Levels[1])
+}
+
+func TestImmutableQueryAll(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(10)
+
+	result := tree.QueryAll(
+		constructMockInterval(dimension{6, 8}, dimension{0, 10}),
+		constructMockInterval(dimension{1, 3}, dimension{0, 10}),
+		constructMockInterval(dimension{2, 7}, dimension{0, 2}),
+	)
+	assert.Equal(t, append(entries[1:4:4], entries[6:9]...), result)
+
+	// overlapping intervals return each entry once
+	result = tree.QueryAll(
+		constructMockInterval(dimension{0, 5}, dimension{2, 10}),
+		constructMockInterval(dimension{3, 10}, dimension{0, 6}),
+		constructMockInterval(dimension{4, 4}, dimension{4, 4}),
+	)
+	assert.Equal(t, entries[2:7], result)
+
+	assert.Len(t, tree.QueryAll(), 0)
+}
+
+func TestImmutableQueryAllMatchesQuery(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(10)
+	iv := constructMockInterval(dimension{2, 7}, dimension{3, 9})
+
+	assert.Equal(t, tree.Query(iv), tree.QueryAll(iv))
+	assert.Equal(t, tree.Query(iv), tree.QueryAll(iv, iv))
+}
+
+func TestImmutableClone(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+
+	clone := tree.Clone()
+	assert.Equal(t, tree.Len(), clone.Len())
+	assert.True(t, clone.Equal(tree, nil))
+	assert.False(t, &tree.top[0] == &clone.top[0])
+	assert.True(t, tree.top[0] == clone.top[0])
+
+	clone = clone.Add(constructMockEntry(3, 0, 5))
+	clone = clone.Delete(entries[1])
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+	assert.Equal(t, entries, tree.Query(iv))
+	assert.Equal(t, uint64(3), tree.Len())
+	assert.Equal(t, uint64(3), clone.Len())
+
+	empty := NewImmutableRangeTree(2).Clone()
+	assert.Equal(t, uint64(0), empty.Len())
+	assert.Len(t, empty.Query(iv), 0)
+}
+
+func TestImmutableAddIfVersion(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+
+	first := constructMockVersionedEntry(0, 1, 3, 4)
+	tree1, ok := tree.AddIfVersion(first, 0)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), tree1.Len())
+	assert.Equal(t, Entries{first}, tree1.Get(first))
+
+	// someone else already wrote version 1
+	stale := constructMockVersionedEntry(1, 1, 3, 4)
+	tree2, ok := tree1.AddIfVersion(stale, 0)
+	assert.False(t, ok)
+	assert.True(t, tree1 == tree2)
+
+	second := constructMockVersionedEntry(2, 2, 3, 4)
+	tree2, ok = tree1.AddIfVersion(second, 1)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), tree2.Len())
+	assert.Equal(t, Entries{second}, tree2.Get(second))
+	assert.Equal(t, Entries{first}, tree1.Get(first))
+}
+
+func TestImmutableAddIfVersionUnversioned(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(2)
+
+	replacement := constructMockVersionedEntry(5, 1, 1, 1)
+	_, ok := tree.AddIfVersion(replacement, 1)
+	assert.False(t, ok)
+
+	tree1, ok := tree.AddIfVersion(replacement, 0)
+	assert.True(t, ok)
+	assert.Equal(t, Entries{entries[0], replacement}, tree1.Get(entries...))
+}
+
+func TestImmutableQueryParallel(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	for i := int64(0); i < 100; i++ {
+		for j := int64(0); j < i%5; j++ {
+			tree = tree.Add(constructMockEntry(uint64(i*5+j), i, j))
+		}
+	}
+
+	intervals := []*mockInterval{
+		constructMockInterval(dimension{0, 100}, dimension{0, 10}),
+		constructMockInterval(dimension{13, 71}, dimension{1, 2}),
+		constructMockInterval(dimension{-10, 2}, dimension{0, 10}),
+		constructMockInterval(dimension{200, 300}, dimension{0, 10}),
+		constructMockInterval(dimension{math.MinInt64, math.MaxInt64}, dimension{0, 10}),
+	}
+	for _, iv := range intervals {
+		expected := tree.Query(iv)
+		for _, workers := range []int{0, 1, 3, 8, 1000} {
+			assert.Equal(t, expected, tree.QueryParallel(iv, workers))
+		}
+	}
+}
+
 func TestImmutableGet(t *testing.T) {
 	tree, entries := constructMultiDimensionalImmutableTree(2)
 
@@ -547,3 +1025,904 @@ func BenchmarkImmutableInsertSecondDimension(b *testing.B) {
 		tree.InsertAtDimension(2, 0, 1)
 	}
 }
+
+func TestImmutableDifference(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(4)
+	other := NewImmutableRangeTree(2).Add(entries[1], entries[3])
+
+	result := tree.Difference(other)
+	assert.Equal(t, uint64(2), result.Len())
+	assert.Equal(t, Entries{entries[0], entries[2]},
+		result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+
+	// neither tree is modified
+	assert.Equal(t, uint64(4), tree.Len())
+	assert.Equal(t, entries,
+		tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+	assert.Equal(t, uint64(2), other.Len())
+}
+
+func TestImmutableIntersect(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(4)
+	e1 := constructMockEntry(10, int64(1), int64(1))
+	e3 := constructMockEntry(11, int64(3), int64(3))
+	other := NewImmutableRangeTree(2).Add(
+		e1, e3,
+		constructMockEntry(12, int64(2), int64(5)),
+		constructMockEntry(13, int64(7), int64(7)),
+	)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	result := tree.Intersect(other)
+	assert.Equal(t, uint64(2), result.Len())
+	assert.Equal(t, Entries{entries[1], entries[3]}, result.Query(iv))
+
+	result = other.Intersect(tree)
+	assert.Equal(t, uint64(2), result.Len())
+	assert.Equal(t, Entries{e1, e3}, result.Query(iv))
+
+	result = tree.Intersect(tree)
+	assert.Equal(t, uint64(4), result.Len())
+	assert.Equal(t, entries, result.Query(iv))
+
+	result = tree.Intersect(NewImmutableRangeTree(2))
+	assert.Equal(t, uint64(0), result.Len())
+	assert.Len(t, result.Query(iv), 0)
+
+	// neither tree is modified
+	assert.Equal(t, entries, tree.Query(iv))
+	assert.Equal(t, uint64(4), other.Len())
+}
+
+func TestImmutableIntersectThreeDimensions(t *testing.T) {
+	e1 := constructMockEntry(0, 1, 1, 1)
+	e2 := constructMockEntry(1, 1, 1, 2)
+	e3 := constructMockEntry(2, 2, 1, 1)
+	tree := NewImmutableRangeTree(3).Add(e1, e2, e3)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10}, dimension{0, 10})
+
+	// the middle list keeps its length while an entry below is dropped
+	result := tree.Intersect(NewImmutableRangeTree(3).Add(constructMockEntry(5, 1, 1, 1)))
+	assert.Equal(t, uint64(1), result.Len())
+	assert.Equal(t, Entries{e1}, result.Query(iv))
+
+	result = tree.Intersect(NewImmutableRangeTree(3).Add(
+		constructMockEntry(5, 1, 1, 1),
+		constructMockEntry(6, 1, 1, 2),
+		constructMockEntry(7, 2, 1, 3),
+	))
+	assert.Equal(t, uint64(2), result.Len())
+	assert.Equal(t, Entries{e1, e2}, result.Query(iv))
+	// the branch at 1 kept every entry so is shared
+	assert.True(t, result.top[0] == tree.top[0])
+
+	result = tree.Intersect(tree)
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, Entries{e1, e2, e3}, result.Query(iv))
+	assert.Equal(t, uint64(3), tree.Len())
+}
+
+func TestImmutableIntersectDimensionMismatch(t *testing.T) {
+	assert.Panics(t, func() {
+		NewImmutableRangeTree(2).Intersect(NewImmutableRangeTree(1))
+	})
+}
+
+func TestImmutableDifferenceMatchesAllDimensions(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(2)
+	// same first dimension, different second
+	other := NewImmutableRangeTree(2).Add(constructMockEntry(5, int64(0), int64(1)))
+
+	result := tree.Difference(other)
+	assert.Equal(t, uint64(2), result.Len())
+	assert.Equal(t, entries,
+		result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+
+	tree = tree.Add(constructMockEntry(2, int64(0), int64(5)))
+	other = NewImmutableRangeTree(2).Add(constructMockEntry(3, int64(0), int64(5)))
+	result = tree.Difference(other)
+	assert.Equal(t, uint64(2), result.Len())
+	assert.Equal(t, entries,
+		result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableDifferenceDisjoint(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+	other := NewImmutableRangeTree(2).Add(constructMockEntry(5, int64(5), int64(5)))
+
+	result := tree.Difference(other)
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, entries,
+		result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableDifferenceIdentical(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(3)
+
+	result := tree.Difference(tree)
+	assert.Equal(t, uint64(0), result.Len())
+	assert.Len(t, result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})), 0)
+	assert.Equal(t, uint64(3), tree.Len())
+}
+
+func TestImmutableDifferenceEmpty(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+
+	result := tree.Difference(NewImmutableRangeTree(2))
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, entries,
+		result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+
+	result = NewImmutableRangeTree(2).Difference(tree)
+	assert.Equal(t, uint64(0), result.Len())
+}
+
+func TestImmutableDifferenceMismatchedDimensions(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(1)
+
+	assert.Panics(t, func() {
+		tree.Difference(NewImmutableRangeTree(1))
+	})
+}
+
+func TestImmutableJoin(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(4)
+	m1 := constructMockEntry(10, int64(1), int64(1))
+	m3 := constructMockEntry(11, int64(3), int64(3))
+	other := NewImmutableRangeTree(2).Add(
+		m3, m1,
+		constructMockEntry(12, int64(2), int64(5)), // same first dimension only
+		constructMockEntry(13, int64(7), int64(7)),
+	)
+
+	pairs := make([]Entries, 0, 2)
+	tree.Join(other, func(a, b Entry) {
+		pairs = append(pairs, Entries{a, b})
+	})
+	assert.Equal(t, []Entries{{entries[1], m1}, {entries[3], m3}}, pairs)
+
+	pairs = pairs[:0]
+	other.Join(tree, func(a, b Entry) {
+		pairs = append(pairs, Entries{a, b})
+	})
+	assert.Equal(t, []Entries{{m1, entries[1]}, {m3, entries[3]}}, pairs)
+}
+
+func TestImmutableJoinEmpty(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(3)
+	called := false
+	fn := func(a, b Entry) { called = true }
+
+	tree.Join(NewImmutableRangeTree(2), fn)
+	NewImmutableRangeTree(2).Join(tree, fn)
+	assert.False(t, called)
+}
+
+func TestImmutableJoinMismatchedDimensions(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(1)
+
+	assert.Panics(t, func() {
+		tree.Join(NewImmutableRangeTree(1), func(a, b Entry) {})
+	})
+}
+
+func TestImmutableFromSorted(t *testing.T) {
+	entries := Entries{
+		constructMockEntry(0, 0, 0),
+		constructMockEntry(1, 0, 5),
+		constructMockEntry(2, 1, -3),
+		constructMockEntry(3, 1, 2),
+		constructMockEntry(4, 1, 7),
+		constructMockEntry(5, 4, 4),
+	}
+	iv := constructMockInterval(dimension{-10, 10}, dimension{-10, 10})
+
+	tree := NewImmutableRangeTreeFromSorted(2, entries)
+	expected := NewImmutableRangeTree(2).Add(entries...)
+	assert.Equal(t, uint64(6), tree.Len())
+	assert.Equal(t, entries, tree.Query(iv))
+	assert.Equal(t, expected.top, tree.top)
+	assert.Equal(t, entries, tree.Get(entries...))
+
+	// the built tree behaves like any other
+	added := tree.Add(constructMockEntry(6, 1, 3))
+	assert.Equal(t, uint64(7), added.Len())
+	assert.Equal(t, entries, tree.Query(iv))
+	deleted := tree.Delete(entries[3])
+	assert.Equal(t, uint64(5), deleted.Len())
+	assert.Equal(t, entries, tree.Query(iv))
+}
+
+func TestImmutableFromSortedThreeDimensions(t *testing.T) {
+	entries := make(Entries, 0, 27)
+	for i := int64(0); i < 27; i++ {
+		entries = append(entries, constructMockEntry(uint64(i), i/9, (i/3)%3, i%3))
+	}
+
+	tree := NewImmutableRangeTreeFromSorted(3, entries)
+	iv := constructMockInterval(dimension{0, 2}, dimension{0, 2}, dimension{0, 2})
+	assert.Equal(t, uint64(27), tree.Len())
+	assert.Equal(t, entries, tree.Query(iv))
+	assert.Equal(t, NewImmutableRangeTree(3).Add(entries...).top, tree.top)
+}
+
+func TestImmutableFromSortedDuplicates(t *testing.T) {
+	entries := Entries{
+		constructMockEntry(0, 1, 1),
+		constructMockEntry(1, 1, 1),
+		constructMockEntry(2, 2, 2),
+	}
+
+	tree := NewImmutableRangeTreeFromSorted(2, entries)
+	assert.Equal(t, uint64(2), tree.Len())
+	assert.Equal(t, Entries{entries[1], entries[2]},
+		tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableFromSortedUnsorted(t *testing.T) {
+	entries := Entries{
+		constructMockEntry(0, 1, 5),
+		constructMockEntry(1, 1, 2),
+		constructMockEntry(2, 0, 9),
+	}
+
+	tree := NewImmutableRangeTreeFromSorted(2, entries)
+	assert.Equal(t, uint64(3), tree.Len())
+	assert.Equal(t, Entries{entries[2], entries[1], entries[0]},
+		tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableFromSortedSingleDimension(t *testing.T) {
+	entries := Entries{
+		constructMockEntry(0, -1),
+		constructMockEntry(1, 3),
+		constructMockEntry(2, 8),
+	}
+
+	tree := NewImmutableRangeTreeFromSorted(1, entries)
+	assert.Equal(t, uint64(3), tree.Len())
+	assert.Equal(t, entries, tree.Query(constructMockInterval(dimension{-10, 10})))
+
+	empty := NewImmutableRangeTreeFromSorted(1, nil)
+	assert.Equal(t, uint64(0), empty.Len())
+}
+
+func BenchmarkImmutableFromSorted(b *testing.B) {
+	numItems := int64(1000)
+	entries := make(Entries, 0, numItems)
+	for i := int64(0); i < numItems; i++ {
+		entries = append(entries, constructMockEntry(uint64(i), i/10, i%10))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		NewImmutableRangeTreeFromSorted(2, entries)
+	}
+}
+
+func TestImmutableMerge(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(4)
+	overwrite := constructMockEntry(10, int64(1), int64(1))
+	sibling := constructMockEntry(11, int64(1), int64(5))
+	far := constructMockEntry(12, int64(7), int64(7))
+	other := NewImmutableRangeTree(2).Add(far, overwrite, sibling)
+
+	result := tree.Merge(other)
+	assert.Equal(t, uint64(6), result.Len())
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+	assert.Equal(t,
+		Entries{entries[0], overwrite, sibling, entries[2], entries[3], far},
+		result.Query(iv),
+	)
+
+	// neither tree is modified
+	assert.Equal(t, entries, tree.Query(iv))
+	assert.Equal(t, uint64(4), tree.Len())
+	assert.Equal(t, Entries{overwrite, sibling, far}, other.Query(iv))
+	assert.Equal(t, uint64(3), other.Len())
+
+	result = other.Merge(tree)
+	assert.Equal(t, uint64(6), result.Len())
+	assert.Equal(t,
+		Entries{entries[0], entries[1], sibling, entries[2], entries[3], far},
+		result.Query(iv),
+	)
+	assert.Equal(t, Entries{entries[1]}, result.Get(overwrite))
+}
+
+func TestImmutableMergeEmpty(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	result := tree.Merge(NewImmutableRangeTree(2))
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, entries, result.Query(iv))
+
+	result = NewImmutableRangeTree(2).Merge(tree)
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, entries, result.Query(iv))
+
+	result = tree.Merge(tree)
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, entries, result.Query(iv))
+}
+
+func TestImmutableMergeThenModify(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+	other := NewImmutableRangeTree(2).Add(constructMockEntry(5, int64(5), int64(5)))
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	result := tree.Merge(other)
+	result = result.Add(constructMockEntry(6, int64(5), int64(6)))
+	result = result.Delete(entries[0])
+	assert.Equal(t, uint64(4), result.Len())
+
+	assert.Equal(t, entries, tree.Query(iv))
+	assert.Len(t, other.Query(iv), 1)
+}
+
+func TestImmutableMergeMismatchedDimensions(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(1)
+
+	assert.Panics(t, func() {
+		tree.Merge(NewImmutableRangeTree(1))
+	})
+}
+
+func TestImmutableGetAt(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+	tree = tree.Add(constructMockEntry(5, int64(1), int64(4)))
+
+	entry, ok := tree.GetAt(1, 1)
+	assert.True(t, ok)
+	assert.Equal(t, entries[1], entry)
+	assert.True(t, tree.Contains(2, 2))
+	assert.True(t, tree.Contains(1, 4))
+
+	entry, ok = tree.GetAt(1, 2)
+	assert.False(t, ok)
+	assert.Nil(t, entry)
+	assert.False(t, tree.Contains(5, 5))
+	assert.False(t, tree.Contains(-1, 0))
+}
+
+func TestImmutableGetAtWrongDimensions(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(3)
+
+	entry, ok := tree.GetAt(1)
+	assert.False(t, ok)
+	assert.Nil(t, entry)
+	assert.False(t, tree.Contains(1, 1, 1))
+	assert.False(t, tree.Contains())
+	assert.False(t, NewImmutableRangeTree(2).Contains(0, 0))
+}
+
+func TestImmutableQueryLimit(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(10)
+	iv := constructMockInterval(dimension{0, 100}, dimension{0, 100})
+
+	assert.Equal(t, entries[:3], tree.QueryLimit(iv, 0, 3))
+	assert.Equal(t, entries[3:6], tree.QueryLimit(iv, 3, 3))
+	assert.Equal(t, entries[8:], tree.QueryLimit(iv, 8, 3))
+	assert.Len(t, tree.QueryLimit(iv, 10, 3), 0)
+
+	assert.Equal(t, entries, tree.QueryLimit(iv, 0, 0))
+	assert.Equal(t, entries[5:], tree.QueryLimit(iv, 5, 0))
+	assert.Equal(t, entries[:2], tree.QueryLimit(iv, -5, 2))
+
+	assert.Equal(t, entries[5:7],
+		tree.QueryLimit(constructMockInterval(dimension{4, 100}, dimension{0, 100}), 1, 2),
+	)
+}
+
+// countingInterval counts the lists of nodes a query visits.
+type countingInterval struct {
+	*mockInterval
+	calls int
+}
+
+func (ci *countingInterval) LowAtDimension(dimension uint64) int64 {
+	ci.calls++
+	return ci.mockInterval.LowAtDimension(dimension)
+}
+
+func TestImmutableQueryLimitStops(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(100)
+	iv := &countingInterval{
+		mockInterval: constructMockInterval(dimension{0, 100}, dimension{0, 100}),
+	}
+
+	tree.QueryLimit(iv, 2, 3)
+	// the top list and the five lists beneath it that were visited
+	assert.Equal(t, 6, iv.calls)
+}
+
+func TestImmutableDeleteInterval(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(6)
+	sibling := constructMockEntry(10, int64(2), int64(8))
+	tree = tree.Add(sibling)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	result, deleted := tree.DeleteInterval(constructMockInterval(dimension{1, 3}, dimension{0, 5}))
+	assert.Equal(t, Entries{entries[1], entries[2], entries[3]}, deleted)
+	assert.Equal(t, uint64(4), result.Len())
+	assert.Equal(t, Entries{entries[0], sibling, entries[4], entries[5]}, result.Query(iv))
+	// the emptied branches are pruned
+	assert.Len(t, result.top, 4)
+
+	// this tree is not modified
+	assert.Equal(t, uint64(7), tree.Len())
+	assert.Len(t, tree.Query(iv), 7)
+
+	result, deleted = result.DeleteInterval(iv)
+	assert.Len(t, deleted, 4)
+	assert.Equal(t, uint64(0), result.Len())
+	assert.Len(t, result.top, 0)
+}
+
+func TestImmutableDeleteIntervalNothing(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(3)
+
+	result, deleted := tree.DeleteInterval(constructMockInterval(dimension{1, 1}, dimension{5, 10}))
+	assert.Len(t, deleted, 0)
+	assert.True(t, result == tree)
+	assert.Equal(t, uint64(3), result.Len())
+}
+
+func TestImmutableDeleteIntervalThenAdd(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(4)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	result, _ := tree.DeleteInterval(constructMockInterval(dimension{0, 1}, dimension{0, 10}))
+	result = result.Add(constructMockEntry(5, int64(2), int64(5)))
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, entries, tree.Query(iv))
+}
+
+func TestImmutableQueryReverse(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	for i := int64(0); i < 4; i++ {
+		for j := int64(0); j < 3; j++ {
+			tree = tree.Add(constructMockEntry(uint64(i*3+j), i, j))
+		}
+	}
+
+	ivs := []*mockInterval{
+		constructMockInterval(dimension{0, 10}, dimension{0, 10}),
+		constructMockInterval(dimension{1, 2}, dimension{1, 1}),
+		constructMockInterval(dimension{5, 10}, dimension{0, 10}),
+	}
+	for _, iv := range ivs {
+		expected := tree.Query(iv)
+		for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+			expected[i], expected[j] = expected[j], expected[i]
+		}
+		assert.Equal(t, expected, tree.QueryReverse(iv))
+	}
+
+	var result Entries
+	tree.ApplyReverse(ivs[0], func(entry Entry) bool {
+		result = append(result, entry)
+		return len(result) < 2
+	})
+	assert.Equal(t, Entries{
+		tree.Query(ivs[0])[11], tree.Query(ivs[0])[10],
+	}, result)
+}
+
+func TestImmutableApply(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(5)
+	tree = tree.Add(constructMockEntry(5, int64(2), int64(4)))
+	iv := constructMockInterval(dimension{1, 10}, dimension{0, 10})
+
+	result := Entries{}
+	tree.Apply(iv, func(e Entry) bool {
+		result = append(result, e)
+		return true
+	})
+	assert.Equal(t, tree.Query(iv), result)
+	assert.Len(t, result, 5)
+
+	calls := 0
+	tree.Apply(iv, func(e Entry) bool {
+		calls++
+		assert.Equal(t, entries[1], e)
+		return false
+	})
+	assert.Equal(t, 1, calls)
+
+	result = result[:0]
+	tree.Apply(iv, func(e Entry) bool {
+		result = append(result, e)
+		return len(result) < 3
+	})
+	assert.Equal(t, Entries{entries[1], entries[2], tree.Get(constructMockEntry(0, 2, 4))[0]}, result)
+}
+
+func TestImmutableEqual(t *testing.T) {
+	incremental, entries := constructMultiDimensionalImmutableTree(0)
+	for i := int64(0); i < 5; i++ {
+		entry := constructMockEntry(uint64(i), i%2, i)
+		entries = append(entries, entry)
+		incremental = incremental.Add(entry)
+	}
+	bulk := NewImmutableRangeTree(2).Add(entries...)
+
+	assert.True(t, incremental.Equal(bulk, nil))
+	assert.True(t, bulk.Equal(incremental, nil))
+	assert.True(t, bulk.Equal(bulk, nil))
+
+	// same point, different entry
+	replaced := bulk.Add(constructMockEntry(10, 0, 0))
+	assert.False(t, replaced.Equal(bulk, nil))
+	assert.True(t, replaced.Equal(bulk, func(a, b Entry) bool { return true }))
+
+	// same number of entries at different points
+	moved := bulk.Delete(entries[0]).Add(constructMockEntry(0, 0, 1))
+	assert.False(t, moved.Equal(bulk, nil))
+
+	assert.False(t, bulk.Delete(entries[4]).Equal(bulk, nil))
+}
+
+func TestImmutableEqualEmpty(t *testing.T) {
+	assert.True(t, NewImmutableRangeTree(2).Equal(NewImmutableRangeTree(2), nil))
+	assert.False(t, NewImmutableRangeTree(2).Equal(NewImmutableRangeTree(3), nil))
+
+	tree, _ := constructMultiDimensionalImmutableTree(1)
+	empty := tree.Delete(tree.Query(constructMockInterval(dimension{0, 0}, dimension{0, 0}))...)
+	assert.True(t, empty.Equal(NewImmutableRangeTree(2), nil))
+}
+
+func bumpID(entry Entry) Entry {
+	me := entry.(*mockEntry)
+	return constructMockEntry(me.id+100, me.dimensions...)
+}
+
+func TestImmutableMutate(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(4)
+
+	result, modified := tree.Mutate(
+		constructMockInterval(dimension{1, 2}, dimension{0, 10}), bumpID,
+	)
+	assert.Equal(t, uint64(2), modified)
+	assert.Equal(t, uint64(4), result.Len())
+	assert.Equal(t, Entries{
+		entries[0], constructMockEntry(101, 1, 1),
+		constructMockEntry(102, 2, 2), entries[3],
+	}, result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})))
+
+	// the original tree is unchanged
+	assert.Equal(t, entries,
+		tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableMutateSecondDimension(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(4)
+
+	result, modified := tree.Mutate(
+		constructMockInterval(dimension{0, 10}, dimension{3, 10}), bumpID,
+	)
+	assert.Equal(t, uint64(1), modified)
+	assert.Equal(t, Entries{constructMockEntry(103, 3, 3)},
+		result.Query(constructMockInterval(dimension{3, 3}, dimension{3, 3})),
+	)
+	assert.Equal(t, entries[:3],
+		result.Query(constructMockInterval(dimension{0, 2}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableMutateUnchanged(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+
+	result, modified := tree.Mutate(
+		constructMockInterval(dimension{0, 10}, dimension{0, 10}),
+		func(entry Entry) Entry { return entry },
+	)
+	assert.Equal(t, uint64(0), modified)
+	assert.Equal(t, entries,
+		result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+
+	result, modified = tree.Mutate(
+		constructMockInterval(dimension{5, 10}, dimension{0, 10}), bumpID,
+	)
+	assert.Equal(t, uint64(0), modified)
+	assert.Equal(t, uint64(3), result.Len())
+}
+
+func TestImmutableMutateChangesDimension(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(2)
+
+	assert.Panics(t, func() {
+		tree.Mutate(constructMockInterval(dimension{0, 10}, dimension{0, 10}),
+			func(entry Entry) Entry {
+				return constructMockEntry(entry.(*mockEntry).id, 5, 5)
+			},
+		)
+	})
+	assert.Panics(t, func() {
+		tree.Mutate(constructMockInterval(dimension{0, 10}, dimension{0, 10}),
+			func(entry Entry) Entry { return nil },
+		)
+	})
+}
+
+// capacity returns the total capacity of every list of nodes in the
+// tree and the total number of nodes held in those lists.
+func capacity(nodes orderedNodes) (int, int) {
+	c, l := cap(nodes), len(nodes)
+	for _, n := range nodes {
+		nc, nl := capacity(n.orderedNodes)
+		c += nc
+		l += nl
+	}
+
+	return c, l
+}
+
+func constructFragmentedImmutableTree(number int64) (*ImmutableRangeTree, Entries) {
+	tree := NewImmutableRangeTree(2)
+	entries := make(Entries, 0, number)
+	for i := int64(0); i < number; i++ {
+		entries = append(entries, constructMockEntry(uint64(i), i%10, i))
+		tree = tree.Add(entries[i])
+		if i%3 == 0 {
+			deleted := constructMockEntry(uint64(number+i), i%10, number+i)
+			tree = tree.Add(deleted).Delete(deleted)
+		}
+	}
+
+	return tree, entries
+}
+
+func TestImmutableCompact(t *testing.T) {
+	tree, entries := constructFragmentedImmutableTree(100)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 100})
+
+	compacted := tree.Compact()
+	assert.Equal(t, tree.Len(), compacted.Len())
+	assert.Equal(t, tree.Query(iv), compacted.Query(iv))
+	assert.Equal(t, entries, compacted.Get(entries...))
+
+	before, l := capacity(tree.top)
+	after, compactedL := capacity(compacted.top)
+	assert.Equal(t, l, compactedL)
+	assert.Equal(t, l, after)
+	assert.True(t, after < before)
+
+	// the compacted tree is still usable
+	added := compacted.Add(constructMockEntry(200, 5, 200))
+	assert.Equal(t, uint64(101), added.Len())
+	assert.Equal(t, uint64(100), compacted.Len())
+	assert.Equal(t, tree.Query(iv), compacted.Query(iv))
+}
+
+func TestImmutableCompactEmpty(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+
+	compacted := tree.Compact()
+	assert.Equal(t, uint64(0), compacted.Len())
+	assert.Len(t, compacted.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})), 0)
+}
+
+func TestImmutableCompactEmptyBranches(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(3)
+	// leaves the branch at 2 in the first dimension without entries
+	tree, _, _ = tree.InsertAtDimension(2, 2, -1)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	compacted := tree.Compact()
+	assert.Equal(t, uint64(2), compacted.Len())
+	assert.Equal(t, tree.Query(iv), compacted.Query(iv))
+	assert.Equal(t, entries[:2], compacted.Query(iv))
+	assert.Equal(t, uint64(2), compacted.Stats().Levels[0].Nodes)
+	assert.Equal(t, uint64(3), tree.Stats().Levels[0].Nodes)
+	_, l := capacity(compacted.top)
+	assert.Equal(t, 4, l)
+
+	// a tree that holds nothing but empty branches compacts to nothing
+	emptied := tree.Delete(entries[:2]...)
+	assert.Equal(t, uint64(0), emptied.Len())
+	assert.Len(t, emptied.Compact().top, 0)
+}
+
+func BenchmarkImmutableCompact(b *testing.B) {
+	tree, _ := constructFragmentedImmutableTree(1000)
+	before, _ := capacity(tree.top)
+
+	var compacted *ImmutableRangeTree
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		compacted = tree.Compact()
+	}
+
+	after, _ := capacity(compacted.top)
+	b.ReportMetric(float64(before), `slots-before`)
+	b.ReportMetric(float64(after), `slots-after`)
+}
+
+// sumIDs merges entries by adding their ids together.
+func sumIDs(existing, incoming Entry) Entry {
+	me := existing.(*mockEntry)
+	return constructMockEntry(me.id+incoming.(*mockEntry).id, me.dimensions...)
+}
+
+func TestImmutableAddMerge(t *testing.T) {
+	tree, entries := constructMultiDimensionalImmutableTree(2)
+
+	result := tree.AddMerge(sumIDs,
+		constructMockEntry(5, 1, 1),
+		constructMockEntry(10, 2, 2),
+	)
+	assert.Equal(t, uint64(3), result.Len())
+	assert.Equal(t, Entries{
+		entries[0], constructMockEntry(6, 1, 1), constructMockEntry(10, 2, 2),
+	}, result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})))
+
+	// the original tree is unchanged
+	assert.Equal(t, uint64(2), tree.Len())
+	assert.Equal(t, entries,
+		tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableAddMergeWithinBatch(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+
+	result := tree.AddMerge(sumIDs,
+		constructMockEntry(1, 3, 3),
+		constructMockEntry(2, 3, 3),
+		constructMockEntry(4, 3, 3),
+	)
+	assert.Equal(t, uint64(1), result.Len())
+	assert.Equal(t, Entries{constructMockEntry(7, 3, 3)},
+		result.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableAddMergeMovesEntry(t *testing.T) {
+	tree, _ := constructMultiDimensionalImmutableTree(1)
+
+	assert.Panics(t, func() {
+		tree.AddMerge(func(existing, incoming Entry) Entry {
+			return constructMockEntry(0, 5, 5)
+		}, constructMockEntry(1, 0, 0))
+	})
+}
+
+func TestImmutableCountInInterval(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	for i := int64(0); i < 4; i++ {
+		for j := int64(0); j < 4; j++ {
+			tree = tree.Add(constructMockEntry(uint64(i*4+j), i, j))
+		}
+	}
+
+	assert.Equal(t, uint64(16), tree.CountInInterval(
+		constructMockInterval(dimension{0, 10}, dimension{0, 10}),
+	))
+	assert.Equal(t, uint64(4), tree.CountInInterval(
+		constructMockInterval(dimension{1, 2}, dimension{2, 3}),
+	))
+	assert.Equal(t, uint64(0), tree.CountInInterval(
+		constructMockInterval(dimension{3, 1}, dimension{0, 10}),
+	))
+	assert.Equal(t, uint64(0), tree.CountInInterval(
+		constructMockInterval(dimension{0, 10}, dimension{10, 20}),
+	))
+	assert.Equal(t, uint64(0), NewImmutableRangeTree(2).CountInInterval(
+		constructMockInterval(dimension{0, 10}, dimension{0, 10}),
+	))
+}
+
+func descending(a, b int64) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
+
+func constructDescendingImmutableTree() (*ImmutableRangeTree, [][]Entry) {
+	tree := NewImmutableRangeTreeWithComparators(
+		[]func(a, b int64) int{descending, nil},
+	)
+	entries := make([][]Entry, 4)
+	for i := int64(0); i < 4; i++ {
+		for j := int64(0); j < 2; j++ {
+			entry := constructMockEntry(uint64(i*2+j), i, j)
+			entries[i] = append(entries[i], entry)
+			tree = tree.Add(entry)
+		}
+	}
+
+	return tree, entries
+}
+
+func TestImmutableComparators(t *testing.T) {
+	tree, entries := constructDescendingImmutableTree()
+	assert.Equal(t, uint64(8), tree.Len())
+
+	result := tree.Query(constructMockInterval(dimension{2, 1}, dimension{0, 10}))
+	assert.Equal(t, Entries{
+		entries[2][0], entries[2][1], entries[1][0], entries[1][1],
+	}, result)
+	assert.Len(t, tree.Query(constructMockInterval(dimension{1, 2}, dimension{0, 10})), 0)
+	assert.Equal(t, drainIterator(tree.QueryIter(
+		constructMockInterval(dimension{3, 0}, dimension{1, 1}),
+	)), Entries{entries[3][1], entries[2][1], entries[1][1], entries[0][1]})
+	assert.Equal(t, Entries{entries[0][1], entries[0][0], entries[1][1], entries[1][0]},
+		tree.QueryReverse(constructMockInterval(dimension{1, 0}, dimension{0, 10})),
+	)
+
+	entry, ok := tree.GetAt(2, 1)
+	assert.True(t, ok)
+	assert.Equal(t, entries[2][1], entry)
+	assert.Equal(t, Entries{entries[3][0]}, tree.Get(constructMockEntry(9, 3, 0)))
+
+	deleted := tree.Delete(entries[2][0])
+	assert.Equal(t, uint64(7), deleted.Len())
+	_, ok = deleted.GetAt(2, 0)
+	assert.False(t, ok)
+
+	min, _ := tree.MinAtDimension(1)
+	max, _ := tree.MaxAtDimension(1)
+	assert.Equal(t, int64(3), min)
+	assert.Equal(t, int64(0), max)
+
+	result = tree.Query(NewUnboundedInterval(
+		constructMockInterval(dimension{0, 2}, dimension{1, 0}), []bool{true}, []bool{false, true},
+	))
+	assert.Equal(t, Entries{entries[3][1], entries[2][1]}, result)
+}
+
+func TestImmutableComparatorsInsert(t *testing.T) {
+	tree, entries := constructDescendingImmutableTree()
+
+	// in descending order the values after 2 are those below it, and
+	// adding moves a value back towards the front
+	tree1, modified, deleted := tree.InsertAtDimension(1, 2, 1)
+	assert.Equal(t, Entries{entries[1][0], entries[1][1], entries[0][0], entries[0][1]}, modified)
+	assert.Equal(t, Entries{entries[2][0], entries[2][1]}, deleted)
+	assert.Equal(t, uint64(6), tree1.Len())
+	assert.Equal(t, Entries{entries[1][0], entries[1][1]},
+		tree1.Query(constructMockInterval(dimension{2, 2}, dimension{0, 10})),
+	)
+
+	merged := tree1.Merge(tree)
+	assert.Equal(t, uint64(8), merged.Len())
+	assert.Equal(t, tree.Query(constructMockInterval(dimension{10, 0}, dimension{0, 10})),
+		merged.Query(constructMockInterval(dimension{10, 0}, dimension{0, 10})),
+	)
+}
+
+func TestImmutableComparatorsDimensionZero(t *testing.T) {
+	tree, _ := constructDescendingImmutableTree()
+
+	result, modified, deleted := tree.InsertAtDimension(0, 2, 1)
+	assert.True(t, result == tree)
+	assert.Len(t, modified, 0)
+	assert.Len(t, deleted, 0)
+
+	result, modified = tree.InsertAtDimensionFunc(0, 2, 1, func(Entry) {})
+	assert.True(t, result == tree)
+	assert.Len(t, modified, 0)
+
+	result, modified, deleted = tree.InsertAtDimensionRange(0, 3, 0, 1)
+	assert.True(t, result == tree)
+	assert.Len(t, modified, 0)
+	assert.Len(t, deleted, 0)
+}
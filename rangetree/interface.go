@@ -35,6 +35,16 @@ type Entry interface {
 	ValueAtDimension(dimension uint64) int64
 }
 
+// VersionedEntry is an entry that carries a version, used by
+// AddIfVersion to detect that an entry was replaced by someone else.
+// The tree keeps no versions of its own; the version of a point is read
+// from the entry stored there.
+type VersionedEntry interface {
+	Entry
+	// Version returns the version of this entry.
+	Version() uint64
+}
+
 // Interval describes the methods required to query the rangetree.  Note that
 // all ranges are inclusive.
 type Interval interface {
@@ -63,6 +73,16 @@ type RangeTree interface {
 	// Query will return a list of entries that fall within
 	// the provided interval.  The values at dimensions are inclusive.
 	Query(interval Interval) Entries
+	// QueryLimit is like Query but stops after limit entries have been
+	// found.  Entries are visited in ascending order of the value at the
+	// first dimension, then the second, and so on, so the results for
+	// a given tree and interval are stable.  A limit less than one
+	// returns no entries.
+	QueryLimit(interval Interval, limit int) Entries
+	// QueryReverse is like Query but returns the entries in descending
+	// order of the value at the first dimension, then the second, and
+	// so on, the reverse of the order used by QueryLimit.
+	QueryReverse(interval Interval) Entries
 	// Apply will call the provided function with each entry that exists
 	// within the provided range, in order.  Return false at any time to
 	// cancel iteration.  Altering the entry in such a way that its location
@@ -79,4 +99,23 @@ type RangeTree interface {
 	// were moved.  The second is a list entries that were deleted.  These
 	// lists are exclusive.
 	InsertAtDimension(dimension uint64, index, number int64) (Entries, Entries)
+	// Histogram counts the entries in the tree by fixed width buckets
+	// of the values at the provided dimension.  An entry with value v is
+	// counted in the bucket keyed by floor(v/bucketSize)*bucketSize, so
+	// buckets always start on a multiple of bucketSize, including for
+	// negative values.  Empty buckets are omitted.  An invalid dimension
+	// or a bucketSize less than one returns an empty histogram.
+	Histogram(dimension uint64, bucketSize int64) map[int64]uint64
+	// InsertAtDimensionClamp is like InsertAtDimension except that entries
+	// are never deleted by a negative shift.  An entry at value v >= index
+	// moves to max(v+number, index), so every entry in the range
+	// [index, index-number] ends up at exactly index.  Entries that end up
+	// at the same point are resolved the way Add resolves them: the entry
+	// that started at the higher value overwrites the other.  Returned
+	// are two lists.  The first is a list of entries that were moved,
+	// including those that were clamped, but not one that was at index
+	// and so is clamped back to where it was.  The second is a list of
+	// every entry that was overwritten by the clamp.  These lists are
+	// exclusive.
+	InsertAtDimensionClamp(dimension uint64, index, number int64) (Entries, Entries)
 }
@@ -0,0 +1,206 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import "math"
+
+// exclusiveInterval adjusts the bounds of an interval that should not
+// include some of them.  Values are integers, so excluding a bound is
+// the same as including the next value inside it.
+type exclusiveInterval struct {
+	Interval
+	inclusiveLow, inclusiveHigh []bool
+}
+
+// included returns a bool indicating if the bound at the provided
+// dimension is included.  Bounds without a flag are included.
+func included(flags []bool, dimension uint64) bool {
+	return dimension > uint64(len(flags)) || flags[dimension-1]
+}
+
+// bounds returns the inclusive bounds at the provided dimension.  An
+// empty range is returned as a low greater than its high.
+func (ei *exclusiveInterval) bounds(dimension uint64) (int64, int64) {
+	low, high := ei.Interval.LowAtDimension(dimension), ei.Interval.HighAtDimension(dimension)
+	if !included(ei.inclusiveLow, dimension) {
+		if low == math.MaxInt64 {
+			return math.MaxInt64, math.MinInt64
+		}
+		low++
+	}
+	if !included(ei.inclusiveHigh, dimension) {
+		if high == math.MinInt64 {
+			return math.MaxInt64, math.MinInt64
+		}
+		high--
+	}
+
+	return low, high
+}
+
+// LowAtDimension returns the lowest value included at the provided
+// dimension.
+func (ei *exclusiveInterval) LowAtDimension(dimension uint64) int64 {
+	low, _ := ei.bounds(dimension)
+	return low
+}
+
+// HighAtDimension returns the highest value included at the provided
+// dimension.
+func (ei *exclusiveInterval) HighAtDimension(dimension uint64) int64 {
+	_, high := ei.bounds(dimension)
+	return high
+}
+
+// NewExclusiveInterval returns an interval with the bounds of the
+// provided interval that leaves out the bounds which are not flagged
+// as inclusive, so it may be passed to Query or Apply to search open
+// or half-open ranges.  The flags are indexed by dimension - 1 and
+// any dimension without a flag keeps its bound inclusive.
+//
+// LowAtDimension and HighAtDimension of the result move an excluded
+// bound to the next value in ascending order, which is what trees
+// without comparators search.  An ImmutableRangeTree instead compares
+// against the bounds themselves, so excluded bounds are left out in
+// the order of its comparators, descending or otherwise.
+func NewExclusiveInterval(interval Interval, inclusiveLow, inclusiveHigh []bool) Interval {
+	return &exclusiveInterval{
+		Interval:      interval,
+		inclusiveLow:  inclusiveLow,
+		inclusiveHigh: inclusiveHigh,
+	}
+}
+
+// NewHalfOpenInterval returns an interval that includes the low bound
+// and excludes the high bound of the provided interval at each of the
+// provided number of dimensions.  Adjacent half-open intervals never
+// share a value, which is useful when tiling a space.
+func NewHalfOpenInterval(interval Interval, dimensions uint64) Interval {
+	inclusiveHigh := make([]bool, dimensions)
+	return NewExclusiveInterval(interval, nil, inclusiveHigh)
+}
+
+// unboundedInterval removes some of the bounds of an interval.  An
+// unbounded low is reported as math.MinInt64 and an unbounded high as
+// math.MaxInt64, and the immutable tree skips comparing against either.
+type unboundedInterval struct {
+	Interval
+	unboundedLow, unboundedHigh []bool
+}
+
+// unbounded returns a bool indicating if the bound at the provided
+// dimension has been removed.  Bounds without a flag are kept.
+func unbounded(flags []bool, dimension uint64) bool {
+	return dimension <= uint64(len(flags)) && flags[dimension-1]
+}
+
+// LowAtDimension returns the lowest value included at the provided
+// dimension.
+func (ui *unboundedInterval) LowAtDimension(dimension uint64) int64 {
+	if unbounded(ui.unboundedLow, dimension) {
+		return math.MinInt64
+	}
+
+	return ui.Interval.LowAtDimension(dimension)
+}
+
+// HighAtDimension returns the highest value included at the provided
+// dimension.
+func (ui *unboundedInterval) HighAtDimension(dimension uint64) int64 {
+	if unbounded(ui.unboundedHigh, dimension) {
+		return math.MaxInt64
+	}
+
+	return ui.Interval.HighAtDimension(dimension)
+}
+
+// dimensionBounds are the bounds of an interval at a single dimension
+// as they were given, so they may be compared in any order.
+type dimensionBounds struct {
+	low, high int64
+	// openLow and openHigh are set for a bound that has been removed,
+	// whose value is not used.
+	openLow, openHigh bool
+	// exclusiveLow and exclusiveHigh are set for a bound that is not
+	// itself included.
+	exclusiveLow, exclusiveHigh bool
+}
+
+// within returns a bool indicating if the provided value is within the
+// bounds in the order of the comparator.
+func (b dimensionBounds) within(value int64, c comparator) bool {
+	if !b.openLow {
+		if cmp := c.compare(value, b.low); cmp < 0 || (cmp == 0 && b.exclusiveLow) {
+			return false
+		}
+	}
+	if !b.openHigh {
+		if cmp := c.compare(value, b.high); cmp > 0 || (cmp == 0 && b.exclusiveHigh) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// boundsAt returns the bounds of the provided interval at the provided
+// dimension.  The bounds of an exclusive interval are returned before
+// they are moved inside, as only ascending order knows which value is
+// next, and flagged as excluded instead.
+func boundsAt(interval Interval, dimension uint64) dimensionBounds {
+	switch iv := interval.(type) {
+	case *exclusiveInterval:
+		b := boundsAt(iv.Interval, dimension)
+		b.exclusiveLow = b.exclusiveLow || !included(iv.inclusiveLow, dimension)
+		b.exclusiveHigh = b.exclusiveHigh || !included(iv.inclusiveHigh, dimension)
+		return b
+	case *unboundedInterval:
+		openLow, openHigh := unbounded(iv.unboundedLow, dimension), unbounded(iv.unboundedHigh, dimension)
+		var b dimensionBounds
+		if _, ok := iv.Interval.(*exclusiveInterval); ok && (!openLow || !openHigh) {
+			b = boundsAt(iv.Interval, dimension)
+		} else {
+			if !openLow {
+				b.low = iv.Interval.LowAtDimension(dimension)
+			}
+			if !openHigh {
+				b.high = iv.Interval.HighAtDimension(dimension)
+			}
+		}
+		b.openLow, b.openHigh = openLow, openHigh
+		return b
+	default:
+		return dimensionBounds{
+			low:  interval.LowAtDimension(dimension),
+			high: interval.HighAtDimension(dimension),
+		}
+	}
+}
+
+// NewUnboundedInterval returns an interval with the bounds of the
+// provided interval except for those flagged as unbounded, which
+// include every value below or above the other bound.  The flags are
+// indexed by dimension - 1 and any dimension without a flag keeps its
+// bound.  The provided interval is not asked for a bound that has been
+// removed.
+func NewUnboundedInterval(interval Interval, unboundedLow, unboundedHigh []bool) Interval {
+	return &unboundedInterval{
+		Interval:      interval,
+		unboundedLow:  unboundedLow,
+		unboundedHigh: unboundedHigh,
+	}
+}
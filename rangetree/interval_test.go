@@ -0,0 +1,154 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExclusiveInterval(t *testing.T) {
+	iv := NewExclusiveInterval(
+		constructMockInterval(dimension{0, 10}, dimension{5, 7}),
+		[]bool{false, true}, []bool{true, false},
+	)
+
+	assert.Equal(t, int64(1), iv.LowAtDimension(1))
+	assert.Equal(t, int64(10), iv.HighAtDimension(1))
+	assert.Equal(t, int64(5), iv.LowAtDimension(2))
+	assert.Equal(t, int64(6), iv.HighAtDimension(2))
+}
+
+func TestExclusiveIntervalMissingFlags(t *testing.T) {
+	iv := NewExclusiveInterval(constructMockInterval(dimension{0, 10}, dimension{5, 7}), nil, []bool{false})
+
+	assert.Equal(t, int64(0), iv.LowAtDimension(1))
+	assert.Equal(t, int64(9), iv.HighAtDimension(1))
+	assert.Equal(t, int64(5), iv.LowAtDimension(2))
+	assert.Equal(t, int64(7), iv.HighAtDimension(2))
+}
+
+func TestExclusiveIntervalEmpty(t *testing.T) {
+	iv := NewExclusiveInterval(
+		constructMockInterval(dimension{math.MaxInt64, math.MaxInt64}, dimension{math.MinInt64, 0}),
+		[]bool{false, true}, []bool{true, true},
+	)
+	assert.True(t, iv.LowAtDimension(1) > iv.HighAtDimension(1))
+
+	iv = NewHalfOpenInterval(constructMockInterval(dimension{math.MinInt64, math.MinInt64}), 1)
+	assert.True(t, iv.LowAtDimension(1) > iv.HighAtDimension(1))
+}
+
+func TestHalfOpenIntervalQuery(t *testing.T) {
+	tree, entries := constructMultiDimensionalOrderedTree(10)
+
+	first := tree.Query(NewHalfOpenInterval(constructMockInterval(dimension{0, 5}, dimension{0, 10}), 2))
+	second := tree.Query(NewHalfOpenInterval(constructMockInterval(dimension{5, 10}, dimension{0, 10}), 2))
+	assert.Equal(t, entries[:5], first)
+	assert.Equal(t, entries[5:], second)
+
+	immutable, _ := constructMultiDimensionalImmutableTree(10)
+	result := immutable.Query(NewExclusiveInterval(
+		constructMockInterval(dimension{2, 5}, dimension{0, 10}), []bool{false}, nil,
+	))
+	assert.Equal(t, entries[3:6], result)
+}
+
+func TestExclusiveIntervalDescending(t *testing.T) {
+	tree, entries := constructDescendingImmutableTree()
+
+	// in descending order 3 is the low bound and 0 the high one, so
+	// excluding them leaves 2 and 1
+	iv := constructMockInterval(dimension{3, 0}, dimension{0, 10})
+	result := tree.Query(NewExclusiveInterval(iv, []bool{false}, []bool{false}))
+	assert.Equal(t, Entries{
+		entries[2][0], entries[2][1], entries[1][0], entries[1][1],
+	}, result)
+	assert.Equal(t, uint64(4), tree.CountInInterval(
+		NewExclusiveInterval(iv, []bool{false}, []bool{false}),
+	))
+	assert.Equal(t, Entries{entries[3][0], entries[3][1], entries[2][0], entries[2][1]},
+		tree.Query(NewExclusiveInterval(constructMockInterval(dimension{3, 1}, dimension{0, 10}), nil, []bool{false})),
+	)
+	assert.Len(t, tree.Query(NewExclusiveInterval(
+		constructMockInterval(dimension{2, 2}, dimension{0, 10}), []bool{false}, nil,
+	)), 0)
+
+	result = tree.Query(NewExclusiveInterval(NewUnboundedInterval(
+		constructMockInterval(dimension{0, 1}, dimension{0, 10}), []bool{true}, nil,
+	), nil, []bool{false}))
+	assert.Equal(t, Entries{entries[3][0], entries[3][1], entries[2][0], entries[2][1]}, result)
+
+	result = tree.QueryAll(
+		NewHalfOpenInterval(constructMockInterval(dimension{3, 1}, dimension{0, 1}), 2),
+	)
+	assert.Equal(t, Entries{entries[3][0], entries[2][0]}, result)
+}
+
+func TestExclusiveIntervalEmptyQuery(t *testing.T) {
+	tree, _ := constructMultiDimensionalOrderedTree(10)
+
+	result := tree.Query(NewExclusiveInterval(
+		constructMockInterval(dimension{3, 4}, dimension{0, 10}), []bool{false}, []bool{false},
+	))
+	assert.Len(t, result, 0)
+}
+
+func TestUnboundedInterval(t *testing.T) {
+	iv := NewUnboundedInterval(
+		constructMockInterval(dimension{0, 10}, dimension{5, 7}),
+		[]bool{true}, []bool{false, true},
+	)
+
+	assert.Equal(t, int64(math.MinInt64), iv.LowAtDimension(1))
+	assert.Equal(t, int64(10), iv.HighAtDimension(1))
+	assert.Equal(t, int64(5), iv.LowAtDimension(2))
+	assert.Equal(t, int64(math.MaxInt64), iv.HighAtDimension(2))
+}
+
+func TestUnboundedIntervalQuery(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	e1 := constructMockEntry(0, math.MinInt64, 0)
+	e2 := constructMockEntry(1, 3, 5)
+	e3 := constructMockEntry(2, 3, math.MaxInt64)
+	e4 := constructMockEntry(3, 8, 100)
+	tree = tree.Add(e1, e2, e3, e4)
+
+	result := tree.Query(NewUnboundedInterval(
+		constructMockInterval(dimension{0, 5}, dimension{5, 0}), nil, []bool{false, true},
+	))
+	assert.Equal(t, Entries{e2, e3}, result)
+
+	result = tree.Query(NewUnboundedInterval(
+		constructMockInterval(dimension{0, 5}, dimension{0, 5}), []bool{true}, nil,
+	))
+	assert.Equal(t, Entries{e1, e2}, result)
+
+	result = tree.Query(NewUnboundedInterval(nil, []bool{true, true}, []bool{true, true}))
+	assert.Equal(t, Entries{e1, e2, e3, e4}, result)
+	assert.Equal(t, uint64(4), tree.CountInInterval(
+		NewUnboundedInterval(nil, []bool{true, true}, []bool{true, true}),
+	))
+
+	ordered, entries := constructMultiDimensionalOrderedTree(5)
+	result = ordered.Query(NewUnboundedInterval(
+		constructMockInterval(dimension{2, 0}, dimension{0, 0}), nil, []bool{true, true},
+	))
+	assert.Equal(t, entries[2:], result)
+}
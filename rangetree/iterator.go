@@ -0,0 +1,83 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+// iteratorFrame is the position of a QueryIterator within the list of
+// nodes at one dimension.
+type iteratorFrame struct {
+	list       orderedNodes
+	index, end int
+}
+
+// QueryIterator walks the entries of an immutable tree that fall within
+// an interval one at a time, in the same order Query returns them.  It
+// holds no resources beyond the tree itself, so it may be abandoned at
+// any point.
+type QueryIterator struct {
+	tree     *ImmutableRangeTree
+	interval Interval
+	stack    []iteratorFrame
+}
+
+func (qi *QueryIterator) push(list orderedNodes, dimension uint64) {
+	start, end := qi.tree.span(list, qi.interval, dimension)
+	qi.stack = append(qi.stack, iteratorFrame{
+		list:  list,
+		index: start,
+		end:   end,
+	})
+}
+
+// Next returns the next entry within the interval and true, or nil and
+// false once every entry has been returned.
+func (qi *QueryIterator) Next() (Entry, bool) {
+	for len(qi.stack) > 0 {
+		frame := &qi.stack[len(qi.stack)-1]
+		if frame.index >= frame.end {
+			qi.stack = qi.stack[:len(qi.stack)-1]
+			continue
+		}
+
+		n := frame.list[frame.index]
+		frame.index++
+		dimension := uint64(len(qi.stack))
+		if isLastDimension(qi.tree.dimensions, dimension) {
+			return n.entry, true
+		}
+
+		qi.push(n.orderedNodes, dimension+1)
+	}
+
+	return nil, false
+}
+
+// QueryIter returns an iterator over the entries in the given interval.
+// Unlike Query, the entries are found as the iterator is advanced rather
+// than all up front.
+func (irt *ImmutableRangeTree) QueryIter(interval Interval) *QueryIterator {
+	qi := &QueryIterator{
+		tree:     irt,
+		interval: interval,
+	}
+	if irt.number == 0 {
+		return qi
+	}
+
+	qi.stack = make([]iteratorFrame, 0, irt.dimensions)
+	qi.push(irt.top, 1)
+	return qi
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func drainIterator(qi *QueryIterator) Entries {
+	entries := Entries{}
+	for entry, ok := qi.Next(); ok; entry, ok = qi.Next() {
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestQueryIterMatchesQuery(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	for i := int64(0); i < 5; i++ {
+		for j := int64(0); j < 5; j++ {
+			tree = tree.Add(constructMockEntry(uint64(i*5+j), i, j))
+		}
+	}
+
+	ivs := []*mockInterval{
+		constructMockInterval(dimension{0, 10}, dimension{0, 10}),
+		constructMockInterval(dimension{1, 3}, dimension{2, 2}),
+		constructMockInterval(dimension{4, 10}, dimension{-5, 0}),
+		constructMockInterval(dimension{6, 10}, dimension{0, 10}),
+	}
+	for _, iv := range ivs {
+		assert.Equal(t, tree.Query(iv), drainIterator(tree.QueryIter(iv)))
+	}
+}
+
+func TestQueryIterEmpty(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+
+	entry, ok := tree.QueryIter(iv).Next()
+	assert.False(t, ok)
+	assert.Nil(t, entry)
+
+	tree = tree.Add(constructMockEntry(0, 1, 1))
+	entry, ok = tree.QueryIter(
+		constructMockInterval(dimension{5, 0}, dimension{0, 10}),
+	).Next()
+	assert.False(t, ok)
+	assert.Nil(t, entry)
+}
+
+func TestQueryIterAbandon(t *testing.T) {
+	tree := NewImmutableRangeTree(1)
+	e1 := constructMockEntry(0, 0)
+	e2 := constructMockEntry(1, 1)
+	tree = tree.Add(e1, e2)
+
+	qi := tree.QueryIter(constructMockInterval(dimension{0, 10}))
+	entry, ok := qi.Next()
+	assert.True(t, ok)
+	assert.Equal(t, e1, entry)
+
+	entry, ok = qi.Next()
+	assert.True(t, ok)
+	assert.Equal(t, e2, entry)
+
+	entry, ok = qi.Next()
+	assert.False(t, ok)
+	assert.Nil(t, entry)
+	_, ok = qi.Next()
+	assert.False(t, ok)
+}
+
+func BenchmarkQueryIter(b *testing.B) {
+	numItems := int64(1000)
+	tree := NewImmutableRangeTree(2)
+	for i := int64(0); i < numItems; i++ {
+		tree = tree.Add(constructMockEntry(uint64(i), i, i))
+	}
+	iv := constructMockInterval(dimension{0, numItems}, dimension{0, numItems})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		drainIterator(tree.QueryIter(iv))
+	}
+}
@@ -57,3 +57,19 @@ func constructMockInterval(dimensions ...dimension) *mockInterval {
 		dimensions: dimensions,
 	}
 }
+
+type mockVersionedEntry struct {
+	*mockEntry
+	version uint64
+}
+
+func (mve *mockVersionedEntry) Version() uint64 {
+	return mve.version
+}
+
+func constructMockVersionedEntry(id, version uint64, values ...int64) *mockVersionedEntry {
+	return &mockVersionedEntry{
+		mockEntry: constructMockEntry(id, values...),
+		version:   version,
+	}
+}
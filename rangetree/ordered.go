@@ -16,7 +16,10 @@ limitations under the License.
 
 package rangetree
 
-import "sort"
+import (
+	"math"
+	"sort"
+)
 
 // orderedNodes represents an ordered list of points living
 // at the last dimension.  No duplicates can be inserted here.
@@ -29,6 +32,58 @@ func (nodes orderedNodes) search(value int64) int {
 	)
 }
 
+// comparator orders the values at a dimension of an immutable tree.  It
+// returns a negative number if a comes before b, a positive number if a
+// comes after b and zero only if they are equal.  A nil comparator
+// orders values ascending.
+type comparator func(a, b int64) int
+
+// compare returns the order of a and b.
+func (c comparator) compare(a, b int64) int {
+	if c != nil {
+		return c(a, b)
+	}
+
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// searchBy is like search for nodes ordered by the provided comparator.
+func (nodes orderedNodes) searchBy(value int64, c comparator) int {
+	if c == nil {
+		return nodes.search(value)
+	}
+
+	return sort.Search(
+		len(nodes),
+		func(i int) bool { return c(nodes[i].value, value) >= 0 },
+	)
+}
+
+// span returns the range of indices, [start, end), of the nodes between
+// the provided bounds in the order of the provided comparator.  An open
+// bound includes every node before or after the other.
+func (nodes orderedNodes) span(low, high int64, openLow, openHigh bool, c comparator) (int, int) {
+	start, end := 0, len(nodes)
+	if !openLow {
+		start = nodes.searchBy(low, c)
+	}
+	if !openHigh {
+		rest := nodes[start:]
+		end = start + sort.Search(
+			len(rest),
+			func(i int) bool { return c.compare(rest[i].value, high) > 0 },
+		)
+	}
+
+	return start, end
+}
+
 // addAt will add the provided node at the provided index.  Returns
 // a node if one was overwritten.
 func (nodes *orderedNodes) addAt(i int, node *node) *node {
@@ -56,6 +111,12 @@ func (nodes *orderedNodes) add(node *node) *node {
 	return nodes.addAt(i, node)
 }
 
+// addBy is like add for nodes ordered by the provided comparator.
+func (nodes *orderedNodes) addBy(node *node, c comparator) *node {
+	i := nodes.searchBy(node.value, c)
+	return nodes.addAt(i, node)
+}
+
 func (nodes *orderedNodes) deleteAt(i int) *node {
 	if i >= len(*nodes) { // no matching found
 		return nil
@@ -97,8 +158,33 @@ func (nodes orderedNodes) apply(low, high int64, fn func(*node) bool) bool {
 	return true
 }
 
+// applyReverse is like apply but visits the nodes in descending order.
+func (nodes orderedNodes) applyReverse(low, high int64, fn func(*node) bool) bool {
+	index := sort.Search(
+		len(nodes),
+		func(i int) bool { return nodes[i].value > high },
+	)
+
+	for index--; index >= 0; index-- {
+		if nodes[index].value < low {
+			break
+		}
+
+		if !fn(nodes[index]) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (nodes orderedNodes) get(value int64) (*node, int) {
-	i := nodes.search(value)
+	return nodes.getBy(value, nil)
+}
+
+// getBy is like get for nodes ordered by the provided comparator.
+func (nodes orderedNodes) getBy(value int64, c comparator) (*node, int) {
+	i := nodes.searchBy(value, c)
 	if i == len(nodes) {
 		return nil, i
 	}
@@ -113,10 +199,18 @@ func (nodes orderedNodes) get(value int64) (*node, int) {
 func (nodes *orderedNodes) getOrAdd(entry Entry,
 	dimension, lastDimension uint64) (*node, bool) {
 
+	return nodes.getOrAddBy(entry, dimension, lastDimension, nil)
+}
+
+// getOrAddBy is like getOrAdd for nodes ordered by the provided
+// comparator.
+func (nodes *orderedNodes) getOrAddBy(entry Entry,
+	dimension, lastDimension uint64, c comparator) (*node, bool) {
+
 	isLastDimension := isLastDimension(lastDimension, dimension)
 	value := entry.ValueAtDimension(dimension)
 
-	i := nodes.search(value)
+	i := nodes.searchBy(value, c)
 	if i == len(*nodes) {
 		node := newNode(value, entry, !isLastDimension)
 		*nodes = append(*nodes, node)
@@ -144,6 +238,17 @@ func (nodes orderedNodes) flatten(entries *Entries) {
 	}
 }
 
+// each calls fn with every entry beneath these nodes, in order.
+func (nodes orderedNodes) each(fn func(Entry)) {
+	for _, node := range nodes {
+		if node.orderedNodes != nil {
+			node.orderedNodes.each(fn)
+		} else {
+			fn(node.entry)
+		}
+	}
+}
+
 func (nodes *orderedNodes) insert(insertDimension, dimension, maxDimension uint64,
 	index, number int64, modified, deleted *Entries) {
 
@@ -186,8 +291,150 @@ func (nodes *orderedNodes) insert(insertDimension, dimension, maxDimension uint6
 	}
 }
 
+// insertClamp is like insert except that nodes shifted below index are
+// placed at index and merged, rather than deleted.  Only the entries
+// that actually move are added to modified.
+func (nodes *orderedNodes) insertClamp(insertDimension, dimension, maxDimension uint64,
+	index, number int64, modified, overwritten *Entries) {
+
+	lastDimension := isLastDimension(maxDimension, dimension)
+
+	if insertDimension == dimension {
+		i := nodes.search(index)
+		if i == len(*nodes) {
+			return
+		}
+
+		// a node at index is clamped back to where it is, so it and
+		// anything beneath it stays put
+		var unmoved *node
+		start := i
+		if number < 0 && (*nodes)[i].value == index {
+			unmoved = (*nodes)[i]
+			start++
+		}
+
+		for j := start; j < len(*nodes); j++ {
+			(*nodes)[j].value += number
+			if (*nodes)[j].value < index {
+				(*nodes)[j].value = index
+			}
+		}
+
+		// nodes are visited in their original order, so a later
+		// node overwrites an earlier one at the same point
+		var clamped *node
+		k := start
+		for ; k < len(*nodes) && (*nodes)[k].value == index; k++ {
+			switch {
+			case clamped == nil:
+				clamped = (*nodes)[k]
+				if lastDimension && unmoved != nil {
+					*overwritten = append(*overwritten, unmoved.entry)
+				}
+			case lastDimension:
+				*overwritten = append(*overwritten, clamped.entry)
+				clamped = (*nodes)[k]
+			default:
+				clamped.orderedNodes.merge((*nodes)[k].orderedNodes, overwritten)
+			}
+		}
+
+		// everything from start on has moved and nothing moved is
+		// overwritten by the unmoved node, which started lower
+		if clamped != nil {
+			orderedNodes{clamped}.flatten(modified)
+		}
+		(*nodes)[k:].flatten(modified)
+
+		target := unmoved
+		switch {
+		case clamped == nil:
+		case unmoved == nil, lastDimension:
+			target = clamped
+		default:
+			unmoved.orderedNodes.merge(clamped.orderedNodes, overwritten)
+		}
+
+		if k > i+1 {
+			(*nodes)[i] = target
+			n := copy((*nodes)[i+1:], (*nodes)[k:])
+			for j := i + 1 + n; j < len(*nodes); j++ {
+				(*nodes)[j] = nil
+			}
+			*nodes = (*nodes)[:i+1+n]
+		}
+
+		return
+	}
+
+	for _, node := range *nodes {
+		node.orderedNodes.insertClamp(
+			insertDimension, dimension+1, maxDimension,
+			index, number, modified, overwritten,
+		)
+	}
+}
+
+// merge adds the nodes in other to these nodes.  Points that exist in
+// both are overwritten by other and the overwritten entries are added
+// to the provided list.
+func (nodes *orderedNodes) merge(other orderedNodes, overwritten *Entries) {
+	for _, node := range other {
+		existing, i := nodes.get(node.value)
+		if existing == nil {
+			nodes.addAt(i, node)
+			continue
+		}
+
+		if node.orderedNodes == nil { // last dimension
+			*overwritten = append(*overwritten, existing.entry)
+			(*nodes)[i] = node
+			continue
+		}
+
+		existing.orderedNodes.merge(node.orderedNodes, overwritten)
+	}
+}
+
+// histogram adds a count for every entry beneath these nodes to the
+// bucket of its value at histDimension.  value is the value at
+// histDimension seen on the way down, if any.
+func (nodes orderedNodes) histogram(dimension, histDimension uint64,
+	value, bucketSize int64, hist map[int64]uint64) {
+
+	for _, node := range nodes {
+		if dimension == histDimension {
+			value = node.value
+		}
+
+		if node.orderedNodes == nil { // last dimension
+			hist[bucket(value, bucketSize)]++
+			continue
+		}
+
+		node.orderedNodes.histogram(dimension+1, histDimension,
+			value, bucketSize, hist,
+		)
+	}
+}
+
+// bucket returns floor(value/size)*size.
+func bucket(value, size int64) int64 {
+	b := value / size * size
+	if b > value { // division truncates towards zero
+		b -= size
+	}
+
+	return b
+}
+
+// immutableInsert is like insert but copies rather than modifies the
+// nodes it changes.  The nodes at insertDimension are ordered by the
+// provided comparator.  onDelete is called with each entry that is
+// deleted.
 func (nodes orderedNodes) immutableInsert(insertDimension, dimension, maxDimension uint64,
-	index, number int64, modified, deleted *Entries) orderedNodes {
+	index, number int64, c comparator, modified *Entries, onDelete func(Entry)) orderedNodes {
 
 	lastDimension := isLastDimension(maxDimension, dimension)
 
@@ -195,19 +442,19 @@ func (nodes orderedNodes) immutableInsert(insertDimension, dimension, maxDimensi
 	copy(cp, nodes)
 
 	if insertDimension == dimension {
-		i := cp.search(index)
+		i := cp.searchBy(index, c)
 		var toDelete []int
 
 		for j := i; j < len(cp); j++ {
 			nn := newNode(cp[j].value+number, cp[j].entry, !lastDimension)
 			nn.orderedNodes = cp[j].orderedNodes
 			cp[j] = nn
-			if cp[j].value < index {
+			if c.compare(cp[j].value, index) < 0 {
 				toDelete = append(toDelete, j)
 				if lastDimension {
-					*deleted = append(*deleted, cp[j].entry)
+					onDelete(cp[j].entry)
 				} else {
-					cp[j].orderedNodes.flatten(deleted)
+					cp[j].orderedNodes.each(onDelete)
 				}
 				continue
 			}
@@ -218,8 +465,9 @@ func (nodes orderedNodes) immutableInsert(insertDimension, dimension, maxDimensi
 			}
 		}
 
-		for _, index := range toDelete {
-			cp.deleteAt(index)
+		// delete from the back so the remaining indices stay valid
+		for j := len(toDelete) - 1; j >= 0; j-- {
+			cp.deleteAt(toDelete[j])
 		}
 
 		return cp
@@ -231,11 +479,107 @@ func (nodes orderedNodes) immutableInsert(insertDimension, dimension, maxDimensi
 		nn.orderedNodes = oldNode.orderedNodes.immutableInsert(
 			insertDimension, dimension+1,
 			maxDimension,
-			index, number,
-			modified, deleted,
+			index, number, c,
+			modified, onDelete,
 		)
 		cp[i] = nn
 	}
 
 	return cp
 }
+
+// outside returns a bool indicating if value moved by number leaves the
+// band [from, to] in the order of the provided comparator.
+func outside(value, number, from, to int64, c comparator) bool {
+	if c == nil {
+		// the distances are computed unsigned so they cannot overflow
+		return (number > 0 && uint64(to-value) < uint64(number)) ||
+			(number < 0 && uint64(value-from) < uint64(-number))
+	}
+
+	if (number > 0 && value > math.MaxInt64-number) ||
+		(number < 0 && value < math.MinInt64-number) {
+
+		return true
+	}
+
+	value += number
+	return c(value, from) < 0 || c(value, to) > 0
+}
+
+// immutableInsertRange is like immutableInsert except that only nodes
+// with a value in [from, to] at insertDimension move, and those moved
+// outside of it are deleted.  Nodes left without children are removed.
+// nodes is returned as is, along with false, if no value is in the band.
+func (nodes orderedNodes) immutableInsertRange(insertDimension, dimension, maxDimension uint64,
+	from, to, number int64, c comparator, modified *Entries, onDelete func(Entry)) (orderedNodes, bool) {
+
+	lastDimension := isLastDimension(maxDimension, dimension)
+
+	if insertDimension == dimension {
+		i, end := nodes.span(from, to, false, false, c)
+		if i == end {
+			return nodes, false
+		}
+
+		cp := make(orderedNodes, i, len(nodes))
+		copy(cp, nodes[:i])
+
+		j := i
+		for ; j < end; j++ {
+			value := nodes[j].value
+			if outside(value, number, from, to, c) {
+				if lastDimension {
+					onDelete(nodes[j].entry)
+				} else {
+					nodes[j].orderedNodes.each(onDelete)
+				}
+				continue
+			}
+
+			nn := newNode(value+number, nodes[j].entry, !lastDimension)
+			nn.orderedNodes = nodes[j].orderedNodes
+			cp = append(cp, nn)
+			if lastDimension {
+				*modified = append(*modified, nn.entry)
+			} else {
+				nn.orderedNodes.flatten(modified)
+			}
+		}
+
+		return append(cp, nodes[j:]...), true
+	}
+
+	var cp orderedNodes // only allocated once something changes
+	for i, oldNode := range nodes {
+		list, changed := oldNode.orderedNodes.immutableInsertRange(
+			insertDimension, dimension+1, maxDimension,
+			from, to, number, c,
+			modified, onDelete,
+		)
+		if !changed && cp == nil {
+			continue
+		}
+
+		if cp == nil {
+			cp = make(orderedNodes, i, len(nodes))
+			copy(cp, nodes[:i])
+		}
+		if !changed {
+			cp = append(cp, oldNode)
+			continue
+		}
+		if len(list) == 0 {
+			continue
+		}
+
+		nn := newNode(oldNode.value, oldNode.entry, !lastDimension)
+		nn.orderedNodes = list
+		cp = append(cp, nn)
+	}
+
+	if cp == nil {
+		return nodes, false
+	}
+	return cp, true
+}
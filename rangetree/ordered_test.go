@@ -138,6 +138,49 @@ func TestApply(t *testing.T) {
 	assert.Equal(t, nodes{n2}, results)
 }
 
+func TestApplyReverse(t *testing.T) {
+	ns := make(orderedNodes, 0)
+
+	n1 := newNode(4, constructMockEntry(1, 4), false)
+	n2 := newNode(1, constructMockEntry(2, 1), false)
+	n3 := newNode(7, constructMockEntry(3, 7), false)
+
+	ns.add(n1)
+	ns.add(n2)
+	ns.add(n3)
+
+	results := make(nodes, 0, 3)
+	ns.applyReverse(0, 100, func(n *node) bool {
+		results = append(results, n)
+		return true
+	})
+
+	assert.Equal(t, nodes{n3, n1, n2}, results)
+	results = results[:0]
+
+	ns.applyReverse(1, 4, func(n *node) bool {
+		results = append(results, n)
+		return true
+	})
+
+	assert.Equal(t, nodes{n1, n2}, results)
+	results = results[:0]
+
+	ns.applyReverse(5, 6, func(n *node) bool {
+		results = append(results, n)
+		return true
+	})
+
+	assert.Len(t, results, 0)
+
+	ns.applyReverse(0, 100, func(n *node) bool {
+		results = append(results, n)
+		return false
+	})
+
+	assert.Equal(t, nodes{n3}, results)
+}
+
 func TestInsertDelete(t *testing.T) {
 	ns := make(orderedNodes, 0)
 
@@ -201,6 +201,22 @@ func (ot *orderedTree) apply(list orderedNodes, interval Interval,
 	return true
 }
 
+// applyReverse is like apply but visits the nodes in descending order
+// at every dimension.
+func (ot *orderedTree) applyReverse(list orderedNodes, interval Interval,
+	dimension uint64, fn func(*node) bool) bool {
+
+	low, high := interval.LowAtDimension(dimension), interval.HighAtDimension(dimension)
+
+	if isLastDimension(ot.dimensions, dimension) {
+		return list.applyReverse(low, high, fn)
+	}
+
+	return list.applyReverse(low, high, func(n *node) bool {
+		return ot.applyReverse(n.orderedNodes, interval, dimension+1, fn)
+	})
+}
+
 // Apply will call (in order) the provided function to every
 // entry that falls within the provided interval.  Any alteration
 // the the entry that would result in different answers to the
@@ -224,6 +240,49 @@ func (ot *orderedTree) Query(interval Interval) Entries {
 	return entries
 }
 
+// QueryLimit will return an ordered list of at most limit results
+// in the given interval.  Entries are ordered by their value at the
+// first dimension, then the second, and so on.
+func (ot *orderedTree) QueryLimit(interval Interval, limit int) Entries {
+	entries := NewEntries()
+	if limit < 1 {
+		return entries
+	}
+
+	ot.apply(ot.top, interval, 1, func(n *node) bool {
+		entries = append(entries, n.entry)
+		return len(entries) < limit
+	})
+
+	return entries
+}
+
+// QueryReverse will return the results in the given interval in the
+// opposite order of Query.  Entries are ordered by descending value at
+// the first dimension, then the second, and so on.
+func (ot *orderedTree) QueryReverse(interval Interval) Entries {
+	entries := NewEntries()
+
+	ot.applyReverse(ot.top, interval, 1, func(n *node) bool {
+		entries = append(entries, n.entry)
+		return true
+	})
+
+	return entries
+}
+
+// Histogram counts the entries in the tree by fixed width buckets
+// of the values at the provided dimension in a single pass.
+func (ot *orderedTree) Histogram(dimension uint64, bucketSize int64) map[int64]uint64 {
+	hist := make(map[int64]uint64)
+	if dimension < 1 || dimension > ot.dimensions || bucketSize < 1 {
+		return hist
+	}
+
+	ot.top.histogram(1, dimension, 0, bucketSize, hist)
+	return hist
+}
+
 // InsertAtDimension will increment items at and above the given index
 // by the number provided.  Provide a negative number to to decrement.
 // Returned are two lists.  The first list is a list of entries that
@@ -249,6 +308,29 @@ func (ot *orderedTree) InsertAtDimension(dimension uint64,
 	return modified, deleted
 }
 
+// InsertAtDimensionClamp is like InsertAtDimension except that entries
+// shifted below index are clamped to index instead of being deleted.
+// Returned are the entries that were moved and the entries that were
+// overwritten because they were clamped onto the same point as another.
+func (ot *orderedTree) InsertAtDimensionClamp(dimension uint64,
+	index, number int64) (Entries, Entries) {
+
+	if dimension > ot.dimensions || number == 0 {
+		return nil, nil
+	}
+
+	modified := make(Entries, 0, 100)
+	overwritten := make(Entries, 0, 10)
+
+	ot.top.insertClamp(dimension, 1, ot.dimensions,
+		index, number, &modified, &overwritten,
+	)
+
+	ot.number -= uint64(len(overwritten))
+
+	return modified, overwritten
+}
+
 func newOrderedTree(dimensions uint64) *orderedTree {
 	return &orderedTree{
 		dimensions: dimensions,
@@ -386,6 +386,60 @@ func TestApplyWithBail(t *testing.T) {
 	assert.Equal(t, entries[:1], result)
 }
 
+func TestQueryLimit(t *testing.T) {
+	tree, entries := constructMultiDimensionalOrderedTree(10)
+	iv := constructMockInterval(dimension{0, 100}, dimension{0, 100})
+
+	result := tree.QueryLimit(iv, 3)
+	assert.Equal(t, entries[:3], result)
+
+	result = tree.QueryLimit(iv, 100)
+	assert.Equal(t, entries, result)
+
+	result = tree.QueryLimit(iv, 0)
+	assert.Len(t, result, 0)
+
+	result = tree.QueryLimit(
+		constructMockInterval(dimension{4, 100}, dimension{0, 100}), 2,
+	)
+	assert.Equal(t, entries[4:6], result)
+}
+
+func TestQueryLimitOrder(t *testing.T) {
+	tree := newOrderedTree(2)
+	e1 := constructMockEntry(0, 1, 5)
+	e2 := constructMockEntry(1, 1, 2)
+	e3 := constructMockEntry(2, 0, 9)
+	tree.Add(e1, e2, e3)
+
+	result := tree.QueryLimit(
+		constructMockInterval(dimension{0, 10}, dimension{0, 10}), 2,
+	)
+	assert.Equal(t, Entries{e3, e2}, result)
+}
+
+func TestQueryReverse(t *testing.T) {
+	tree, entries := constructMultiDimensionalOrderedTree(10)
+
+	result := tree.QueryReverse(constructMockInterval(dimension{2, 5}, dimension{0, 100}))
+	assert.Equal(t, Entries{entries[5], entries[4], entries[3], entries[2]}, result)
+
+	result = tree.QueryReverse(constructMockInterval(dimension{20, 50}, dimension{0, 100}))
+	assert.Len(t, result, 0)
+}
+
+func TestQueryReverseOrder(t *testing.T) {
+	tree := newOrderedTree(2)
+	e1 := constructMockEntry(0, 1, 5)
+	e2 := constructMockEntry(1, 1, 2)
+	e3 := constructMockEntry(2, 0, 9)
+	tree.Add(e1, e2, e3)
+
+	iv := constructMockInterval(dimension{0, 10}, dimension{0, 10})
+	assert.Equal(t, Entries{e1, e2, e3}, tree.QueryReverse(iv))
+	assert.Equal(t, Entries{e3, e2, e1}, tree.Query(iv))
+}
+
 func BenchmarkApply(b *testing.B) {
 	numItems := 1000
 
@@ -573,6 +627,156 @@ func TestInsertInvalidNumber(t *testing.T) {
 	assert.Equal(t, entries, result)
 }
 
+func TestInsertClampFirstDimension(t *testing.T) {
+	tree, entries := constructMultiDimensionalOrderedTree(4)
+
+	modified, overwritten := tree.InsertAtDimensionClamp(1, 1, -1)
+	assert.Len(t, overwritten, 0)
+	// entry 1 is clamped back to where it is, so it has not moved
+	assert.Equal(t, entries[2:], modified)
+	assert.Equal(t, uint64(4), tree.Len())
+
+	// entry 1 is clamped onto index 1 and entry 2 lands there too, but
+	// they differ at the second dimension so both are kept
+	result := tree.Query(constructMockInterval(dimension{1, 1}, dimension{0, 10}))
+	assert.Equal(t, entries[1:3], result)
+
+	result = tree.Query(constructMockInterval(dimension{2, 2}, dimension{0, 10}))
+	assert.Equal(t, entries[3:], result)
+}
+
+func TestInsertClampSecondDimension(t *testing.T) {
+	tree, entries := constructMultiDimensionalOrderedTree(4)
+
+	modified, overwritten := tree.InsertAtDimensionClamp(2, 1, -5)
+	assert.Len(t, overwritten, 0)
+	assert.Equal(t, entries[2:], modified)
+	assert.Equal(t, uint64(4), tree.Len())
+
+	result := tree.Query(constructMockInterval(dimension{0, 10}, dimension{1, 1}))
+	assert.Equal(t, entries[1:], result)
+
+	result = tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 0}))
+	assert.Equal(t, entries[:1], result)
+}
+
+func TestInsertClampOverwrites(t *testing.T) {
+	tree := newOrderedTree(2)
+	e1 := constructMockEntry(1, 0, 5)
+	e2 := constructMockEntry(2, 1, 5)
+	e3 := constructMockEntry(3, 2, 5)
+	e4 := constructMockEntry(4, 2, 6)
+	e5 := constructMockEntry(5, 5, 5)
+	tree.Add(e1, e2, e3, e4, e5)
+
+	modified, overwritten := tree.InsertAtDimensionClamp(1, 1, -3)
+	assert.Equal(t, Entries{e2}, overwritten)
+	assert.Equal(t, Entries{e3, e4, e5}, modified)
+	assert.Equal(t, uint64(4), tree.Len())
+
+	result := tree.Query(constructMockInterval(dimension{1, 1}, dimension{0, 10}))
+	assert.Equal(t, Entries{e3, e4}, result)
+
+	result = tree.Query(constructMockInterval(dimension{0, 0}, dimension{0, 10}))
+	assert.Equal(t, Entries{e1}, result)
+
+	result = tree.Query(constructMockInterval(dimension{1, 1}, dimension{5, 5}))
+	assert.Equal(t, Entries{e3}, result)
+}
+
+func TestInsertClampOntoUnmoved(t *testing.T) {
+	tree := newOrderedTree(2)
+	e1 := constructMockEntry(1, 1, 4)
+	e2 := constructMockEntry(2, 1, 5)
+	e3 := constructMockEntry(3, 2, 5)
+	e4 := constructMockEntry(4, 3, 6)
+	tree.Add(e1, e2, e3, e4)
+
+	modified, overwritten := tree.InsertAtDimensionClamp(1, 1, -2)
+	assert.Equal(t, Entries{e2}, overwritten)
+	assert.Equal(t, Entries{e3, e4}, modified)
+	assert.Equal(t, uint64(3), tree.Len())
+
+	result := tree.Query(constructMockInterval(dimension{1, 1}, dimension{0, 10}))
+	assert.Equal(t, Entries{e1, e3, e4}, result)
+}
+
+func TestInsertClampOverwritesLastDimension(t *testing.T) {
+	tree := newOrderedTree(2)
+	e1 := constructMockEntry(1, 0, 1)
+	e2 := constructMockEntry(2, 0, 2)
+	e3 := constructMockEntry(3, 0, 3)
+	tree.Add(e1, e2, e3)
+
+	modified, overwritten := tree.InsertAtDimensionClamp(2, 1, -10)
+	assert.Equal(t, Entries{e1, e2}, overwritten)
+	assert.Equal(t, Entries{e3}, modified)
+	assert.Equal(t, uint64(1), tree.Len())
+
+	result := tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10}))
+	assert.Equal(t, Entries{e3}, result)
+}
+
+func TestInsertClampPositive(t *testing.T) {
+	tree, entries := constructMultiDimensionalOrderedTree(3)
+
+	modified, overwritten := tree.InsertAtDimensionClamp(1, 1, 1)
+	assert.Len(t, overwritten, 0)
+	assert.Equal(t, entries[1:], modified)
+
+	result := tree.Query(constructMockInterval(dimension{2, 10}, dimension{0, 10}))
+	assert.Equal(t, entries[1:], result)
+}
+
+func TestInsertClampInvalid(t *testing.T) {
+	tree, entries := constructMultiDimensionalOrderedTree(3)
+
+	modified, overwritten := tree.InsertAtDimensionClamp(3, 1, -1)
+	assert.Len(t, modified, 0)
+	assert.Len(t, overwritten, 0)
+
+	modified, overwritten = tree.InsertAtDimensionClamp(1, 1, 0)
+	assert.Len(t, modified, 0)
+	assert.Len(t, overwritten, 0)
+
+	modified, overwritten = tree.InsertAtDimensionClamp(1, 10, -1)
+	assert.Len(t, modified, 0)
+	assert.Len(t, overwritten, 0)
+
+	result := tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10}))
+	assert.Equal(t, entries, result)
+}
+
+func TestHistogram(t *testing.T) {
+	tree, _ := constructMultiDimensionalOrderedTree(25)
+	tree.Add(constructMockEntry(100, -1, 3), constructMockEntry(101, -10, 4))
+
+	assert.Equal(t, map[int64]uint64{-10: 2, 0: 10, 10: 10, 20: 5}, tree.Histogram(1, 10))
+	assert.Equal(t, map[int64]uint64{0: 12, 10: 10, 20: 5}, tree.Histogram(2, 10))
+	assert.Equal(t, uint64(27), tree.Histogram(1, 1000)[0]+tree.Histogram(1, 1000)[-1000])
+}
+
+func TestHistogramInvalid(t *testing.T) {
+	tree, _ := constructMultiDimensionalOrderedTree(3)
+
+	assert.Len(t, tree.Histogram(0, 10), 0)
+	assert.Len(t, tree.Histogram(3, 10), 0)
+	assert.Len(t, tree.Histogram(1, 0), 0)
+	assert.Len(t, newOrderedTree(2).Histogram(1, 10), 0)
+}
+
+func BenchmarkHistogram(b *testing.B) {
+	numItems := uint64(100000)
+
+	tree, _ := constructMultiDimensionalOrderedTree(numItems)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		tree.Histogram(2, 100)
+	}
+}
+
 func BenchmarkInsertFirstDimension(b *testing.B) {
 	numItems := uint64(100000)
 
@@ -345,6 +345,35 @@ func (rt *skipListRT) Query(interval rangetree.Interval) rangetree.Entries {
 	return entries
 }
 
+// QueryLimit will return a list of at most limit entries that fall
+// within the provided interval.  Entries are ordered by their value at
+// the first dimension, then the second, and so on.
+func (rt *skipListRT) QueryLimit(interval rangetree.Interval, limit int) rangetree.Entries {
+	entries := make(rangetree.Entries, 0, 100)
+	if limit < 1 {
+		return entries
+	}
+
+	rt.apply(rt.top, 0, interval, func(e rangetree.Entry) bool {
+		entries = append(entries, e)
+		return len(entries) < limit
+	})
+
+	return entries
+}
+
+// QueryReverse will return a list of entries that fall within the
+// provided interval in descending order.  The skip list can only be
+// iterated forward, so this reverses the results of Query.
+func (rt *skipListRT) QueryReverse(interval rangetree.Interval) rangetree.Entries {
+	entries := rt.Query(interval)
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries
+}
+
 func (rt *skipListRT) flatten(sl *skip.SkipList, dimension uint64, entries *rangetree.Entries) {
 	lastDimension := isLastDimension(dimension, rt.dimensions)
 	for iter := sl.Iter(skipEntry(0)); iter.Next(); {
@@ -356,6 +385,51 @@ func (rt *skipListRT) flatten(sl *skip.SkipList, dimension uint64, entries *rang
 	}
 }
 
+// histogram adds a count for every entry in the provided list to the
+// bucket of its value at histDimension.
+func (rt *skipListRT) histogram(sl *skip.SkipList, dimension, histDimension uint64,
+	value, bucketSize int64, hist map[int64]uint64) {
+
+	lastDimension := isLastDimension(dimension, rt.dimensions)
+	for iter := sl.Iter(skipEntry(0)); iter.Next(); {
+		e := iter.Value()
+		if dimension == histDimension {
+			value = int64(e.(keyed).key())
+		}
+
+		if lastDimension {
+			hist[bucket(value, bucketSize)]++
+			continue
+		}
+
+		rt.histogram(e.(*dimensionalBundle).sl, dimension+1, histDimension,
+			value, bucketSize, hist,
+		)
+	}
+}
+
+// bucket returns floor(value/size)*size.
+func bucket(value, size int64) int64 {
+	b := value / size * size
+	if b > value { // division truncates towards zero
+		b -= size
+	}
+
+	return b
+}
+
+// Histogram counts the entries in the tree by fixed width buckets
+// of the values at the provided dimension in a single pass.
+func (rt *skipListRT) Histogram(dimension uint64, bucketSize int64) map[int64]uint64 {
+	hist := make(map[int64]uint64)
+	if dimension >= rt.dimensions || bucketSize < 1 {
+		return hist
+	}
+
+	rt.histogram(rt.top, 0, dimension, 0, bucketSize, hist)
+	return hist
+}
+
 func (rt *skipListRT) insert(sl *skip.SkipList, dimension, insertDimension uint64,
 	index, number int64, deleted, affected *rangetree.Entries) {
 
@@ -432,6 +506,150 @@ func (rt *skipListRT) InsertAtDimension(dimension uint64,
 	return affected, deleted
 }
 
+// insertClamp is like insert except that bundles shifted below index
+// are placed at index and merged, rather than deleted.  Only the
+// entries that actually move are added to affected.
+func (rt *skipListRT) insertClamp(sl *skip.SkipList, dimension, insertDimension uint64,
+	index, number int64, overwritten, affected *rangetree.Entries) {
+
+	lastDimension := isLastDimension(dimension, rt.dimensions)
+	if dimension != insertDimension {
+		iter := sl.Iter(skipEntry(0))
+		for iter.Next() {
+			rt.insertClamp(iter.Value().(*dimensionalBundle).sl, dimension+1,
+				insertDimension, index, number, overwritten, affected,
+			)
+		}
+		return
+	}
+
+	// collect first, keys can't be changed while iterating as
+	// clamped bundles would end up out of order
+	var shifted common.Comparators
+	iter := sl.Iter(skipEntry(index))
+	for iter.Next() {
+		shifted = append(shifted, iter.Value())
+	}
+
+	// a bundle at index is clamped back to where it is, so it and
+	// anything beneath it stays put
+	var unmoved common.Comparator
+	if len(shifted) > 0 && number < 0 && int64(shifted[0].(keyed).key()) == index {
+		unmoved, shifted = shifted[0], shifted[1:]
+	}
+
+	if len(shifted) == 0 {
+		return
+	}
+
+	// every bundle up to index-number ends up at index, these are
+	// merged into the first one in order so later ones overwrite
+	clamped := int64(shifted[0].(keyed).key())+number <= index
+	var collapsed common.Comparators
+	for _, e := range shifted[1:] {
+		if int64(e.(keyed).key())+number > index {
+			break
+		}
+		collapsed = append(collapsed, e)
+	}
+
+	if len(collapsed) > 0 {
+		sl.Delete(collapsed...)
+	}
+
+	if lastDimension && unmoved != nil && clamped {
+		// the unmoved bundle started lower, so it is overwritten
+		*overwritten = append(*overwritten, unmoved.(*lastBundle).entry)
+		sl.Delete(unmoved)
+	}
+
+	target := shifted[0]
+	for _, e := range collapsed {
+		if lastDimension {
+			*overwritten = append(*overwritten, target.(*lastBundle).entry)
+			target.(*lastBundle).entry = e.(*lastBundle).entry
+			continue
+		}
+		rt.merge(target.(*dimensionalBundle).sl, e.(*dimensionalBundle).sl,
+			dimension+1, overwritten,
+		)
+	}
+
+	moving := append(shifted[:1], shifted[1+len(collapsed):]...)
+	if !lastDimension && unmoved != nil && clamped {
+		// the clamped bundle is merged into the unmoved one, every
+		// entry in it has moved and overwrites any at the same point
+		rt.flatten(target.(*dimensionalBundle).sl, dimension+1, affected)
+		sl.Delete(target)
+		rt.merge(unmoved.(*dimensionalBundle).sl, target.(*dimensionalBundle).sl,
+			dimension+1, overwritten,
+		)
+		moving = moving[1:]
+	}
+
+	for _, e := range moving {
+		value := int64(e.(keyed).key()) + number
+		if value < index {
+			value = index
+		}
+
+		if lastDimension {
+			e.(*lastBundle).id = uint64(value)
+			*affected = append(*affected, e.(*lastBundle).entry)
+		} else {
+			e.(*dimensionalBundle).id = uint64(value)
+			rt.flatten(e.(*dimensionalBundle).sl, dimension+1, affected)
+		}
+	}
+}
+
+// merge inserts the bundles in src into dst.  Any entries in dst that
+// are replaced by an entry in src are added to overwritten.
+func (rt *skipListRT) merge(dst, src *skip.SkipList, dimension uint64,
+	overwritten *rangetree.Entries) {
+
+	lastDimension := isLastDimension(dimension, rt.dimensions)
+	iter := src.Iter(skipEntry(0))
+	for iter.Next() {
+		e := iter.Value()
+		if lastDimension {
+			if old := dst.Insert(e)[0]; old != nil {
+				*overwritten = append(*overwritten, old.(*lastBundle).entry)
+			}
+			continue
+		}
+
+		existing := dst.Get(e)[0]
+		if existing == nil {
+			dst.Insert(e)
+			continue
+		}
+
+		rt.merge(existing.(*dimensionalBundle).sl, e.(*dimensionalBundle).sl,
+			dimension+1, overwritten,
+		)
+	}
+}
+
+// InsertAtDimensionClamp is like InsertAtDimension except that entries
+// shifted below index are clamped to index instead of being deleted.
+// Returned are the entries that were moved and the entries that were
+// overwritten because they were clamped onto the same point as another.
+func (rt *skipListRT) InsertAtDimensionClamp(dimension uint64,
+	index, number int64) (rangetree.Entries, rangetree.Entries) {
+
+	if dimension >= rt.dimensions || number == 0 {
+		return rangetree.Entries{}, rangetree.Entries{}
+	}
+
+	affected := make(rangetree.Entries, 0, 100)
+	overwritten := make(rangetree.Entries, 0, 10)
+
+	rt.insertClamp(rt.top, 0, dimension, index, number, &overwritten, &affected)
+	rt.number -= uint64(len(overwritten))
+	return affected, overwritten
+}
+
 func new(dimensions uint64) *skipListRT {
 	sl := &skipListRT{}
 	sl.init(dimensions)
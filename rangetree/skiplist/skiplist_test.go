@@ -176,6 +176,38 @@ func TestRTMultiDimensionQuery(t *testing.T) {
 	assert.Equal(t, rangetree.Entries{m1}, result)
 }
 
+func TestRTQueryLimit(t *testing.T) {
+	rt := new(2)
+	m1 := newMockEntry(3, 3)
+	m2 := newMockEntry(6, 6)
+	m3 := newMockEntry(9, 9)
+	rt.Add(m1, m2, m3)
+
+	iv := newMockInterval([]int64{0, 0}, []int64{10, 10})
+	result := rt.QueryLimit(iv, 2)
+	assert.Equal(t, rangetree.Entries{m1, m2}, result)
+
+	result = rt.QueryLimit(iv, 10)
+	assert.Equal(t, rangetree.Entries{m1, m2, m3}, result)
+
+	result = rt.QueryLimit(iv, 0)
+	assert.Len(t, result, 0)
+}
+
+func TestRTQueryReverse(t *testing.T) {
+	rt := new(2)
+	m1 := newMockEntry(3, 3)
+	m2 := newMockEntry(6, 6)
+	m3 := newMockEntry(9, 9)
+	rt.Add(m1, m2, m3)
+
+	result := rt.QueryReverse(newMockInterval([]int64{0, 0}, []int64{10, 10}))
+	assert.Equal(t, rangetree.Entries{m3, m2, m1}, result)
+
+	result = rt.QueryReverse(newMockInterval([]int64{4, 0}, []int64{7, 10}))
+	assert.Equal(t, rangetree.Entries{m2}, result)
+}
+
 func TestRTSingleDimensionInsert(t *testing.T) {
 	rt := new(1)
 	m1 := newMockEntry(3)
@@ -306,6 +338,103 @@ func TestRTInsertZero(t *testing.T) {
 	assert.Equal(t, rangetree.Entries{m1}, rt.Get(m1))
 }
 
+func TestRTInsertClamp(t *testing.T) {
+	rt := new(2)
+	m1 := newMockEntry(3, 3)
+	m2 := newMockEntry(6, 6)
+	m3 := newMockEntry(9, 9)
+	rt.Add(m1, m2, m3)
+
+	// m2 is clamped back to where it is, so it has not moved
+	affected, overwritten := rt.InsertAtDimensionClamp(1, 6, -2)
+	assert.Equal(t, rangetree.Entries{m3}, affected)
+	assert.Len(t, overwritten, 0)
+	assert.Equal(t, uint64(3), rt.Len())
+
+	e2 := newMockEntry(6, 6)
+	e3 := newMockEntry(9, 7)
+	assert.Equal(t, rangetree.Entries{m2, m3}, rt.Get(e2, e3))
+}
+
+func TestRTInsertClampOverwrites(t *testing.T) {
+	rt := new(2)
+	m1 := newMockEntry(3, 3)
+	m2 := newMockEntry(6, 3)
+	m3 := newMockEntry(7, 3)
+	m4 := newMockEntry(7, 4)
+	m5 := newMockEntry(9, 3)
+	rt.Add(m1, m2, m3, m4, m5)
+
+	affected, overwritten := rt.InsertAtDimensionClamp(0, 6, -2)
+	assert.Equal(t, rangetree.Entries{m3, m4, m5}, affected)
+	assert.Equal(t, rangetree.Entries{m2}, overwritten)
+	assert.Equal(t, uint64(4), rt.Len())
+
+	e3 := newMockEntry(6, 3)
+	e4 := newMockEntry(6, 4)
+	e5 := newMockEntry(7, 3)
+	assert.Equal(t, rangetree.Entries{m1, m3, m4, m5}, rt.Get(m1, e3, e4, e5))
+	assert.Equal(t, rangetree.Entries{nil}, rt.Get(newMockEntry(9, 3)))
+}
+
+func TestRTInsertClampOntoUnmoved(t *testing.T) {
+	rt := new(2)
+	m1 := newMockEntry(6, 2)
+	m2 := newMockEntry(6, 3)
+	m3 := newMockEntry(7, 3)
+	m4 := newMockEntry(8, 4)
+	rt.Add(m1, m2, m3, m4)
+
+	affected, overwritten := rt.InsertAtDimensionClamp(0, 6, -2)
+	assert.Equal(t, rangetree.Entries{m3, m4}, affected)
+	assert.Equal(t, rangetree.Entries{m2}, overwritten)
+	assert.Equal(t, uint64(3), rt.Len())
+	assert.Equal(t, rangetree.Entries{m1, m3, m4},
+		rt.Get(m1, newMockEntry(6, 3), newMockEntry(6, 4)),
+	)
+}
+
+func TestRTInsertClampOverwritesLastDimension(t *testing.T) {
+	rt := new(2)
+	m1 := newMockEntry(3, 3)
+	m2 := newMockEntry(3, 4)
+	m3 := newMockEntry(3, 5)
+	rt.Add(m1, m2, m3)
+
+	affected, overwritten := rt.InsertAtDimensionClamp(1, 3, -5)
+	assert.Equal(t, rangetree.Entries{m3}, affected)
+	assert.Equal(t, rangetree.Entries{m1, m2}, overwritten)
+	assert.Equal(t, uint64(1), rt.Len())
+	assert.Equal(t, rangetree.Entries{m3}, rt.Get(m1))
+}
+
+func TestRTInsertClampInvalid(t *testing.T) {
+	rt := new(2)
+	m1 := newMockEntry(3, 3)
+	rt.Add(m1)
+
+	affected, overwritten := rt.InsertAtDimensionClamp(4, 0, -1)
+	assert.Len(t, affected, 0)
+	assert.Len(t, overwritten, 0)
+
+	affected, overwritten = rt.InsertAtDimensionClamp(1, 0, 0)
+	assert.Len(t, affected, 0)
+	assert.Len(t, overwritten, 0)
+	assert.Equal(t, rangetree.Entries{m1}, rt.Get(m1))
+}
+
+func TestRTHistogram(t *testing.T) {
+	rt := new(2)
+	rt.Add(generateMultiDimensionalEntries(25)...)
+	rt.Add(newMockEntry(-1, 3), newMockEntry(-10, 4))
+
+	assert.Equal(t, map[int64]uint64{-10: 2, 0: 10, 10: 10, 20: 5}, rt.Histogram(0, 10))
+	assert.Equal(t, map[int64]uint64{0: 12, 10: 10, 20: 5}, rt.Histogram(1, 10))
+
+	assert.Len(t, rt.Histogram(2, 10), 0)
+	assert.Len(t, rt.Histogram(0, 0), 0)
+}
+
 func BenchmarkMultiDimensionInsert(b *testing.B) {
 	numItems := b.N
 	rt := new(2)
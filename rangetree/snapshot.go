@@ -0,0 +1,53 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import "sync/atomic"
+
+// SnapshotHolder publishes the latest version of an immutable tree to
+// any number of goroutines.  As trees are never modified, a tree that
+// has been loaded stays consistent while later versions are stored.
+type SnapshotHolder struct {
+	tree atomic.Pointer[ImmutableRangeTree]
+}
+
+// Load returns the latest version of the tree.
+func (sh *SnapshotHolder) Load() *ImmutableRangeTree {
+	return sh.tree.Load()
+}
+
+// Update stores the tree that fn returns for the latest version of the
+// tree and returns it.  If another update stores a tree first, fn is
+// called again with that tree, so fn may be called more than once and
+// should do nothing but build the new tree.
+func (sh *SnapshotHolder) Update(fn func(*ImmutableRangeTree) *ImmutableRangeTree) *ImmutableRangeTree {
+	for {
+		old := sh.tree.Load()
+		tree := fn(old)
+		if sh.tree.CompareAndSwap(old, tree) {
+			return tree
+		}
+	}
+}
+
+// NewSnapshotHolder returns a holder whose latest version is the
+// provided tree.
+func NewSnapshotHolder(tree *ImmutableRangeTree) *SnapshotHolder {
+	sh := &SnapshotHolder{}
+	sh.tree.Store(tree)
+	return sh
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotHolder(t *testing.T) {
+	tree := NewImmutableRangeTree(2)
+	sh := NewSnapshotHolder(tree)
+	assert.Equal(t, tree, sh.Load())
+
+	entry := constructMockEntry(0, 1, 1)
+	updated := sh.Update(func(tree *ImmutableRangeTree) *ImmutableRangeTree {
+		return tree.Add(entry)
+	})
+	assert.Equal(t, updated, sh.Load())
+	assert.Equal(t, uint64(1), sh.Load().Len())
+	assert.Equal(t, uint64(0), tree.Len())
+}
+
+func TestSnapshotHolderConcurrentUpdates(t *testing.T) {
+	sh := NewSnapshotHolder(NewImmutableRangeTree(2))
+	iv := constructMockInterval(dimension{0, 1000}, dimension{0, 1000})
+	writers, perWriter := 4, 50
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			tree := sh.Load()
+			assert.Equal(t, int(tree.Len()), len(tree.Query(iv)))
+		}
+	}()
+
+	var writersWG sync.WaitGroup
+	writersWG.Add(writers)
+	for i := 0; i < writers; i++ {
+		go func(i int) {
+			defer writersWG.Done()
+			for j := 0; j < perWriter; j++ {
+				entry := constructMockEntry(uint64(i*perWriter+j), int64(i), int64(j))
+				sh.Update(func(tree *ImmutableRangeTree) *ImmutableRangeTree {
+					return tree.Add(entry)
+				})
+			}
+		}(i)
+	}
+
+	writersWG.Wait()
+	close(done)
+	wg.Wait()
+
+	assert.Equal(t, uint64(writers*perWriter), sh.Load().Len())
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import "sort"
+
+// typedNode is a node of an ImmutableTypedRangeTree.  Like node, only
+// nodes at the last dimension hold an entry and only the others hold
+// a list of nodes.
+type typedNode[T any] struct {
+	value int64
+	entry T
+	nodes typedNodes[T]
+}
+
+// typedNodes is an ordered list of typed nodes with no duplicate
+// values.
+type typedNodes[T any] []*typedNode[T]
+
+func (nodes typedNodes[T]) search(value int64) int {
+	return sort.Search(
+		len(nodes),
+		func(i int) bool { return nodes[i].value >= value },
+	)
+}
+
+func (nodes *typedNodes[T]) insertAt(i int, n *typedNode[T]) {
+	*nodes = append(*nodes, nil)
+	copy((*nodes)[i+1:], (*nodes)[i:])
+	(*nodes)[i] = n
+}
+
+// without returns a copy of nodes without the node at the provided
+// index.
+func (nodes typedNodes[T]) without(i int) typedNodes[T] {
+	cp := make(typedNodes[T], 0, len(nodes)-1)
+	cp = append(cp, nodes[:i]...)
+	return append(cp, nodes[i+1:]...)
+}
+
+// ImmutableTypedRangeTree is an immutable range tree that holds entries
+// of type T directly rather than as Entry interfaces, so no entry is
+// boxed and reading a coordinate is a call of the function the tree was
+// constructed with rather than an interface call.  Adds, deletes and
+// queries behave as those of ImmutableRangeTree, and like it every
+// change returns a new tree that shares unchanged nodes with this one.
+// It has its own name as a generic type cannot share the name of
+// ImmutableRangeTree within the package.
+type ImmutableTypedRangeTree[T any] struct {
+	number     uint64
+	top        typedNodes[T]
+	dimensions uint64
+	valueAt    func(entry T, dimension uint64) int64
+}
+
+// add adds the entry beneath the provided nodes, which must already be
+// a copy, copying each node on the way down that is not in copied.
+func (irt *ImmutableTypedRangeTree[T]) add(nodes *typedNodes[T],
+	copied map[*typedNode[T]]bool, entry T, added *uint64) {
+
+	list := nodes
+	for i := uint64(1); i <= irt.dimensions; i++ {
+		value := irt.valueAt(entry, i)
+		index := list.search(value)
+		found := index < len(*list) && (*list)[index].value == value
+
+		if isLastDimension(irt.dimensions, i) {
+			n := &typedNode[T]{value: value, entry: entry}
+			if found {
+				(*list)[index] = n
+			} else {
+				list.insertAt(index, n)
+				*added++
+			}
+			return
+		}
+
+		var n *typedNode[T]
+		switch {
+		case !found:
+			n = &typedNode[T]{value: value, nodes: make(typedNodes[T], 0, 10)}
+			list.insertAt(index, n)
+		case !copied[(*list)[index]]:
+			previous := (*list)[index]
+			n = &typedNode[T]{value: value, nodes: make(typedNodes[T], len(previous.nodes))}
+			copy(n.nodes, previous.nodes)
+			(*list)[index] = n
+		default:
+			n = (*list)[index]
+		}
+		copied[n] = true
+		list = &n.nodes
+	}
+}
+
+// Add will add the provided entries into the tree and return a new tree
+// with those entries added.  An entry at the same point as one already
+// in the tree replaces it.
+func (irt *ImmutableTypedRangeTree[T]) Add(entries ...T) *ImmutableTypedRangeTree[T] {
+	if len(entries) == 0 {
+		return irt
+	}
+
+	copied := make(map[*typedNode[T]]bool)
+	top := make(typedNodes[T], len(irt.top))
+	copy(top, irt.top)
+	added := uint64(0)
+	for _, entry := range entries {
+		irt.add(&top, copied, entry, &added)
+	}
+
+	tree := irt.empty()
+	tree.top = top
+	tree.number = irt.number + added
+	return tree
+}
+
+// delete returns list without the entry at the point of the provided
+// entry and a bool indicating if there was one.  Nodes left without
+// any entries are removed.
+func (irt *ImmutableTypedRangeTree[T]) delete(list typedNodes[T], entry T,
+	dimension uint64) (typedNodes[T], bool) {
+
+	value := irt.valueAt(entry, dimension)
+	i := list.search(value)
+	if i == len(list) || list[i].value != value { // there's nothing to delete
+		return list, false
+	}
+
+	if isLastDimension(irt.dimensions, dimension) {
+		return list.without(i), true
+	}
+
+	nodes, ok := irt.delete(list[i].nodes, entry, dimension+1)
+	if !ok {
+		return list, false
+	}
+	if len(nodes) == 0 {
+		return list.without(i), true
+	}
+
+	cp := make(typedNodes[T], len(list))
+	copy(cp, list)
+	cp[i] = &typedNode[T]{value: value, nodes: nodes}
+	return cp, true
+}
+
+// Delete will remove the entries at the points of the provided entries
+// and return a new tree with those entries removed.
+func (irt *ImmutableTypedRangeTree[T]) Delete(entries ...T) *ImmutableTypedRangeTree[T] {
+	top := irt.top
+	deleted := uint64(0)
+	for _, entry := range entries {
+		var ok bool
+		if top, ok = irt.delete(top, entry, 1); ok {
+			deleted++
+		}
+	}
+
+	if deleted == 0 {
+		return irt
+	}
+
+	tree := irt.empty()
+	tree.top = top
+	tree.number = irt.number - deleted
+	return tree
+}
+
+func (irt *ImmutableTypedRangeTree[T]) apply(list typedNodes[T], interval Interval,
+	dimension uint64, fn func(T) bool) bool {
+
+	low, high := interval.LowAtDimension(dimension), interval.HighAtDimension(dimension)
+	lastDimension := isLastDimension(irt.dimensions, dimension)
+
+	for i := list.search(low); i < len(list) && list[i].value <= high; i++ {
+		if lastDimension {
+			if !fn(list[i].entry) {
+				return false
+			}
+		} else if !irt.apply(list[i].nodes, interval, dimension+1, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Apply will call the provided function with each entry that exists
+// within the provided range, in the order of Query.  Return false at
+// any time to stop.
+func (irt *ImmutableTypedRangeTree[T]) Apply(interval Interval, fn func(T) bool) {
+	irt.apply(irt.top, interval, 1, fn)
+}
+
+// Query will return a list of entries that fall within the provided
+// interval, ordered as Query of ImmutableRangeTree orders them.
+func (irt *ImmutableTypedRangeTree[T]) Query(interval Interval) []T {
+	var entries []T
+	irt.apply(irt.top, interval, 1, func(entry T) bool {
+		entries = append(entries, entry)
+		return true
+	})
+
+	return entries
+}
+
+// Len returns the number of items in this tree.
+func (irt *ImmutableTypedRangeTree[T]) Len() uint64 {
+	return irt.number
+}
+
+// empty returns a new empty tree like this one.
+func (irt *ImmutableTypedRangeTree[T]) empty() *ImmutableTypedRangeTree[T] {
+	return NewImmutableTypedRangeTree(irt.dimensions, irt.valueAt)
+}
+
+// NewImmutableTypedRangeTree returns a new empty tree with the provided
+// number of dimensions, which reads the value of an entry at a
+// dimension with valueAt.
+func NewImmutableTypedRangeTree[T any](dimensions uint64,
+	valueAt func(entry T, dimension uint64) int64) *ImmutableTypedRangeTree[T] {
+
+	return &ImmutableTypedRangeTree[T]{
+		dimensions: dimensions,
+		valueAt:    valueAt,
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2014 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rangetree
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cell struct {
+	row, column int64
+	name        string
+}
+
+func cellValueAt(c cell, dimension uint64) int64 {
+	if dimension == 1 {
+		return c.row
+	}
+	return c.column
+}
+
+func constructTypedTree(number int64) (*ImmutableTypedRangeTree[cell], []cell) {
+	cells := make([]cell, 0, number)
+	for i := int64(0); i < number; i++ {
+		cells = append(cells, cell{row: i, column: i})
+	}
+
+	return NewImmutableTypedRangeTree(2, cellValueAt).Add(cells...), cells
+}
+
+func TestTypedAdd(t *testing.T) {
+	tree, cells := constructTypedTree(3)
+	assert.Equal(t, uint64(3), tree.Len())
+
+	result := tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10}))
+	assert.Equal(t, cells, result)
+
+	result = tree.Query(constructMockInterval(dimension{1, 1}, dimension{0, 10}))
+	assert.Equal(t, cells[1:2], result)
+}
+
+func TestTypedAddOverwrite(t *testing.T) {
+	tree, cells := constructTypedTree(3)
+
+	replacement := cell{row: 1, column: 1, name: "replaced"}
+	tree1 := tree.Add(replacement, cell{row: 1, column: 5})
+	assert.Equal(t, uint64(4), tree1.Len())
+
+	result := tree1.Query(constructMockInterval(dimension{1, 1}, dimension{0, 10}))
+	assert.Equal(t, []cell{replacement, {row: 1, column: 5}}, result)
+
+	// the original tree is unchanged
+	result = tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10}))
+	assert.Equal(t, cells, result)
+	assert.Equal(t, uint64(3), tree.Len())
+}
+
+func TestTypedDelete(t *testing.T) {
+	tree, cells := constructTypedTree(3)
+	tree = tree.Add(cell{row: 1, column: 5})
+
+	tree1 := tree.Delete(cells[1], cell{row: 7, column: 7}, cell{row: 2, column: 4})
+	assert.Equal(t, uint64(3), tree1.Len())
+	result := tree1.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10}))
+	assert.Equal(t, []cell{cells[0], {row: 1, column: 5}, cells[2]}, result)
+
+	tree2 := tree1.Delete(cell{row: 1, column: 5}, cells[0])
+	assert.Equal(t, uint64(1), tree2.Len())
+	assert.Len(t, tree2.top, 1)
+	result = tree2.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10}))
+	assert.Equal(t, cells[2:], result)
+
+	assert.Equal(t, uint64(4), tree.Len())
+	assert.Len(t, tree.Query(constructMockInterval(dimension{0, 10}, dimension{0, 10})), 4)
+
+	assert.True(t, tree2 == tree2.Delete(cells[0]))
+}
+
+func TestTypedApply(t *testing.T) {
+	tree, cells := constructTypedTree(5)
+
+	var result []cell
+	tree.Apply(constructMockInterval(dimension{1, 10}, dimension{0, 10}), func(c cell) bool {
+		result = append(result, c)
+		return len(result) < 2
+	})
+	assert.Equal(t, cells[1:3], result)
+}
+
+func TestTypedMatchesImmutable(t *testing.T) {
+	typed := NewImmutableTypedRangeTree(2, cellValueAt)
+	tree := NewImmutableRangeTree(2)
+	for i := int64(0); i < 50; i++ {
+		row, column := (i*7)%11, (i*5)%13
+		typed = typed.Add(cell{row: row, column: column})
+		tree = tree.Add(constructMockEntry(uint64(i), row, column))
+	}
+
+	iv := constructMockInterval(dimension{2, 8}, dimension{3, 9})
+	entries := tree.Query(iv)
+	cells := typed.Query(iv)
+	assert.Equal(t, len(entries), len(cells))
+	for i, entry := range entries {
+		assert.Equal(t, entry.ValueAtDimension(1), cells[i].row)
+		assert.Equal(t, entry.ValueAtDimension(2), cells[i].column)
+	}
+	assert.Equal(t, tree.Len(), typed.Len())
+}
+
+func BenchmarkTypedQuery(b *testing.B) {
+	numItems := int64(1000)
+	tree, _ := constructTypedTree(numItems)
+	iv := constructMockInterval(dimension{0, numItems}, dimension{0, numItems})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Query(iv)
+	}
+}
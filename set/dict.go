@@ -27,7 +27,15 @@ uintptr much better than the generic interace{} key.
 
 package set
 
-import "sync"
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// ErrUnhashable is returned when unmarshaling a set from JSON that has
+// an array or object member, which cannot be held in a set.
+var ErrUnhashable = errors.New(`set: cannot hold a JSON array or object`)
 
 var pool = sync.Pool{}
 
@@ -139,6 +147,42 @@ func (set *Set) Dispose() {
 	pool.Put(set)
 }
 
+// MarshalJSON encodes the set as a JSON array of its items in no
+// particular order.  Every item must be something encoding/json can
+// marshal, otherwise the error it returns, such as a
+// *json.UnsupportedTypeError for a channel, is returned.
+func (set *Set) MarshalJSON() ([]byte, error) {
+	return json.Marshal(set.Flatten())
+}
+
+// UnmarshalJSON replaces the items in the set with the members of the
+// provided JSON array, dropping duplicates.  Members are decoded as
+// encoding/json decodes into an interface{}, so numbers become float64s.
+// ErrUnhashable is returned, and the set left unchanged, if a member is
+// an array or object.
+func (set *Set) UnmarshalJSON(data []byte) error {
+	var members []interface{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	items := make(map[interface{}]struct{}, len(members))
+	for _, member := range members {
+		switch member.(type) {
+		case []interface{}, map[string]interface{}:
+			return ErrUnhashable
+		}
+		items[member] = struct{}{}
+	}
+
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
+	set.items = items
+	set.flattened = nil
+	return nil
+}
+
 // New is the constructor for sets.  It will pull from a reuseable memory pool if it can.
 // Takes a list of items to initialize the set with.
 func New(items ...interface{}) *Set {
@@ -150,6 +194,20 @@ func New(items ...interface{}) *Set {
 	return set
 }
 
+// NewWithCapacity returns a new set whose backing map is sized to hold
+// at least n items without growing.  This avoids rehashing when the
+// number of items is known ahead of time.  The capacity is only a hint,
+// the set will grow past it as needed.
+func NewWithCapacity(n int) *Set {
+	if n < 0 {
+		n = 0
+	}
+
+	return &Set{
+		items: make(map[interface{}]struct{}, n),
+	}
+}
+
 func init() {
 	pool.New = func() interface{} {
 		return &Set{
@@ -17,6 +17,7 @@ limitations under the License.
 package set
 
 import (
+	"encoding/json"
 	"reflect"
 	"strconv"
 	"testing"
@@ -164,6 +165,65 @@ func TestClear(t *testing.T) {
 	}
 }
 
+func TestNewWithCapacity(t *testing.T) {
+	set := NewWithCapacity(2)
+	set.Add(`test`, `test1`, `test2`)
+
+	if set.Len() != 3 {
+		t.Errorf(`Expected len: %d, received: %d`, 3, set.Len())
+	}
+
+	if !set.All(`test`, `test1`, `test2`) {
+		t.Errorf(`Expected all items to exist.`)
+	}
+
+	set = NewWithCapacity(-1)
+	set.Add(`test`)
+	if !set.Exists(`test`) {
+		t.Errorf(`Expected item to exist.`)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	set := New()
+	set.Add(`a`)
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["a"]` {
+		t.Errorf(`Incorrect result returned: %s`, data)
+	}
+
+	set = New()
+	set.Add(make(chan int))
+	if _, err := json.Marshal(set); err == nil {
+		t.Errorf(`Expected an error marshaling a channel`)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	set := &Set{}
+	if err := json.Unmarshal([]byte(`["a", "b", "a", 1, true]`), set); err != nil {
+		t.Fatal(err)
+	}
+
+	if set.Len() != 4 {
+		t.Errorf(`Expected len: %d, received: %d`, 4, set.Len())
+	}
+	if !set.All(`a`, `b`, float64(1), true) {
+		t.Errorf(`Incorrect items: %+v`, set.Flatten())
+	}
+
+	if err := json.Unmarshal([]byte(`["c", [1]]`), set); err != ErrUnhashable {
+		t.Errorf(`Expected error: %v, received: %v`, ErrUnhashable, err)
+	}
+	if !set.Exists(`a`) || set.Exists(`c`) {
+		t.Errorf(`Set changed by a failed unmarshal: %+v`, set.Flatten())
+	}
+}
+
 func BenchmarkFlatten(b *testing.B) {
 	set := New()
 	for i := 0; i < 50; i++ {
@@ -206,3 +266,29 @@ func BenchmarkClear(b *testing.B) {
 		set.Clear()
 	}
 }
+
+func BenchmarkAddWithoutCapacity(b *testing.B) {
+	items := make([]interface{}, 10000)
+	for i := range items {
+		items[i] = i
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set := New()
+		set.Add(items...)
+	}
+}
+
+func BenchmarkAddWithCapacity(b *testing.B) {
+	items := make([]interface{}, 10000)
+	for i := range items {
+		items[i] = i
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		set := NewWithCapacity(len(items))
+		set.Add(items...)
+	}
+}
@@ -89,3 +89,71 @@ func (s Int64Slice) Insert(x int64) Int64Slice {
 	s[i] = x
 	return s
 }
+
+// DeleteRange removes every value in the inclusive range [low, high]
+// from this list and returns the number of values removed.  The values
+// after the range are moved down in place so the list stays sorted.
+// If this slice has not been sorted DeleteRange's behavior is
+// undefined.
+func (s *Int64Slice) DeleteRange(low, high int64) int {
+	if low > high {
+		return 0
+	}
+
+	i := s.Search(low)
+	rest := (*s)[i:]
+	j := i + sort.Search(len(rest), func(k int) bool {
+		return rest[k] > high
+	})
+	if i == j {
+		return 0
+	}
+
+	*s = append((*s)[:i], (*s)[j:]...)
+	return j - i
+}
+
+// ApplyRange calls fn with each value in the inclusive range
+// [low, high], in order, until fn returns false.  If this slice has not
+// been sorted ApplyRange's behavior is undefined.
+func (s Int64Slice) ApplyRange(low, high int64, fn func(int64) bool) {
+	for i := s.Search(low); i < len(s) && s[i] <= high; i++ {
+		if !fn(s[i]) {
+			return
+		}
+	}
+}
+
+// Merge returns a new sorted list containing the values of this list and
+// the other list along with the index in the returned list at which
+// each value of the other list can be found.  Like Insert, a value that
+// already exists is not added twice and its position is that of the
+// existing value.  Neither list is modified.  If either list has not
+// been sorted Merge's behavior is undefined.
+func (s Int64Slice) Merge(other Int64Slice) (Int64Slice, []int) {
+	merged := make(Int64Slice, 0, len(s)+len(other))
+	positions := make([]int, len(other))
+
+	i, j := 0, 0
+	for i < len(s) || j < len(other) {
+		switch {
+		case j == len(other) || (i < len(s) && s[i] < other[j]):
+			merged = appendUnique(merged, s[i])
+			i++
+		default:
+			merged = appendUnique(merged, other[j])
+			positions[j] = len(merged) - 1
+			j++
+		}
+	}
+
+	return merged, positions
+}
+
+func appendUnique(s Int64Slice, x int64) Int64Slice {
+	if len(s) > 0 && s[len(s)-1] == x {
+		return s
+	}
+
+	return append(s, x)
+}
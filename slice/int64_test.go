@@ -17,6 +17,7 @@ limitations under the License.
 package slice
 
 import (
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -52,3 +53,93 @@ func TestInsert(t *testing.T) {
 	s = s.Insert(7)
 	assert.Equal(t, Int64Slice{1, 2, 3, 6, 7}, s)
 }
+
+func TestDeleteRange(t *testing.T) {
+	s := Int64Slice{1, 3, 5, 7, 9}
+
+	assert.Equal(t, 2, s.DeleteRange(3, 6))
+	assert.Equal(t, Int64Slice{1, 7, 9}, s)
+
+	assert.Equal(t, 0, s.DeleteRange(2, 6))
+	assert.Equal(t, Int64Slice{1, 7, 9}, s)
+
+	assert.Equal(t, 2, s.DeleteRange(7, math.MaxInt64))
+	assert.Equal(t, Int64Slice{1}, s)
+
+	assert.Equal(t, 1, s.DeleteRange(math.MinInt64, 1))
+	assert.Len(t, s, 0)
+	assert.Equal(t, 0, s.DeleteRange(0, 10))
+}
+
+func TestDeleteRangeInvalid(t *testing.T) {
+	s := Int64Slice{1, 3, 5}
+
+	assert.Equal(t, 0, s.DeleteRange(5, 1))
+	assert.Equal(t, Int64Slice{1, 3, 5}, s)
+
+	assert.Equal(t, 1, s.DeleteRange(3, 3))
+	assert.Equal(t, Int64Slice{1, 5}, s)
+}
+
+func TestApplyRange(t *testing.T) {
+	s := Int64Slice{1, 3, 5, 7, 9}
+	var result Int64Slice
+	collect := func(x int64) bool {
+		result = append(result, x)
+		return true
+	}
+
+	s.ApplyRange(2, 7, collect)
+	assert.Equal(t, Int64Slice{3, 5, 7}, result)
+
+	result = nil
+	s.ApplyRange(math.MinInt64, math.MaxInt64, collect)
+	assert.Equal(t, s, result)
+
+	result = nil
+	s.ApplyRange(7, 2, collect)
+	s.ApplyRange(10, 20, collect)
+	assert.Len(t, result, 0)
+
+	result = nil
+	s.ApplyRange(0, 10, func(x int64) bool {
+		result = append(result, x)
+		return x < 5
+	})
+	assert.Equal(t, Int64Slice{1, 3, 5}, result)
+}
+
+func TestMerge(t *testing.T) {
+	s := Int64Slice{1, 3, 6}
+	other := Int64Slice{0, 3, 4, 7, 8}
+
+	merged, positions := s.Merge(other)
+	assert.Equal(t, Int64Slice{0, 1, 3, 4, 6, 7, 8}, merged)
+	assert.Equal(t, []int{0, 2, 3, 5, 6}, positions)
+	for i, p := range positions {
+		assert.Equal(t, other[i], merged[p])
+	}
+
+	// neither input is modified
+	assert.Equal(t, Int64Slice{1, 3, 6}, s)
+	assert.Equal(t, Int64Slice{0, 3, 4, 7, 8}, other)
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	s := Int64Slice{1, 3}
+	other := Int64Slice{3, 3, 5}
+
+	merged, positions := s.Merge(other)
+	assert.Equal(t, Int64Slice{1, 3, 5}, merged)
+	assert.Equal(t, []int{1, 1, 2}, positions)
+}
+
+func TestMergeEmpty(t *testing.T) {
+	merged, positions := Int64Slice{}.Merge(Int64Slice{2, 4})
+	assert.Equal(t, Int64Slice{2, 4}, merged)
+	assert.Equal(t, []int{0, 1}, positions)
+
+	merged, positions = Int64Slice{2, 4}.Merge(nil)
+	assert.Equal(t, Int64Slice{2, 4}, merged)
+	assert.Equal(t, []int{}, positions)
+}
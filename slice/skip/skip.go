@@ -299,6 +299,14 @@ func (sl *SkipList) Get(comparators ...common.Comparator) common.Comparators {
 	return result
 }
 
+// Contains returns a bool indicating if a value with the provided key
+// exists in the list.  Unlike Get, this does not allocate a result.
+// This is an O(log n) operation.
+func (sl *SkipList) Contains(cmp common.Comparator) bool {
+	n, _ := sl.search(cmp, nil, nil)
+	return n != nil && n.Compare(cmp) == 0
+}
+
 // GetWithPosition will retrieve the value with the provided key and
 // return the position of that value within the list.  Returns nil, 0
 // if an associated value could not be found.
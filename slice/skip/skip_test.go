@@ -159,6 +159,23 @@ func TestGetWithPosition(t *testing.T) {
 	assert.Equal(t, uint64(1), pos)
 }
 
+func TestContains(t *testing.T) {
+	m1 := newMockEntry(5)
+	m2 := newMockEntry(6)
+	sl := New(uint8(0))
+	assert.False(t, sl.Contains(m1))
+
+	sl.Insert(m1, m2)
+	assert.True(t, sl.Contains(m1))
+	assert.True(t, sl.Contains(m2))
+	assert.False(t, sl.Contains(newMockEntry(4)))
+	assert.False(t, sl.Contains(newMockEntry(7)))
+
+	sl.Delete(m1)
+	assert.False(t, sl.Contains(m1))
+	assert.True(t, sl.Contains(m2))
+}
+
 func TestReplaceAtPosition(t *testing.T) {
 	m1 := newMockEntry(5)
 	m2 := newMockEntry(6)
@@ -362,6 +379,20 @@ func BenchmarkGet(b *testing.B) {
 	}
 }
 
+func BenchmarkContains(b *testing.B) {
+	numItems := b.N
+	sl := New(uint64(0))
+
+	entries := generateMockEntries(numItems)
+	sl.Insert(entries...)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sl.Contains(entries[i%numItems])
+	}
+}
+
 func BenchmarkDelete(b *testing.B) {
 	numItems := b.N
 	sl := New(uint64(0))
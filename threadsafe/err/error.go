@@ -27,17 +27,57 @@ import "sync"
 // Error is a struct that holds an error and allows this error
 // to be set and retrieved in a threadsafe manner.
 type Error struct {
-	lock sync.RWMutex
-	err  error
+	lock     sync.RWMutex
+	err      error
+	first    error
+	onError  func(error)
+	notified bool
 }
 
 // Set will set the error of this structure to the provided
-// value.
+// value.  If this is the first non-nil error to be set, the callback
+// provided to OnError is called with it after the error is set.
 func (e *Error) Set(err error) {
 	e.lock.Lock()
-	defer e.lock.Unlock()
 
 	e.err = err
+	var fn func(error)
+	if err != nil && e.first == nil {
+		e.first = err
+		if e.onError != nil {
+			fn = e.onError
+			e.notified = true
+		}
+	}
+
+	e.lock.Unlock()
+
+	// called without the lock so fn may use this structure
+	if fn != nil {
+		fn(err)
+	}
+}
+
+// OnError sets a function to be called once with the first non-nil
+// error set on this structure, as soon as it is set.  If that error
+// has already been set, fn is called with it before OnError returns.
+// The function is called without any lock held, so it may call Set or
+// Get.  Subsequent errors do not call it again.
+func (e *Error) OnError(fn func(error)) {
+	e.lock.Lock()
+
+	e.onError = fn
+	first := e.first
+	notify := fn != nil && first != nil && !e.notified
+	if notify {
+		e.notified = true
+	}
+
+	e.lock.Unlock()
+
+	if notify {
+		fn(first)
+	}
 }
 
 // Get will return any error associated with this structure.
@@ -32,3 +32,41 @@ func TestGetSetError(t *testing.T) {
 
 	assert.Equal(t, err, e.Get())
 }
+
+func TestOnError(t *testing.T) {
+	e := New()
+	var received []error
+	e.OnError(func(err error) {
+		// the lock must not be held here
+		assert.Equal(t, err, e.Get())
+		received = append(received, err)
+	})
+
+	e.Set(nil)
+	assert.Len(t, received, 0)
+
+	err1, err2 := fmt.Errorf(`test1`), fmt.Errorf(`test2`)
+	e.Set(err1)
+	e.Set(err2)
+	assert.Equal(t, []error{err1}, received)
+	assert.Equal(t, err2, e.Get())
+}
+
+func TestOnErrorAfterSet(t *testing.T) {
+	e := New()
+	err := fmt.Errorf(`test`)
+	e.Set(err)
+
+	var received []error
+	e.OnError(func(err error) {
+		received = append(received, err)
+		e.Set(fmt.Errorf(`again`))
+	})
+	assert.Equal(t, []error{err}, received)
+
+	e.OnError(func(err error) {
+		received = append(received, err)
+	})
+	e.Set(fmt.Errorf(`later`))
+	assert.Len(t, received, 1)
+}
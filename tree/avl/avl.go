@@ -25,21 +25,38 @@ Time complexities:
 Space: O(n)
 Insert: O(log n)
 Delete: O(log n)
+DeleteRange: O(log n + k), where k is the number of entries removed
 Get: O(log n)
+RangeAggregate: O(log n), for a tree constructed with a Monoid
 
 The immutable version of the AVL tree is obviously going to be slower than
 the mutable version but should offer higher read availability.
+
+Every *Immutable is already a snapshot.  Insert, Delete and DeleteRange
+never modify the tree they are called on, they copy the O(log n) nodes on
+the path to the change and return a new tree that shares every other node
+with the old one.  To serve concurrent reads while writes continue, keep
+the current tree behind an atomic.Value or a mutex guarded pointer.
+Readers load the pointer once and read from that tree for as long as they
+like while a writer builds the next version and swaps the pointer.  Nodes
+are never mutated after being shared, so no locking is needed for reads
+and no read is ever affected by a later write.  A single writer, or
+writers serialized with a lock, must be used to avoid losing updates.
 */
 package avl
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
 // Immutable represents an immutable AVL tree.  This is acheived
 // by branch copying.
 type Immutable struct {
 	root   *node
 	number uint64
-	dummy  node // helper for inserts.
+	dummy  node   // helper for inserts.
+	monoid Monoid // nil unless aggregates are kept.
 }
 
 // copy returns a copy of this immutable tree with a copy
@@ -53,10 +70,86 @@ func (immutable *Immutable) copy() *Immutable {
 		root:   root,
 		number: immutable.number,
 		dummy:  *newNode(nil),
+		monoid: immutable.monoid,
 	}
 	return cp
 }
 
+// refresh recomputes the aggregates of the stale nodes of the subtree
+// rooted at n and returns the aggregate of the subtree.  Only nodes
+// created by this tree can be stale so the walk stops at shared nodes.
+func (immutable *Immutable) refresh(n *node) Aggregate {
+	if n == nil {
+		return immutable.monoid.Identity()
+	}
+
+	if n.stale {
+		n.aggregate = immutable.monoid.Combine(
+			immutable.monoid.Combine(
+				immutable.refresh(n.children[0]),
+				immutable.monoid.Value(n.entry),
+			),
+			immutable.refresh(n.children[1]),
+		)
+		n.stale = false
+	}
+
+	return n.aggregate
+}
+
+// refreshed refreshes the aggregates of this tree, if it keeps them,
+// and returns it.
+func (immutable *Immutable) refreshed() *Immutable {
+	if immutable.monoid != nil {
+		immutable.refresh(immutable.root)
+	}
+
+	return immutable
+}
+
+// RangeAggregate returns the combined aggregate of the entries between
+// low and high, inclusive, in O(log n).  Identity is returned if no
+// entries are in the range and nil is returned if this tree was not
+// constructed with a Monoid.
+func (immutable *Immutable) RangeAggregate(low, high Entry) Aggregate {
+	if immutable.monoid == nil {
+		return nil
+	}
+
+	if low.Compare(high) > 0 {
+		return immutable.monoid.Identity()
+	}
+
+	return immutable.rangeAggregate(immutable.root, low, high)
+}
+
+// rangeAggregate returns the aggregate of the entries of the subtree
+// rooted at n between low and high, where a nil bound is unbounded.
+// Once a node within the range is found each side is bounded only on
+// one end, so a single path is followed on each side.
+func (immutable *Immutable) rangeAggregate(n *node, low, high Entry) Aggregate {
+	for n != nil {
+		switch {
+		case low == nil && high == nil:
+			return n.aggregate
+		case low != nil && n.entry.Compare(low) < 0:
+			n = n.children[1]
+		case high != nil && n.entry.Compare(high) > 0:
+			n = n.children[0]
+		default:
+			return immutable.monoid.Combine(
+				immutable.monoid.Combine(
+					immutable.rangeAggregate(n.children[0], low, nil),
+					immutable.monoid.Value(n.entry),
+				),
+				immutable.rangeAggregate(n.children[1], nil, high),
+			)
+		}
+	}
+
+	return immutable.monoid.Identity()
+}
+
 func (immutable *Immutable) resetDummy() {
 	immutable.dummy.children[0], immutable.dummy.children[1] = nil, nil
 	immutable.dummy.balance = 0
@@ -101,6 +194,18 @@ func (immutable *Immutable) Len() uint64 {
 	return immutable.number
 }
 
+// AscendEach calls fn with every entry in the tree from smallest to
+// largest.  Return false from fn to stop the iteration.
+func (immutable *Immutable) AscendEach(fn func(Entry) bool) {
+	walkInOrder(immutable.root, 0, fn)
+}
+
+// DescendEach calls fn with every entry in the tree from largest to
+// smallest.  Return false from fn to stop the iteration.
+func (immutable *Immutable) DescendEach(fn func(Entry) bool) {
+	walkInOrder(immutable.root, 1, fn)
+}
+
 func (immutable *Immutable) insert(entry Entry) Entry {
 	// TODO: check cache to see if a node has already been copied.
 	if immutable.root == nil {
@@ -198,7 +303,7 @@ func (immutable *Immutable) Insert(entries ...Entry) (*Immutable, Entries) {
 		overwritten = append(overwritten, cp.insert(e))
 	}
 
-	return cp, overwritten
+	return cp.refreshed(), overwritten
 }
 
 func (immutable *Immutable) delete(entry Entry) Entry {
@@ -260,7 +365,9 @@ func (immutable *Immutable) delete(entry Entry) Entry {
 			immutable.root = it.children[dir]
 		}
 	} else { // climb up and set heirs
-		heir := it.children[1]
+		// the path to the heir is changed below so it is copied too
+		heir := it.children[1].copy()
+		it.children[1] = heir
 		dirs[top] = 1
 		cache[top] = it
 		top++
@@ -269,6 +376,7 @@ func (immutable *Immutable) delete(entry Entry) Entry {
 			dirs[top] = 0
 			cache[top] = heir
 			top++
+			heir.children[0] = heir.children[0].copy()
 			heir = heir.children[0]
 		}
 
@@ -322,7 +430,258 @@ func (immutable *Immutable) Delete(entries ...Entry) (*Immutable, Entries) {
 		deleted = append(deleted, cp.delete(e))
 	}
 
-	return cp, deleted
+	return cp.refreshed(), deleted
+}
+
+// DeleteRange will remove all entries between low and high, inclusive,
+// from this AVL tree and return a new tree and the removed entries in
+// ascending order.  The tree is split around the range and the remaining
+// halves are joined, so only O(log n) nodes are copied regardless of the
+// number of entries removed.
+func (immutable *Immutable) DeleteRange(low, high Entry) (*Immutable, Entries) {
+	if immutable.root == nil || low.Compare(high) > 0 {
+		return immutable, Entries{}
+	}
+
+	h := height(immutable.root)
+	left, hl, rest, hr := split(immutable.root, h, func(e Entry) bool {
+		return e.Compare(low) < 0
+	})
+	middle, _, right, hright := split(rest, hr, func(e Entry) bool {
+		return e.Compare(high) <= 0
+	})
+
+	deleted := make(Entries, 0)
+	deleted = appendInOrder(deleted, middle)
+	if len(deleted) == 0 {
+		return immutable, deleted
+	}
+
+	cp := &Immutable{number: immutable.number - uint64(len(deleted)), monoid: immutable.monoid}
+	cp.init()
+	cp.root, _ = join2(left, hl, right, hright)
+	return cp.refreshed(), deleted
+}
+
+// DeleteAll will remove the provided entries from a copy of this tree
+// and return the copy along with the entries that were actually
+// removed, in ascending order.  Entries that do not exist in the tree
+// are ignored.  The entries are sorted first so that a single walk of
+// the tree finds all of them, rebalancing each subtree once rather
+// than once per delete.
+func (immutable *Immutable) DeleteAll(entries ...Entry) (*Immutable, Entries) {
+	if immutable.root == nil || len(entries) == 0 {
+		return immutable, Entries{}
+	}
+
+	sorted := make(Entries, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Compare(sorted[j]) < 0
+	})
+
+	deleted := make(Entries, 0, len(entries))
+	root, _ := deleteSorted(immutable.root, height(immutable.root), sorted, &deleted)
+	if len(deleted) == 0 {
+		return immutable, deleted
+	}
+
+	cp := &Immutable{number: immutable.number - uint64(len(deleted)), monoid: immutable.monoid}
+	cp.init()
+	cp.root = root
+	return cp.refreshed(), deleted
+}
+
+// deleteSorted returns the subtree rooted at n without the provided
+// sorted entries, appending the entries removed to deleted.  Subtrees
+// holding none of the entries are shared rather than copied.
+func deleteSorted(n *node, h int, entries Entries, deleted *Entries) (*node, int) {
+	if n == nil || len(entries) == 0 {
+		return n, h
+	}
+
+	low := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Compare(n.entry) >= 0
+	})
+	high := low
+	for high < len(entries) && entries[high].Compare(n.entry) == 0 {
+		high++
+	}
+
+	hl, hr := childHeights(n, h)
+	left, hl := deleteSorted(n.children[0], hl, entries[:low], deleted)
+	if high > low {
+		*deleted = append(*deleted, n.entry)
+	}
+	right, hr := deleteSorted(n.children[1], hr, entries[high:], deleted)
+
+	if high > low {
+		return join2(left, hl, right, hr)
+	}
+	if left == n.children[0] && right == n.children[1] {
+		return n, h
+	}
+
+	return join(left, hl, n.entry, right, hr)
+}
+
+// height returns the height of the subtree rooted at n by following
+// the balance factors down the taller side.
+func height(n *node) int {
+	h := 0
+	for n != nil {
+		h++
+		n = n.children[intFromBool(n.balance > 0)]
+	}
+
+	return h
+}
+
+// childHeights returns the heights of the children of n given
+// the height of n.
+func childHeights(n *node, h int) (int, int) {
+	switch {
+	case n.balance < 0:
+		return h - 1, h - 2
+	case n.balance > 0:
+		return h - 2, h - 1
+	}
+
+	return h - 1, h - 1
+}
+
+// makeNode returns a new node with the provided children whose
+// heights must differ by at most 1.
+func makeNode(left *node, hl int, entry Entry, right *node, hr int) (*node, int) {
+	n := &node{
+		balance:  int8(hr - hl),
+		children: [2]*node{left, right},
+		entry:    entry,
+		stale:    true,
+	}
+	if hl > hr {
+		return n, hl + 1
+	}
+
+	return n, hr + 1
+}
+
+// buildNode returns a new balanced subtree from the provided children
+// whose heights may differ by at most 2, rotating where needed.  The
+// provided nodes are never modified.
+func buildNode(left *node, hl int, entry Entry, right *node, hr int) (*node, int) {
+	switch {
+	case hl-hr == 2:
+		hll, hlr := childHeights(left, hl)
+		if hll >= hlr {
+			r, h := makeNode(left.children[1], hlr, entry, right, hr)
+			return makeNode(left.children[0], hll, left.entry, r, h)
+		}
+		lr := left.children[1]
+		hlrl, hlrr := childHeights(lr, hlr)
+		l, h1 := makeNode(left.children[0], hll, left.entry, lr.children[0], hlrl)
+		r, h2 := makeNode(lr.children[1], hlrr, entry, right, hr)
+		return makeNode(l, h1, lr.entry, r, h2)
+	case hr-hl == 2:
+		hrl, hrr := childHeights(right, hr)
+		if hrr >= hrl {
+			l, h := makeNode(left, hl, entry, right.children[0], hrl)
+			return makeNode(l, h, right.entry, right.children[1], hrr)
+		}
+		rl := right.children[0]
+		hrll, hrlr := childHeights(rl, hrl)
+		l, h1 := makeNode(left, hl, entry, rl.children[0], hrll)
+		r, h2 := makeNode(rl.children[1], hrlr, right.entry, right.children[1], hrr)
+		return makeNode(l, h1, rl.entry, r, h2)
+	}
+
+	return makeNode(left, hl, entry, right, hr)
+}
+
+// join returns a balanced tree containing left, entry and right where
+// every entry in left is less than entry and every entry in right is
+// greater than entry.
+func join(left *node, hl int, entry Entry, right *node, hr int) (*node, int) {
+	switch {
+	case hl > hr+1:
+		hll, hlr := childHeights(left, hl)
+		r, h := join(left.children[1], hlr, entry, right, hr)
+		return buildNode(left.children[0], hll, left.entry, r, h)
+	case hr > hl+1:
+		hrl, hrr := childHeights(right, hr)
+		l, h := join(left, hl, entry, right.children[0], hrl)
+		return buildNode(l, h, right.entry, right.children[1], hrr)
+	}
+
+	return makeNode(left, hl, entry, right, hr)
+}
+
+// join2 is join without a separating entry.
+func join2(left *node, hl int, right *node, hr int) (*node, int) {
+	if left == nil {
+		return right, hr
+	}
+
+	left, hl, last := splitLast(left, hl)
+	return join(left, hl, last, right, hr)
+}
+
+// splitLast returns the subtree rooted at n without its greatest
+// entry along with that entry.
+func splitLast(n *node, h int) (*node, int, Entry) {
+	hl, hr := childHeights(n, h)
+	if n.children[1] == nil {
+		return n.children[0], hl, n.entry
+	}
+
+	r, hr, last := splitLast(n.children[1], hr)
+	l, h := buildNode(n.children[0], hl, n.entry, r, hr)
+	return l, h, last
+}
+
+// split divides the subtree rooted at n into two trees, the first
+// holding the entries for which isLeft returns true.  isLeft must be
+// true for some prefix of the ordered entries and false thereafter.
+func split(n *node, h int, isLeft func(Entry) bool) (*node, int, *node, int) {
+	if n == nil {
+		return nil, 0, nil, 0
+	}
+
+	hl, hr := childHeights(n, h)
+	if isLeft(n.entry) {
+		rl, hrl, rr, hrr := split(n.children[1], hr, isLeft)
+		l, h := join(n.children[0], hl, n.entry, rl, hrl)
+		return l, h, rr, hrr
+	}
+
+	ll, hll, lr, hlr := split(n.children[0], hl, isLeft)
+	r, h := join(lr, hlr, n.entry, n.children[1], hr)
+	return ll, hll, r, h
+}
+
+// appendInOrder appends the entries of the subtree rooted at n to
+// entries in ascending order.
+func appendInOrder(entries Entries, n *node) Entries {
+	if n == nil {
+		return entries
+	}
+
+	entries = appendInOrder(entries, n.children[0])
+	entries = append(entries, n.entry)
+	return appendInOrder(entries, n.children[1])
+}
+
+// walkInOrder calls fn with each entry of the subtree rooted at n until
+// fn returns false, which is returned.  The entries are visited in
+// ascending order if dir is 0 and descending order if dir is 1.
+func walkInOrder(n *node, dir int, fn func(Entry) bool) bool {
+	if n == nil {
+		return true
+	}
+
+	return walkInOrder(n.children[dir], dir, fn) &&
+		fn(n.entry) &&
+		walkInOrder(n.children[takeOpposite(dir)], dir, fn)
 }
 
 func insertBalance(root *node, dir int) *node {
@@ -359,6 +718,8 @@ func removeBalance(root *node, dir int, done *int) *node {
 		root.balance, n.balance = 0, 0
 		root = rotate(root, dir)
 	} else if n.balance == bal {
+		// the double rotation changes the child of n as well
+		n.children[dir] = n.children[dir].copy()
 		adjustBalance(root, takeOpposite(dir), int(-bal))
 		root = doubleRotate(root, dir)
 	} else {
@@ -430,6 +791,16 @@ func normalizeComparison(i int) int {
 	return -1
 }
 
+// NewAggregateImmutable allocates, initializes, and returns a new
+// immutable AVL tree that keeps the aggregate of every subtree using
+// the provided monoid, so RangeAggregate is O(log n).  Every tree
+// derived from the returned tree uses the same monoid.
+func NewAggregateImmutable(monoid Monoid) *Immutable {
+	immutable := NewImmutable()
+	immutable.monoid = monoid
+	return immutable
+}
+
 // NewImmutable allocates, initializes, and returns a new immutable
 // AVL tree.
 func NewImmutable() *Immutable {
@@ -17,6 +17,9 @@ limitations under the License.
 package avl
 
 import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -318,6 +321,290 @@ func TestAVLFails(t *testing.T) {
 	}
 }
 
+// checkBalance asserts that every balance factor in the subtree rooted
+// at n is accurate and bounded and returns the height of the subtree.
+func checkBalance(t *testing.T, n *node) int {
+	if n == nil {
+		return 0
+	}
+
+	hl, hr := checkBalance(t, n.children[0]), checkBalance(t, n.children[1])
+	assert.Equal(t, int8(hr-hl), n.balance)
+	assert.True(t, n.balance >= -1 && n.balance <= 1)
+	if hl > hr {
+		return hl + 1
+	}
+	return hr + 1
+}
+
+func TestAVLDeleteRange(t *testing.T) {
+	entries := generateMockEntries(1000)
+	i1 := NewImmutable()
+	i1, _ = i1.Insert(entries...)
+
+	i2, deleted := i1.DeleteRange(mockEntry(100), mockEntry(899))
+	assert.Equal(t, entries[100:900], deleted)
+	assert.Equal(t, uint64(200), i2.Len())
+	assert.Equal(t, uint64(1000), i1.Len())
+	assert.Equal(t, entries[:100], i2.Get(entries[:100]...))
+	assert.Equal(t, entries[900:], i2.Get(entries[900:]...))
+	assert.Equal(t, Entries{nil, nil}, i2.Get(mockEntry(100), mockEntry(899)))
+	assert.Equal(t, entries, i1.Get(entries...))
+	checkBalance(t, i1.root)
+	checkBalance(t, i2.root)
+
+	// the tree must still support regular operations
+	i3, _ := i2.Insert(mockEntry(500))
+	checkBalance(t, i3.root)
+	i3, _ = i3.Delete(entries[:50]...)
+	checkBalance(t, i3.root)
+	assert.Equal(t, uint64(151), i3.Len())
+}
+
+func TestAVLDeleteRangeEdges(t *testing.T) {
+	entries := generateMockEntries(100)
+	i1 := NewImmutable()
+	i1, _ = i1.Insert(entries...)
+
+	i2, deleted := i1.DeleteRange(mockEntry(-10), mockEntry(10))
+	assert.Equal(t, entries[:11], deleted)
+	assert.Equal(t, uint64(89), i2.Len())
+	checkBalance(t, i2.root)
+
+	i2, deleted = i1.DeleteRange(mockEntry(95), mockEntry(200))
+	assert.Equal(t, entries[95:], deleted)
+	checkBalance(t, i2.root)
+
+	i2, deleted = i1.DeleteRange(mockEntry(-10), mockEntry(200))
+	assert.Equal(t, entries, deleted)
+	assert.Equal(t, uint64(0), i2.Len())
+	assert.Nil(t, i2.root)
+
+	i2, deleted = i1.DeleteRange(mockEntry(200), mockEntry(300))
+	assert.Len(t, deleted, 0)
+	assert.True(t, i1 == i2)
+
+	i2, deleted = i1.DeleteRange(mockEntry(50), mockEntry(10))
+	assert.Len(t, deleted, 0)
+	assert.True(t, i1 == i2)
+
+	i2, deleted = NewImmutable().DeleteRange(mockEntry(0), mockEntry(10))
+	assert.Len(t, deleted, 0)
+	assert.Equal(t, uint64(0), i2.Len())
+}
+
+func TestAVLDeleteRangeRandom(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		i1 := NewImmutable()
+		keys := r.Perm(500)
+		for _, k := range keys {
+			i1, _ = i1.Insert(mockEntry(k))
+		}
+
+		low, high := r.Intn(500), r.Intn(500)
+		if low > high {
+			low, high = high, low
+		}
+		i2, deleted := i1.DeleteRange(mockEntry(low), mockEntry(high))
+		assert.Len(t, deleted, high-low+1)
+		assert.Equal(t, uint64(500-len(deleted)), i2.Len())
+		checkBalance(t, i2.root)
+		assert.Len(t, appendInOrder(nil, i2.root), 500-len(deleted))
+	}
+}
+
+func TestAVLDeleteAllKeys(t *testing.T) {
+	entries := generateMockEntries(1000)
+	i1, _ := NewImmutable().Insert(entries...)
+
+	i2, deleted := i1.DeleteAll(
+		mockEntry(900), mockEntry(5), mockEntry(5000), mockEntry(300), mockEntry(5),
+	)
+	assert.Equal(t, Entries{mockEntry(5), mockEntry(300), mockEntry(900)}, deleted)
+	assert.Equal(t, uint64(997), i2.Len())
+	assert.Equal(t, Entries{nil, nil, nil}, i2.Get(mockEntry(5), mockEntry(300), mockEntry(900)))
+	assert.Equal(t, uint64(1000), i1.Len())
+	assert.Equal(t, entries, i1.Get(entries...))
+	checkBalance(t, i1.root)
+	checkBalance(t, i2.root)
+}
+
+func TestAVLDeleteAllKeysEdges(t *testing.T) {
+	entries := generateMockEntries(100)
+	i1, _ := NewImmutable().Insert(entries...)
+
+	i2, deleted := i1.DeleteAll(entries...)
+	assert.Equal(t, entries, deleted)
+	assert.Equal(t, uint64(0), i2.Len())
+	assert.Nil(t, i2.root)
+
+	i2, deleted = i1.DeleteAll(mockEntry(200), mockEntry(-1))
+	assert.Len(t, deleted, 0)
+	assert.True(t, i1 == i2)
+
+	i2, deleted = i1.DeleteAll()
+	assert.Len(t, deleted, 0)
+	assert.True(t, i1 == i2)
+
+	i2, deleted = NewImmutable().DeleteAll(mockEntry(0))
+	assert.Len(t, deleted, 0)
+	assert.Equal(t, uint64(0), i2.Len())
+}
+
+func TestAVLDeleteAllKeysRandom(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		i1 := NewImmutable()
+		for _, k := range r.Perm(500) {
+			i1, _ = i1.Insert(mockEntry(k))
+		}
+
+		remove := make(Entries, 0, 250)
+		expected := make(Entries, 0, 500)
+		for k := 0; k < 500; k++ {
+			if r.Intn(2) == 0 {
+				remove = append(remove, mockEntry(k))
+			} else {
+				expected = append(expected, mockEntry(k))
+			}
+		}
+		r.Shuffle(len(remove), func(i, j int) {
+			remove[i], remove[j] = remove[j], remove[i]
+		})
+
+		i2, deleted := i1.DeleteAll(remove...)
+		assert.Len(t, deleted, len(remove))
+		assert.Equal(t, uint64(len(expected)), i2.Len())
+		assert.Equal(t, expected, appendInOrder(Entries{}, i2.root))
+		checkBalance(t, i2.root)
+		checkBalance(t, i1.root)
+		assert.Equal(t, uint64(500), i1.Len())
+
+		// the tree must still support regular operations
+		i3, _ := i2.Insert(remove...)
+		checkBalance(t, i3.root)
+		assert.Equal(t, uint64(500), i3.Len())
+	}
+}
+
+func TestAVLAscendDescendEach(t *testing.T) {
+	immutable := NewImmutable()
+	immutable, _ = immutable.Insert(mockEntry(5), mockEntry(1), mockEntry(9), mockEntry(3), mockEntry(7))
+
+	ascending := Entries{}
+	immutable.AscendEach(func(e Entry) bool {
+		ascending = append(ascending, e)
+		return true
+	})
+	assert.Equal(t, Entries{mockEntry(1), mockEntry(3), mockEntry(5), mockEntry(7), mockEntry(9)}, ascending)
+
+	descending := Entries{}
+	immutable.DescendEach(func(e Entry) bool {
+		descending = append(descending, e)
+		return true
+	})
+	assert.Equal(t, Entries{mockEntry(9), mockEntry(7), mockEntry(5), mockEntry(3), mockEntry(1)}, descending)
+}
+
+func TestAVLDescendEachStop(t *testing.T) {
+	immutable := NewImmutable()
+	immutable, _ = immutable.Insert(generateMockEntries(100)...)
+
+	top := Entries{}
+	immutable.DescendEach(func(e Entry) bool {
+		top = append(top, e)
+		return len(top) < 3
+	})
+	assert.Equal(t, Entries{mockEntry(99), mockEntry(98), mockEntry(97)}, top)
+
+	called := false
+	NewImmutable().DescendEach(func(e Entry) bool {
+		called = true
+		return true
+	})
+	assert.False(t, called)
+}
+
+func TestAVLVersionsAreSnapshots(t *testing.T) {
+	entries := generateMockEntries(1000)
+	var current atomic.Value
+	first, _ := NewImmutable().Insert(entries[:500]...)
+	current.Store(first)
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				snapshot := current.Load().(*Immutable)
+				n := snapshot.Len()
+				result := snapshot.Get(entries...)
+				found := uint64(0)
+				for _, e := range result {
+					if e != nil {
+						found++
+					}
+				}
+				assert.Equal(t, n, found)
+			}
+		}()
+	}
+
+	tree := first
+	for i := 500; i < 1000; i++ {
+		tree, _ = tree.Insert(entries[i])
+		tree, _ = tree.Delete(entries[i-500])
+		current.Store(tree)
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(500), first.Len())
+	assert.Equal(t, entries[:500], first.Get(entries[:500]...))
+	assert.Equal(t, make(Entries, 500), first.Get(entries[500:]...))
+	assert.Equal(t, entries[500:], tree.Get(entries[500:]...))
+}
+
+func TestAVLDeleteLeavesVersion(t *testing.T) {
+	entries := generateMockEntries(5)
+	i1, _ := NewImmutable().Insert(entries...)
+	i2, _ := i1.Delete(entries[1])
+	assert.Equal(t, entries, i1.Get(entries...))
+	assert.Equal(t, Entries{entries[0], nil, entries[2], entries[3], entries[4]},
+		i2.Get(entries...),
+	)
+
+	for seed := int64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		i1 := NewImmutable()
+		for _, k := range r.Perm(100) {
+			i1, _ = i1.Insert(mockEntry(k))
+		}
+
+		entries := generateMockEntries(100)
+		for _, e := range entries {
+			i2, _ := i1.Delete(e)
+			assert.Equal(t, uint64(99), i2.Len())
+			assert.Equal(t, entries, i1.Get(entries...))
+			assert.Equal(t, entries, appendInOrder(nil, i1.root))
+			checkBalance(t, i1.root)
+		}
+	}
+}
+
+func BenchmarkImmutableDeleteRange(b *testing.B) {
+	numItems := 1000
+	entries := generateMockEntries(numItems)
+	am, _ := NewImmutable().Insert(entries...)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		am.DeleteRange(mockEntry(100), mockEntry(900))
+	}
+}
+
 func BenchmarkImmutableInsert(b *testing.B) {
 	numItems := b.N
 	sl := NewImmutable()
@@ -386,3 +673,118 @@ func BenchmarkImmutableBulkDelete(b *testing.B) {
 		sl.Delete(entries...)
 	}
 }
+
+func BenchmarkImmutableDeleteAll(b *testing.B) {
+	numItems := 10000
+	sl := NewImmutable()
+
+	entries := generateMockEntries(numItems)
+	sl, _ = sl.Insert(entries...)
+	remove := entries[:numItems/10]
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sl.DeleteAll(remove...)
+	}
+}
+
+type sumMonoid struct{}
+
+func (sumMonoid) Identity() Aggregate { return 0 }
+
+func (sumMonoid) Value(e Entry) Aggregate { return int(e.(mockEntry)) }
+
+func (sumMonoid) Combine(a, b Aggregate) Aggregate { return a.(int) + b.(int) }
+
+type maxMonoid struct{}
+
+func (maxMonoid) Identity() Aggregate { return -1 }
+
+func (maxMonoid) Value(e Entry) Aggregate { return int(e.(mockEntry)) }
+
+func (maxMonoid) Combine(a, b Aggregate) Aggregate {
+	if a.(int) > b.(int) {
+		return a
+	}
+	return b
+}
+
+// entriesAggregate combines the entries of the tree between low and
+// high one at a time.
+func entriesAggregate(immutable *Immutable, monoid Monoid, low, high int) Aggregate {
+	result := monoid.Identity()
+	immutable.AscendEach(func(e Entry) bool {
+		if v := int(e.(mockEntry)); v >= low && v <= high {
+			result = monoid.Combine(result, monoid.Value(e))
+		}
+		return true
+	})
+
+	return result
+}
+
+func TestRangeAggregate(t *testing.T) {
+	immutable := NewAggregateImmutable(sumMonoid{})
+	assert.Equal(t, 0, immutable.RangeAggregate(mockEntry(0), mockEntry(10)))
+
+	immutable, _ = immutable.Insert(generateMockEntries(10)...)
+	assert.Equal(t, 45, immutable.RangeAggregate(mockEntry(0), mockEntry(9)))
+	assert.Equal(t, 2+3+4, immutable.RangeAggregate(mockEntry(2), mockEntry(4)))
+	assert.Equal(t, 9, immutable.RangeAggregate(mockEntry(9), mockEntry(100)))
+	assert.Equal(t, 0, immutable.RangeAggregate(mockEntry(4), mockEntry(2)))
+	assert.Equal(t, 0, immutable.RangeAggregate(mockEntry(20), mockEntry(30)))
+
+	deleted, _ := immutable.Delete(mockEntry(3))
+	assert.Equal(t, 2+4, deleted.RangeAggregate(mockEntry(2), mockEntry(4)))
+	assert.Equal(t, 2+3+4, immutable.RangeAggregate(mockEntry(2), mockEntry(4)))
+
+	assert.Nil(t, NewImmutable().RangeAggregate(mockEntry(0), mockEntry(1)))
+}
+
+func TestRangeAggregateMax(t *testing.T) {
+	immutable := NewAggregateImmutable(maxMonoid{})
+	immutable, _ = immutable.Insert(mockEntry(5), mockEntry(1), mockEntry(8), mockEntry(3))
+
+	assert.Equal(t, 8, immutable.RangeAggregate(mockEntry(0), mockEntry(10)))
+	assert.Equal(t, 5, immutable.RangeAggregate(mockEntry(0), mockEntry(7)))
+	assert.Equal(t, -1, immutable.RangeAggregate(mockEntry(6), mockEntry(7)))
+
+	immutable, _ = immutable.DeleteRange(mockEntry(4), mockEntry(9))
+	assert.Equal(t, 3, immutable.RangeAggregate(mockEntry(0), mockEntry(10)))
+}
+
+func TestRangeAggregateRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	monoid := sumMonoid{}
+	immutable := NewAggregateImmutable(monoid)
+	previous := make([]*Immutable, 0, 200)
+	sums := make([]Aggregate, 0, 200)
+
+	for i := 0; i < 200; i++ {
+		previous = append(previous, immutable)
+		sums = append(sums, entriesAggregate(immutable, monoid, 0, 1000))
+
+		switch r.Intn(4) {
+		case 0, 1:
+			immutable, _ = immutable.Insert(mockEntry(r.Intn(500)), mockEntry(r.Intn(500)))
+		case 2:
+			immutable, _ = immutable.Delete(mockEntry(r.Intn(500)), mockEntry(r.Intn(500)))
+		default:
+			low := r.Intn(500)
+			immutable, _ = immutable.DeleteAll(mockEntry(low), mockEntry(low+1), mockEntry(low+7))
+		}
+
+		low, high := r.Intn(500), r.Intn(500)
+		assert.Equal(t,
+			entriesAggregate(immutable, monoid, low, high),
+			immutable.RangeAggregate(mockEntry(low), mockEntry(high)),
+		)
+	}
+
+	// none of the operations changed an earlier tree
+	for i, immutable := range previous {
+		assert.Equal(t, sums[i], entriesAggregate(immutable, monoid, 0, 1000))
+		assert.Equal(t, sums[i], immutable.RangeAggregate(mockEntry(0), mockEntry(1000)))
+	}
+}
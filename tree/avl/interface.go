@@ -29,3 +29,20 @@ type Entry interface {
 	// is less than, 0 means equality, and 1 means greater than.
 	Compare(Entry) int
 }
+
+// Aggregate is the result of combining the entries of a subtree with a
+// Monoid.
+type Aggregate interface{}
+
+// Monoid describes how the entries of a tree are combined into an
+// Aggregate.  Combine must be associative and Identity must leave any
+// aggregate it is combined with unchanged.
+type Monoid interface {
+	// Identity returns the aggregate of no entries.
+	Identity() Aggregate
+	// Value returns the aggregate of the provided entry alone.
+	Value(Entry) Aggregate
+	// Combine returns the aggregate of the entries of a followed by
+	// the entries of b.
+	Combine(a, b Aggregate) Aggregate
+}
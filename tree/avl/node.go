@@ -28,6 +28,12 @@ type node struct {
 	balance  int8 // bounded, |balance| should be <= 1
 	children [2]*node
 	entry    Entry
+	// aggregate combines every entry of this subtree and is only
+	// valid if stale is false.  Nodes are stale until the tree that
+	// created them refreshes them, which happens before the tree is
+	// returned, so a shared node is never stale.
+	aggregate Aggregate
+	stale     bool
 }
 
 // copy returns a copy of this node with pointers to the original
@@ -37,6 +43,7 @@ func (n *node) copy() *node {
 		balance:  n.balance,
 		children: [2]*node{n.children[0], n.children[1]},
 		entry:    n.entry,
+		stale:    true,
 	}
 }
 
@@ -46,5 +53,6 @@ func newNode(entry Entry) *node {
 	return &node{
 		entry:    entry,
 		children: [2]*node{},
+		stale:    true,
 	}
 }
@@ -28,6 +28,7 @@ import (
 	"errors"
 	"hash"
 	"hash/fnv"
+	"sync"
 	"sync/atomic"
 	"unsafe"
 
@@ -55,6 +56,18 @@ type Ctrie struct {
 	root        *iNode
 	readOnly    bool
 	hashFactory HashFactory
+
+	// loading tracks the keys being computed by LookupOrCompute.
+	loadingLock sync.Mutex
+	loading     map[string]*computeCall
+}
+
+// computeCall is an in-flight LookupOrCompute.  done is closed once the
+// value has been stored, ok is false if the computation panicked.
+type computeCall struct {
+	done  chan struct{}
+	value interface{}
+	ok    bool
 }
 
 // generation demarcates Ctrie snapshots. We use a heap-allocated reference
@@ -306,6 +319,84 @@ func (c *Ctrie) Remove(key []byte) (interface{}, bool) {
 	return c.remove(&Entry{Key: key, hash: c.hash(key)})
 }
 
+// Compute atomically replaces the value for the associated key with the
+// result of fn.  fn is called with the current value and whether the key
+// exists.  If fn returns true its value is stored, otherwise the key is
+// removed.  The new value and whether the key exists afterwards are
+// returned.  Compute is lock-free: if another goroutine modifies the
+// Ctrie where the key lives between the read and the write, the write
+// fails its CAS and fn is called again with the fresh value.  Under
+// contention fn may therefore be called more than once and should be
+// free of side effects.
+func (c *Ctrie) Compute(key []byte,
+	fn func(old interface{}, existed bool) (interface{}, bool)) (interface{}, bool) {
+
+	c.assertReadWrite()
+	return c.compute(&Entry{Key: key, hash: c.hash(key)}, fn)
+}
+
+// LookupOrCompute returns the value for the associated key if it exists.
+// Otherwise compute is called and its result is inserted and returned.
+// The bool reports whether compute ran for this call.  Concurrent misses
+// on the same key wait for a single call to compute rather than calling
+// it again, so compute runs at most once per missing key.  Only the slow
+// path of a miss takes a lock.  If the key is inserted by other means
+// while compute runs, that value is kept and returned instead.  If
+// compute panics, the panic is propagated and one of the waiting callers
+// computes the value instead.
+func (c *Ctrie) LookupOrCompute(key []byte, compute func() interface{}) (interface{}, bool) {
+	c.assertReadWrite()
+	for {
+		if value, ok := c.Lookup(key); ok {
+			return value, false
+		}
+
+		c.loadingLock.Lock()
+		if call, ok := c.loading[string(key)]; ok {
+			c.loadingLock.Unlock()
+			<-call.done
+			if call.ok {
+				return call.value, false
+			}
+			continue // compute panicked, try again
+		}
+
+		// the key may have been stored since the lookup above
+		if value, ok := c.Lookup(key); ok {
+			c.loadingLock.Unlock()
+			return value, false
+		}
+
+		call := &computeCall{done: make(chan struct{})}
+		if c.loading == nil {
+			c.loading = make(map[string]*computeCall)
+		}
+		c.loading[string(key)] = call
+		c.loadingLock.Unlock()
+
+		c.runCompute(key, call, compute)
+		return call.value, true
+	}
+}
+
+func (c *Ctrie) runCompute(key []byte, call *computeCall, compute func() interface{}) {
+	defer func() {
+		c.loadingLock.Lock()
+		delete(c.loading, string(key))
+		c.loadingLock.Unlock()
+		close(call.done)
+	}()
+
+	value := compute()
+	call.value, _ = c.Compute(key, func(old interface{}, existed bool) (interface{}, bool) {
+		if existed {
+			return old, true
+		}
+		return value, true
+	})
+	call.ok = true
+}
+
 // Snapshot returns a stable, point-in-time snapshot of the Ctrie.
 func (c *Ctrie) Snapshot() *Ctrie {
 	for {
@@ -332,6 +423,18 @@ func (c *Ctrie) ReadOnlySnapshot() *Ctrie {
 	}
 }
 
+// Clone returns a new Ctrie holding the keys and values of this Ctrie
+// at a point in time.  The clone is taken from a read-only snapshot and
+// shares every node with it, so this is a constant time operation.
+// Nodes are only copied as either Ctrie writes beneath them, so changes
+// to one are never seen by the other.  The clone is writable even when
+// this Ctrie is a read-only snapshot, and both remain safe for
+// concurrent use.
+func (c *Ctrie) Clone() *Ctrie {
+	snapshot := c.ReadOnlySnapshot()
+	return newCtrie(snapshot.readRoot().copyToGen(&generation{}, snapshot), c.hashFactory, false)
+}
+
 // Clear removes all keys from the Ctrie.
 func (c *Ctrie) Clear() {
 	for {
@@ -361,6 +464,22 @@ func (c *Ctrie) Iterator(cancel <-chan struct{}) <-chan *Entry {
 	return ch
 }
 
+// ScanPrefix calls fn with the key and value of every entry in a
+// read-only snapshot of the Ctrie whose key starts with the provided
+// prefix, stopping early if fn returns false.  Keys are placed by
+// their hash rather than their bytes, so there are no branches that
+// can be skipped and every entry is examined.  The order in which
+// entries are visited is not defined.
+func (c *Ctrie) ScanPrefix(prefix []byte, fn func(key []byte, value interface{}) bool) {
+	snapshot := c.ReadOnlySnapshot()
+	snapshot.walk(snapshot.readRoot(), func(e *Entry) bool {
+		if !bytes.HasPrefix(e.Key, prefix) {
+			return true
+		}
+		return fn(e.Key, e.Value)
+	})
+}
+
 // Size returns the number of keys in the Ctrie.
 func (c *Ctrie) Size() uint {
 	// TODO: The size operation can be optimized further by caching the size
@@ -409,6 +528,34 @@ func (c *Ctrie) traverse(i *iNode, ch chan<- *Entry, cancel <-chan struct{}) err
 	return nil
 }
 
+// walk calls fn for every entry beneath i until fn returns false.  The
+// return value reports whether every entry was visited.
+func (c *Ctrie) walk(i *iNode, fn func(*Entry) bool) bool {
+	main := gcasRead(i, c)
+	switch {
+	case main.cNode != nil:
+		for _, br := range main.cNode.array {
+			switch b := br.(type) {
+			case *iNode:
+				if !c.walk(b, fn) {
+					return false
+				}
+			case *sNode:
+				if !fn(b.Entry) {
+					return false
+				}
+			}
+		}
+	case main.lNode != nil:
+		// Find stops at the first entry fn rejects
+		_, stopped := main.lNode.Find(func(sn interface{}) bool {
+			return !fn(sn.(*sNode).Entry)
+		})
+		return !stopped
+	}
+	return true
+}
+
 func (c *Ctrie) assertReadWrite() {
 	if c.readOnly {
 		panic("Cannot modify read-only snapshot")
@@ -440,6 +587,18 @@ func (c *Ctrie) remove(entry *Entry) (interface{}, bool) {
 	return result, exists
 }
 
+func (c *Ctrie) compute(entry *Entry,
+	fn func(interface{}, bool) (interface{}, bool)) (interface{}, bool) {
+
+	for {
+		root := c.readRoot()
+		result, exists, ok := c.icompute(root, entry, fn, 0, nil, root.gen)
+		if ok {
+			return result, exists
+		}
+	}
+}
+
 func (c *Ctrie) hash(k []byte) uint32 {
 	hasher := c.hashFactory()
 	hasher.Write(k)
@@ -651,6 +810,112 @@ func (c *Ctrie) iremove(i *iNode, entry *Entry, lev uint, parent *iNode, startGe
 	}
 }
 
+// icompute attempts to replace the entry's value in the Ctrie with the
+// result of fn, removing it if fn returns false.  The first two return
+// values are the new value and whether or not the entry is contained in
+// the Ctrie.  The last bool indicates if the operation succeeded.  False
+// means it should be retried.
+func (c *Ctrie) icompute(i *iNode, entry *Entry, fn func(interface{}, bool) (interface{}, bool),
+	lev uint, parent *iNode, startGen *generation) (interface{}, bool, bool) {
+
+	// Linearization point.
+	main := gcasRead(i, c)
+	switch {
+	case main.cNode != nil:
+		cn := main.cNode
+		flag, pos := flagPos(entry.hash, lev, cn.bmp)
+		if cn.bmp&flag == 0 {
+			// The key is not present, insert it as iinsert would.
+			value, keep := fn(nil, false)
+			if !keep {
+				return nil, false, true
+			}
+			rn := cn
+			if cn.gen != i.gen {
+				rn = cn.renewed(i.gen, c)
+			}
+			nsn := &sNode{&Entry{Key: entry.Key, Value: value, hash: entry.hash}}
+			ncn := &mainNode{cNode: rn.inserted(pos, flag, nsn, i.gen)}
+			return value, true, gcas(i, main, ncn, c)
+		}
+		branch := cn.array[pos]
+		switch branch.(type) {
+		case *iNode:
+			in := branch.(*iNode)
+			if startGen == in.gen {
+				return c.icompute(in, entry, fn, lev+w, i, startGen)
+			}
+			if gcas(i, main, &mainNode{cNode: cn.renewed(startGen, c)}, c) {
+				return c.icompute(i, entry, fn, lev, parent, startGen)
+			}
+			return nil, false, false
+		case *sNode:
+			sn := branch.(*sNode)
+			if !bytes.Equal(sn.Key, entry.Key) {
+				// The key is not present but shares a hashcode prefix,
+				// extend the Ctrie as iinsert would.
+				value, keep := fn(nil, false)
+				if !keep {
+					return nil, false, true
+				}
+				rn := cn
+				if cn.gen != i.gen {
+					rn = cn.renewed(i.gen, c)
+				}
+				nsn := &sNode{&Entry{Key: entry.Key, Value: value, hash: entry.hash}}
+				nin := &iNode{main: newMainNode(sn, sn.hash, nsn, nsn.hash, lev+w, i.gen), gen: i.gen}
+				ncn := &mainNode{cNode: rn.updated(pos, nin, i.gen)}
+				return value, true, gcas(i, main, ncn, c)
+			}
+			value, keep := fn(sn.Value, true)
+			if keep {
+				nsn := &sNode{&Entry{Key: entry.Key, Value: value, hash: entry.hash}}
+				ncn := &mainNode{cNode: cn.updated(pos, nsn, i.gen)}
+				return value, true, gcas(i, main, ncn, c)
+			}
+			// Remove the S-node as iremove would.
+			ncn := cn.removed(pos, flag, i.gen)
+			cntr := toContracted(ncn, lev)
+			if !gcas(i, main, cntr, c) {
+				return nil, false, false
+			}
+			if parent != nil {
+				main = gcasRead(i, c)
+				if main.tNode != nil {
+					cleanParent(parent, i, entry.hash, lev-w, c, startGen)
+				}
+			}
+			return nil, false, true
+		default:
+			panic("Ctrie is in an invalid state")
+		}
+	case main.tNode != nil:
+		clean(parent, lev-w, c)
+		return nil, false, false
+	case main.lNode != nil:
+		old, existed := main.lNode.lookup(entry)
+		value, keep := fn(old, existed)
+		if !keep && !existed {
+			return nil, false, true
+		}
+		nln := &mainNode{lNode: main.lNode.removed(entry)}
+		if keep {
+			nln.lNode = nln.lNode.inserted(&Entry{Key: entry.Key, Value: value, hash: entry.hash})
+		} else if nln.lNode.length() == 1 {
+			nln = entomb(nln.lNode.entry())
+		}
+		if !gcas(i, main, nln, c) {
+			return nil, false, false
+		}
+		if !keep {
+			return nil, false, true
+		}
+		return value, true, true
+	default:
+		panic("Ctrie is in an invalid state")
+	}
+}
+
 // toContracted ensures that every I-node except the root points to a C-node
 // with at least one branch. If a given C-Node has only a single S-node below
 // it and is not at the root level, a T-node which wraps the S-node is
@@ -21,6 +21,7 @@ import (
 	"hash/fnv"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -210,6 +211,213 @@ func TestConcurrency2(t *testing.T) {
 	assert.Equal(uint(10000), ctrie.Size())
 }
 
+func increment(old interface{}, existed bool) (interface{}, bool) {
+	if !existed {
+		return 1, true
+	}
+	return old.(int) + 1, true
+}
+
+func TestCompute(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+
+	val, ok := ctrie.Compute([]byte("foo"), increment)
+	assert.True(ok)
+	assert.Equal(1, val)
+
+	val, ok = ctrie.Compute([]byte("foo"), increment)
+	assert.True(ok)
+	assert.Equal(2, val)
+	val, ok = ctrie.Lookup([]byte("foo"))
+	assert.True(ok)
+	assert.Equal(2, val)
+
+	// returning false removes the key
+	val, ok = ctrie.Compute([]byte("foo"), func(old interface{}, existed bool) (interface{}, bool) {
+		assert.True(existed)
+		assert.Equal(2, old)
+		return nil, false
+	})
+	assert.False(ok)
+	assert.Nil(val)
+	_, ok = ctrie.Lookup([]byte("foo"))
+	assert.False(ok)
+
+	// and is a no-op for a missing key
+	_, ok = ctrie.Compute([]byte("foo"), func(old interface{}, existed bool) (interface{}, bool) {
+		assert.False(existed)
+		assert.Nil(old)
+		return nil, false
+	})
+	assert.False(ok)
+	assert.Equal(uint(0), ctrie.Size())
+
+	for i := 0; i < 1000; i++ {
+		ctrie.Compute([]byte(strconv.Itoa(i)), increment)
+		ctrie.Compute([]byte(strconv.Itoa(i)), increment)
+	}
+	for i := 0; i < 1000; i++ {
+		val, ok = ctrie.Lookup([]byte(strconv.Itoa(i)))
+		assert.True(ok)
+		assert.Equal(2, val)
+	}
+	for i := 0; i < 1000; i++ {
+		ctrie.Compute([]byte(strconv.Itoa(i)), func(interface{}, bool) (interface{}, bool) {
+			return nil, false
+		})
+	}
+	assert.Equal(uint(0), ctrie.Size())
+}
+
+func TestComputeLNode(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(mockHashFactory)
+
+	for i := 0; i < 10; i++ {
+		ctrie.Compute([]byte(strconv.Itoa(i)), increment)
+		ctrie.Compute([]byte(strconv.Itoa(i)), increment)
+	}
+	assert.Equal(uint(10), ctrie.Size())
+
+	for i := 0; i < 10; i++ {
+		val, ok := ctrie.Lookup([]byte(strconv.Itoa(i)))
+		assert.True(ok)
+		assert.Equal(2, val)
+	}
+
+	for i := 0; i < 10; i++ {
+		_, ok := ctrie.Compute([]byte(strconv.Itoa(i)), func(interface{}, bool) (interface{}, bool) {
+			return nil, false
+		})
+		assert.False(ok)
+		_, ok = ctrie.Lookup([]byte(strconv.Itoa(i)))
+		assert.False(ok)
+	}
+	assert.Equal(uint(0), ctrie.Size())
+}
+
+func TestComputeConcurrentCounters(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+	var wg sync.WaitGroup
+	wg.Add(8)
+
+	for i := 0; i < 8; i++ {
+		go func() {
+			for j := 0; j < 1000; j++ {
+				ctrie.Compute([]byte(strconv.Itoa(j%10)), increment)
+			}
+			wg.Done()
+		}()
+	}
+
+	wg.Wait()
+	for i := 0; i < 10; i++ {
+		val, ok := ctrie.Lookup([]byte(strconv.Itoa(i)))
+		assert.True(ok)
+		assert.Equal(800, val)
+	}
+}
+
+func TestComputeSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+	ctrie.Insert([]byte("foo"), 1)
+
+	snapshot := ctrie.Snapshot()
+	ctrie.Compute([]byte("foo"), increment)
+	snapshot.Compute([]byte("bar"), increment)
+
+	val, _ := ctrie.Lookup([]byte("foo"))
+	assert.Equal(2, val)
+	val, _ = snapshot.Lookup([]byte("foo"))
+	assert.Equal(1, val)
+	_, ok := ctrie.Lookup([]byte("bar"))
+	assert.False(ok)
+
+	defer func() {
+		assert.NotNil(recover())
+	}()
+	ctrie.ReadOnlySnapshot().Compute([]byte("foo"), increment)
+}
+
+func TestLookupOrCompute(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+
+	val, computed := ctrie.LookupOrCompute([]byte("foo"), func() interface{} {
+		return "bar"
+	})
+	assert.True(computed)
+	assert.Equal("bar", val)
+
+	val, computed = ctrie.LookupOrCompute([]byte("foo"), func() interface{} {
+		t.Error("compute called for an existing key")
+		return "baz"
+	})
+	assert.False(computed)
+	assert.Equal("bar", val)
+
+	val, ok := ctrie.Lookup([]byte("foo"))
+	assert.True(ok)
+	assert.Equal("bar", val)
+}
+
+func TestLookupOrComputeOncePerKey(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+	var calls, computed int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	wg.Add(16)
+
+	for i := 0; i < 16; i++ {
+		go func() {
+			defer wg.Done()
+			<-start
+			val, ran := ctrie.LookupOrCompute([]byte("foo"), func() interface{} {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(10 * time.Millisecond)
+				return "bar"
+			})
+			if ran {
+				atomic.AddInt32(&computed, 1)
+			}
+			assert.Equal("bar", val)
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	assert.Equal(int32(1), calls)
+	assert.Equal(int32(1), computed)
+	assert.Len(ctrie.loading, 0)
+}
+
+func TestLookupOrComputePanic(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+
+	func() {
+		defer func() {
+			assert.Equal("boom", recover())
+		}()
+		ctrie.LookupOrCompute([]byte("foo"), func() interface{} {
+			panic("boom")
+		})
+	}()
+
+	_, ok := ctrie.Lookup([]byte("foo"))
+	assert.False(ok)
+
+	val, computed := ctrie.LookupOrCompute([]byte("foo"), func() interface{} {
+		return "bar"
+	})
+	assert.True(computed)
+	assert.Equal("bar", val)
+}
+
 func TestSnapshot(t *testing.T) {
 	assert := assert.New(t)
 	ctrie := New(nil)
@@ -294,6 +502,45 @@ func TestSnapshot(t *testing.T) {
 	assert.Equal(0, val)
 }
 
+func TestClone(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+	for i := 0; i < 100; i++ {
+		ctrie.Insert([]byte(strconv.Itoa(i)), i)
+	}
+
+	clone := ctrie.Clone()
+	assert.Equal(uint(100), clone.Size())
+
+	for i := 0; i < 50; i++ {
+		ctrie.Remove([]byte(strconv.Itoa(i)))
+		clone.Insert([]byte(strconv.Itoa(i)), -i)
+	}
+	clone.Insert([]byte("100"), 100)
+
+	for i := 0; i < 50; i++ {
+		_, ok := ctrie.Lookup([]byte(strconv.Itoa(i)))
+		assert.False(ok)
+		val, ok := clone.Lookup([]byte(strconv.Itoa(i)))
+		assert.True(ok)
+		assert.Equal(-i, val)
+	}
+	_, ok := ctrie.Lookup([]byte("100"))
+	assert.False(ok)
+	assert.Equal(uint(50), ctrie.Size())
+	assert.Equal(uint(101), clone.Size())
+
+	// a clone of a read-only snapshot is writable
+	snapshot := ctrie.ReadOnlySnapshot()
+	clone = snapshot.Clone()
+	clone.Insert([]byte("0"), 0)
+	val, ok := clone.Lookup([]byte("0"))
+	assert.True(ok)
+	assert.Equal(0, val)
+	_, ok = snapshot.Lookup([]byte("0"))
+	assert.False(ok)
+}
+
 func TestIterator(t *testing.T) {
 	assert := assert.New(t)
 	ctrie := New(nil)
@@ -341,6 +588,88 @@ func TestIterator(t *testing.T) {
 	assert.False(ok)
 }
 
+func TestScanPrefix(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+	ctrie.Insert([]byte("a/b"), 1)
+	ctrie.Insert([]byte("a/c"), 2)
+	ctrie.Insert([]byte("a"), 3)
+	ctrie.Insert([]byte("b/a"), 4)
+
+	found := map[string]interface{}{}
+	ctrie.ScanPrefix([]byte("a/"), func(key []byte, value interface{}) bool {
+		found[string(key)] = value
+		return true
+	})
+	assert.Equal(map[string]interface{}{"a/b": 1, "a/c": 2}, found)
+
+	count := 0
+	ctrie.ScanPrefix(nil, func(key []byte, value interface{}) bool {
+		count++
+		return true
+	})
+	assert.Equal(4, count)
+
+	ctrie.ScanPrefix([]byte("c"), func(key []byte, value interface{}) bool {
+		t.Error("no keys should match")
+		return true
+	})
+}
+
+func TestScanPrefixStop(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+	for i := 0; i < 100; i++ {
+		ctrie.Insert([]byte("key"+strconv.Itoa(i)), i)
+	}
+
+	count := 0
+	ctrie.ScanPrefix([]byte("key"), func(key []byte, value interface{}) bool {
+		count++
+		return count < 5
+	})
+	assert.Equal(5, count)
+}
+
+func TestScanPrefixLNode(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(mockHashFactory)
+	for i := 0; i < 10; i++ {
+		ctrie.Insert([]byte("key"+strconv.Itoa(i)), i)
+	}
+
+	found := 0
+	ctrie.ScanPrefix([]byte("key"), func(key []byte, value interface{}) bool {
+		found++
+		return true
+	})
+	assert.Equal(10, found)
+
+	count := 0
+	ctrie.ScanPrefix([]byte("key"), func(key []byte, value interface{}) bool {
+		count++
+		return count < 3
+	})
+	assert.Equal(3, count)
+}
+
+func TestScanPrefixSnapshot(t *testing.T) {
+	assert := assert.New(t)
+	ctrie := New(nil)
+	ctrie.Insert([]byte("a1"), 1)
+	ctrie.Insert([]byte("a2"), 2)
+
+	count := 0
+	ctrie.ScanPrefix([]byte("a"), func(key []byte, value interface{}) bool {
+		// changes made during the scan are not seen by it
+		ctrie.Insert([]byte("a"+strconv.Itoa(count+10)), count)
+		count++
+		return true
+	})
+	assert.Equal(2, count)
+	assert.Equal(uint(4), ctrie.Size())
+}
+
 func TestSize(t *testing.T) {
 	ctrie := New(nil)
 	for i := 0; i < 10; i++ {
@@ -0,0 +1,144 @@
+/*
+Copyright 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ctrie
+
+import "errors"
+
+// ErrTxnDone is returned when committing a transaction that has already
+// been committed or discarded.
+var ErrTxnDone = errors.New("transaction has already been committed or discarded")
+
+// txnOp is a write made within a transaction.
+type txnOp struct {
+	key    []byte
+	value  interface{}
+	remove bool
+}
+
+// Txn is a set of writes that are applied to a Ctrie atomically.  The
+// transaction works on a private snapshot taken when it was begun, so
+// its reads see the Ctrie as it was at that point plus the transaction's
+// own pending writes, and none of its writes are visible to others
+// until Commit.  A Txn is not safe for concurrent use.
+type Txn struct {
+	ctrie *Ctrie
+	// base and main are the root of the Ctrie and its main node when
+	// the snapshot was taken.  Any operation on the Ctrie since then
+	// replaces main, which is how Commit detects a conflict.
+	base     *iNode
+	main     *mainNode
+	snapshot *Ctrie
+	ops      []txnOp
+	done     bool
+}
+
+// Txn begins a transaction on the Ctrie.  Write operations on a Txn of a
+// read-only snapshot will panic.
+func (c *Ctrie) Txn() *Txn {
+	c.assertReadWrite()
+	t := &Txn{ctrie: c}
+	t.reset()
+	return t
+}
+
+// reset takes a new snapshot of the Ctrie to apply the transaction to.
+func (t *Txn) reset() {
+	c := t.ctrie
+	for {
+		root := c.readRoot()
+		main := gcasRead(root, c)
+		// moving the root to a new generation guarantees that the next
+		// operation on the Ctrie replaces the root's main node
+		renewed := root.copyToGen(&generation{}, c)
+		if c.rdcssRoot(root, main, renewed) {
+			t.base, t.main = renewed, main
+			t.snapshot = newCtrie(&iNode{main: main, gen: &generation{}}, c.hashFactory, false)
+			return
+		}
+	}
+}
+
+// Insert adds the key-value pair to the transaction, replacing the
+// existing value if the key already exists.  Write operations on a
+// committed or discarded transaction will panic.
+func (t *Txn) Insert(key []byte, value interface{}) {
+	t.snapshot.Insert(key, value)
+	t.ops = append(t.ops, txnOp{key: key, value: value})
+}
+
+// Lookup returns the value for the associated key as seen by the
+// transaction or returns false if the key doesn't exist.
+func (t *Txn) Lookup(key []byte) (interface{}, bool) {
+	return t.snapshot.Lookup(key)
+}
+
+// Remove deletes the value for the associated key within the
+// transaction, returning true if it was removed or false if the entry
+// doesn't exist.  Write operations on a committed or discarded
+// transaction will panic.
+func (t *Txn) Remove(key []byte) (interface{}, bool) {
+	value, ok := t.snapshot.Remove(key)
+	t.ops = append(t.ops, txnOp{key: key, remove: true})
+	return value, ok
+}
+
+// Commit atomically applies every write made within the transaction to
+// the Ctrie with a CAS on its root.  If the Ctrie was used in any way
+// since the snapshot was taken, the CAS fails and the writes are
+// replayed, in order, on a new snapshot until the CAS succeeds.  This
+// means the writes are always applied together, but on top of the
+// latest state of the Ctrie, so values read within the transaction may
+// have changed by the time it is committed.  ErrTxnDone is returned if
+// the transaction was already committed or discarded.
+func (t *Txn) Commit() error {
+	if t.done {
+		return ErrTxnDone
+	}
+
+	for {
+		root := t.snapshot.readRoot().copyToGen(&generation{}, t.snapshot)
+		if t.ctrie.rdcssRoot(t.base, t.main, root) {
+			break
+		}
+
+		t.reset()
+		for _, op := range t.ops {
+			if op.remove {
+				t.snapshot.Remove(op.key)
+			} else {
+				t.snapshot.Insert(op.key, op.value)
+			}
+		}
+	}
+
+	t.finish()
+	return nil
+}
+
+// Discard abandons the transaction, none of its writes are applied.
+// Discarding a committed or discarded transaction does nothing.
+func (t *Txn) Discard() {
+	t.finish()
+}
+
+// finish makes the snapshot read-only, as it now shares its nodes with
+// the Ctrie, and releases the pending writes.
+func (t *Txn) finish() {
+	t.done = true
+	t.snapshot.readOnly = true
+	t.ops = nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2015 Workiva, LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+ http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ctrie
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxnCommit(t *testing.T) {
+	ctrie := New(nil)
+	ctrie.Insert([]byte("a"), 1)
+	ctrie.Insert([]byte("b"), 2)
+
+	txn := ctrie.Txn()
+	txn.Insert([]byte("c"), 3)
+	val, ok := txn.Remove([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, 1, val)
+
+	// reads within the transaction see its pending writes
+	val, ok = txn.Lookup([]byte("c"))
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+	_, ok = txn.Lookup([]byte("a"))
+	assert.False(t, ok)
+
+	// but the ctrie does not until it is committed
+	_, ok = ctrie.Lookup([]byte("c"))
+	assert.False(t, ok)
+	_, ok = ctrie.Lookup([]byte("a"))
+	assert.True(t, ok)
+
+	assert.Nil(t, txn.Commit())
+	_, ok = ctrie.Lookup([]byte("a"))
+	assert.False(t, ok)
+	val, ok = ctrie.Lookup([]byte("c"))
+	assert.True(t, ok)
+	assert.Equal(t, 3, val)
+	assert.Equal(t, uint(2), ctrie.Size())
+
+	// the ctrie remains usable after the commit
+	ctrie.Insert([]byte("d"), 4)
+	assert.Equal(t, uint(3), ctrie.Size())
+
+	assert.Equal(t, ErrTxnDone, txn.Commit())
+	assert.Panics(t, func() { txn.Insert([]byte("e"), 5) })
+	_, ok = txn.Lookup([]byte("d"))
+	assert.False(t, ok)
+}
+
+func TestTxnDiscard(t *testing.T) {
+	ctrie := New(nil)
+	ctrie.Insert([]byte("a"), 1)
+
+	txn := ctrie.Txn()
+	txn.Insert([]byte("a"), 2)
+	txn.Discard()
+	txn.Discard()
+
+	val, _ := ctrie.Lookup([]byte("a"))
+	assert.Equal(t, 1, val)
+	assert.Equal(t, ErrTxnDone, txn.Commit())
+	assert.Panics(t, func() { txn.Remove([]byte("a")) })
+}
+
+func TestTxnConflict(t *testing.T) {
+	ctrie := New(nil)
+	ctrie.Insert([]byte("a"), 1)
+
+	txn := ctrie.Txn()
+	txn.Insert([]byte("b"), 2)
+	txn.Remove([]byte("a"))
+
+	ctrie.Insert([]byte("c"), 3)
+	ctrie.Insert([]byte("a"), 10)
+
+	assert.Nil(t, txn.Commit())
+	_, ok := ctrie.Lookup([]byte("a"))
+	assert.False(t, ok)
+	val, _ := ctrie.Lookup([]byte("b"))
+	assert.Equal(t, 2, val)
+	val, _ = ctrie.Lookup([]byte("c"))
+	assert.Equal(t, 3, val)
+	assert.Equal(t, uint(2), ctrie.Size())
+}
+
+func TestTxnReadOnly(t *testing.T) {
+	assert.Panics(t, func() { New(nil).ReadOnlySnapshot().Txn() })
+}
+
+func TestTxnConcurrentAtomic(t *testing.T) {
+	ctrie := New(nil)
+	numTxns := 200
+	var wg sync.WaitGroup
+	wg.Add(3)
+
+	for w := 0; w < 2; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < numTxns; i++ {
+				txn := ctrie.Txn()
+				for k := 0; k < 10; k++ {
+					txn.Insert([]byte(strconv.Itoa(k)), w*numTxns+i)
+				}
+				txn.Insert([]byte("w"+strconv.Itoa(w)+"-"+strconv.Itoa(i)), i)
+				assert.Nil(t, txn.Commit())
+			}
+		}(w)
+	}
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < numTxns; i++ {
+			snapshot := ctrie.ReadOnlySnapshot()
+			first, ok := snapshot.Lookup([]byte("0"))
+			for k := 1; k < 10; k++ {
+				val, found := snapshot.Lookup([]byte(strconv.Itoa(k)))
+				assert.Equal(t, ok, found)
+				assert.Equal(t, first, val)
+			}
+			ctrie.Lookup([]byte("0"))
+		}
+	}()
+
+	wg.Wait()
+	assert.Equal(t, uint(10+2*numTxns), ctrie.Size())
+}
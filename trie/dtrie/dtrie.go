@@ -77,6 +77,14 @@ func (d *Dtrie) Size() (size int) {
 	return size
 }
 
+// NodeCount returns the number of internal nodes, including the root
+// and any collision nodes, reachable from this Dtrie.  Compared with
+// Size this gives an idea of the memory used to hold the entries.  Like
+// Size, this traverses the entire trie.
+func (d *Dtrie) NodeCount() int {
+	return countNodes(d.root)
+}
+
 // Get returns the value for the associated key or returns nil if the
 // key does not exist.
 func (d *Dtrie) Get(key interface{}) interface{} {
@@ -97,6 +105,16 @@ func (d *Dtrie) Remove(key interface{}) *Dtrie {
 	return &Dtrie{root, d.hasher}
 }
 
+// Filter returns a Dtrie holding only the entries for which pred returns
+// true.  This Dtrie is not changed.  The result shares no nodes with
+// this Dtrie, so later changes to either one are not seen by the other.
+func (d *Dtrie) Filter(pred func(key, value interface{}) bool) *Dtrie {
+	root := filter(d.root, func(e Entry) bool {
+		return pred(e.Key(), e.Value())
+	})
+	return &Dtrie{root, d.hasher}
+}
+
 // Iterator returns a read-only channel of Entries from the Dtrie. If a stop
 // channel is provided, closing it will terminate and close the iterator
 // channel. Note that if a cancel channel is not used and not every entry is
@@ -104,3 +122,31 @@ func (d *Dtrie) Remove(key interface{}) *Dtrie {
 func (d *Dtrie) Iterator(stop <-chan struct{}) <-chan Entry {
 	return iterate(d.root, stop)
 }
+
+// Equal returns true if other contains exactly the same keys as this Dtrie
+// and valueEq reports the values for every key as equal.  If valueEq is nil
+// values are compared with ==.  The comparison stops at the first
+// difference found.
+func (d *Dtrie) Equal(other *Dtrie, valueEq func(a, b interface{}) bool) bool {
+	if d == other || d.root == other.root {
+		return true
+	}
+	if valueEq == nil {
+		valueEq = func(a, b interface{}) bool { return a == b }
+	}
+	size := 0
+	equal := walk(d.root, func(e Entry) bool {
+		size++
+		o := get(other.root, other.hasher(e.Key()), e.Key())
+		return o != nil && o.Key() == e.Key() && valueEq(e.Value(), o.Value())
+	})
+	if !equal {
+		return false
+	}
+	// every key in d exists in other, so other is equal if it has
+	// no further keys
+	return walk(other.root, func(Entry) bool {
+		size--
+		return size >= 0
+	})
+}
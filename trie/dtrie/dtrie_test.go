@@ -98,6 +98,24 @@ func updateTest(t *testing.T, hashfunc func(interface{}) uint32, count int) {
 	}
 }
 
+func TestUpdateCollision(t *testing.T) {
+	n := insertTest(t, collisionHash, 10)
+	hash := collisionHash(nil)
+	bottom := n
+	for bottom.level < 6 {
+		bottom = bottom.entries[mask(hash, bottom.level)].(*node)
+	}
+	cNode := bottom.entries[mask(hash, 6)].(*collisionNode)
+	before := append([]Entry(nil), cNode.entries...)
+
+	n = insert(n, &entry{hash, 3, -3})
+	d := &Dtrie{n, collisionHash}
+	assert.Equal(t, 10, d.Size())
+	assert.Equal(t, -3, d.Get(3))
+	assert.Equal(t, 4, d.Get(4))
+	assert.Equal(t, before, cNode.entries)
+}
+
 func TestIterate(t *testing.T) {
 	n := insertTest(t, defaultHasher, 10000)
 	echan := iterate(n, nil)
@@ -134,6 +152,61 @@ func TestSize(t *testing.T) {
 	assert.Equal(t, 10000, d.Size())
 }
 
+func TestNodeCount(t *testing.T) {
+	d := New(nil)
+	assert.Equal(t, 1, d.NodeCount())
+
+	d = d.Insert(0, 0)
+	assert.Equal(t, 1, d.NodeCount())
+
+	d = New(collisionHash)
+	d = d.Insert(0, 0)
+	d = d.Insert(1, 1)
+	// root, one node per level down to level 6, then a collision node
+	assert.Equal(t, 8, d.NodeCount())
+	assert.Equal(t, 2, d.Size())
+
+	d = New(nil)
+	for i := 0; i < 1000; i++ {
+		d = d.Insert(i, i)
+	}
+	assert.True(t, d.NodeCount() > 1)
+	assert.True(t, d.NodeCount() < d.Size())
+}
+
+func TestEqual(t *testing.T) {
+	for _, hashfunc := range []func(interface{}) uint32{defaultHasher, collisionHash} {
+		d1, d2 := New(hashfunc), New(hashfunc)
+		assert.True(t, d1.Equal(d2, nil))
+		for i := 0; i < 1000; i++ {
+			d1 = d1.Insert(i, i)
+			d2 = d2.Insert(999-i, 999-i)
+		}
+		assert.True(t, d1.Equal(d2, nil))
+		assert.True(t, d2.Equal(d1, nil))
+		assert.True(t, d1.Equal(d1, nil))
+
+		d2 = d2.Insert(500, -500)
+		assert.False(t, d1.Equal(d2, nil))
+		assert.True(t, d1.Equal(d2, func(a, b interface{}) bool { return true }))
+
+		d2 = d2.Insert(500, 500)
+		d2 = d2.Insert(1000, 1000)
+		assert.False(t, d1.Equal(d2, nil))
+		assert.False(t, d2.Equal(d1, nil))
+	}
+}
+
+func TestEqualDifferentHashers(t *testing.T) {
+	d1, d2 := New(nil), New(collisionHash)
+	for i := 0; i < 100; i++ {
+		d1 = d1.Insert(i, i)
+		d2 = d2.Insert(i, i)
+	}
+	assert.True(t, d1.Equal(d2, nil))
+	assert.True(t, d2.Equal(d1, nil))
+}
+
 func BenchmarkInsert(b *testing.B) {
 	b.ReportAllocs()
 	n := emptyNode(0, 32)
@@ -169,3 +242,89 @@ func BenchmarkUpdate(b *testing.B) {
 		n = insert(n, &entry{defaultHasher(i), i, -i})
 	}
 }
+
+func TestFilter(t *testing.T) {
+	for _, hashfunc := range []func(interface{}) uint32{defaultHasher, collisionHash} {
+		d := New(hashfunc)
+		for i := 0; i < 1000; i++ {
+			d = d.Insert(i, i)
+		}
+
+		even := d.Filter(func(key, value interface{}) bool {
+			return key.(int)%2 == 0
+		})
+		assert.Equal(t, 500, even.Size())
+		walk(even.root, func(e Entry) bool {
+			assert.Equal(t, 0, e.Key().(int)%2)
+			return true
+		})
+		for i := 0; i < 1000; i += 2 {
+			assert.Equal(t, i, even.Get(i))
+		}
+		for i := 0; i < 1000; i++ {
+			assert.Equal(t, i, d.Get(i))
+		}
+		assert.Equal(t, 1000, d.Size())
+
+		all := d.Filter(func(key, value interface{}) bool { return true })
+		assert.Equal(t, 1000, all.Size())
+		assert.True(t, d.Equal(all, nil))
+
+		none := d.Filter(func(key, value interface{}) bool { return false })
+		assert.Equal(t, 0, none.Size())
+		assert.Equal(t, 1, none.NodeCount())
+		assert.Equal(t, 1000, d.Size())
+	}
+}
+
+func TestFilterIsolated(t *testing.T) {
+	for _, hashfunc := range []func(interface{}) uint32{defaultHasher, collisionHash} {
+		d := New(hashfunc)
+		for i := 0; i < 100; i++ {
+			d = d.Insert(i, i)
+		}
+
+		even := d.Filter(func(key, value interface{}) bool {
+			return key.(int)%2 == 0
+		})
+		all := d.Filter(func(key, value interface{}) bool { return true })
+
+		d = d.Insert(2, -2)
+		d = d.Insert(4, -4)
+		d = d.Insert(1000, 1000)
+		d = d.Remove(6)
+		d = d.Remove(7)
+
+		for i := 0; i < 100; i += 2 {
+			assert.Equal(t, i, even.Get(i))
+		}
+		assert.Equal(t, 50, even.Size())
+
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, i, all.Get(i))
+		}
+		assert.Equal(t, 100, all.Size())
+	}
+}
+
+func TestFilterCollapses(t *testing.T) {
+	d := New(collisionHash)
+	d = d.Insert(0, 0)
+	d = d.Insert(1, 1)
+	d = d.Insert(2, 2)
+
+	one := d.Filter(func(key, value interface{}) bool {
+		return key.(int) == 1
+	})
+	assert.Equal(t, 1, one.Size())
+	assert.Equal(t, 1, one.NodeCount())
+	assert.Equal(t, 1, one.Get(1))
+
+	two := d.Filter(func(key, value interface{}) bool {
+		return key.(int) != 1
+	})
+	assert.Equal(t, 2, two.Size())
+	assert.Equal(t, 8, two.NodeCount())
+	assert.Equal(t, 3, d.Size())
+	assert.Equal(t, 1, d.Get(1))
+}
@@ -93,6 +93,17 @@ func insert(n *node, entry Entry) *node {
 			return newNode
 		}
 		cNode := newNode.entries[index].(*collisionNode)
+		for i, e := range cNode.entries {
+			if e.Key() == entry.Key() {
+				// the collision node is replaced rather than written
+				// to, as an iterator may still be reading its entries
+				entries := make([]Entry, len(cNode.entries))
+				copy(entries, cNode.entries)
+				entries[i] = entry
+				newNode.entries[index] = &collisionNode{entries: entries}
+				return newNode
+			}
+		}
 		cNode.entries = append(cNode.entries, entry)
 		return newNode
 	}
@@ -192,6 +203,91 @@ func remove(n *node, keyHash uint32, key interface{}) *node {
 	return n
 }
 
+// filter returns a trie holding only the entries beneath n for which
+// keep returns true.  n is never modified and every node of the result
+// is newly built, since insert and remove change nodes in place and the
+// result must not see those changes.  A sub-node left holding a single
+// entry is replaced by that entry, as remove does.
+func filter(n *node, keep func(Entry) bool) *node {
+	newNode := &node{
+		entries: make([]Entry, len(n.entries)),
+		nodeMap: n.nodeMap,
+		dataMap: n.dataMap,
+		level:   n.level,
+	}
+
+	for i, e := range n.entries {
+		index := uint(i)
+		switch {
+		case n.dataMap.GetBit(index):
+			if keep(e) {
+				newNode.entries[index] = e
+			} else {
+				newNode.dataMap = newNode.dataMap.ClearBit(index)
+			}
+		case n.nodeMap.GetBit(index):
+			subNode := filter(e.(*node), keep)
+			if sole, ok := soleEntry(subNode); ok {
+				newNode.entries[index] = sole
+				newNode.nodeMap = newNode.nodeMap.ClearBit(index)
+				newNode.dataMap = newNode.dataMap.SetBit(index)
+			} else if isEmptyNode(subNode) {
+				newNode.nodeMap = newNode.nodeMap.ClearBit(index)
+			} else {
+				newNode.entries[index] = subNode
+			}
+		case n.level == 6 && e != nil:
+			cNode := e.(*collisionNode)
+			kept := make([]Entry, 0, len(cNode.entries))
+			for _, ce := range cNode.entries {
+				if keep(ce) {
+					kept = append(kept, ce)
+				}
+			}
+			switch len(kept) {
+			case 0:
+			case 1:
+				newNode.entries[index] = kept[0]
+				newNode.dataMap = newNode.dataMap.SetBit(index)
+			default:
+				newNode.entries[index] = &collisionNode{entries: kept}
+			}
+		}
+	}
+
+	return newNode
+}
+
+// isEmptyNode returns true if n holds no entries, sub-nodes or
+// collision nodes.
+func isEmptyNode(n *node) bool {
+	for _, e := range n.entries {
+		if e != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// soleEntry returns the entry held by n if it holds exactly one entry
+// and no sub-nodes or collision nodes.
+func soleEntry(n *node) (Entry, bool) {
+	if n.nodeMap.PopCount() != 0 || n.dataMap.PopCount() != 1 {
+		return nil, false
+	}
+	var sole Entry
+	for i, e := range n.entries {
+		if e == nil {
+			continue
+		}
+		if !n.dataMap.GetBit(uint(i)) || sole != nil {
+			return nil, false
+		}
+		sole = e
+	}
+	return sole, true
+}
+
 func iterate(n *node, stop <-chan struct{}) <-chan Entry {
 	out := make(chan Entry)
 	go func() {
@@ -232,3 +328,44 @@ func pushEntries(n *node, stop <-chan struct{}, out chan Entry) {
 		}
 	}
 }
+
+// walk calls fn for every entry beneath n until fn returns false.  The
+// return value reports whether the walk visited every entry.
+func walk(n *node, fn func(Entry) bool) bool {
+	for i, e := range n.entries {
+		index := uint(i)
+		switch {
+		case n.dataMap.GetBit(index):
+			if !fn(e) {
+				return false
+			}
+		case n.nodeMap.GetBit(index):
+			if !walk(e.(*node), fn) {
+				return false
+			}
+		case n.level == 6 && e != nil:
+			for _, ce := range e.(*collisionNode).entries {
+				if !fn(ce) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// countNodes returns the number of nodes and collision nodes in the
+// trie rooted at n, including n itself.
+func countNodes(n *node) int {
+	count := 1
+	for i, e := range n.entries {
+		index := uint(i)
+		switch {
+		case n.nodeMap.GetBit(index):
+			count += countNodes(e.(*node))
+		case n.level == 6 && e != nil && !n.dataMap.GetBit(index):
+			count++
+		}
+	}
+	return count
+}
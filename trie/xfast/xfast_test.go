@@ -522,6 +522,124 @@ func TestDeleteNotFound(t *testing.T) {
 	checkTrie(t, xft)
 }
 
+// checkSameStructure asserts that the subtrees rooted at expected and
+// actual have identical shape, keys, and threads.
+func checkSameStructure(t *testing.T, expected, actual *node) {
+	if expected == nil || actual == nil {
+		assert.True(t, expected == nil && actual == nil)
+		return
+	}
+
+	if isLeaf(expected) || isLeaf(actual) {
+		if assert.True(t, isLeaf(expected) && isLeaf(actual)) {
+			assert.Equal(t, expected.entry.Key(), actual.entry.Key())
+		}
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		ec, ac := expected.children[i], actual.children[i]
+		if ec != nil && ec.parent == expected {
+			if assert.True(t, ac != nil && ac.parent == actual) {
+				checkSameStructure(t, ec, ac)
+			}
+			continue
+		}
+		// this is a thread, which is nil or points to a leaf
+		checkSameStructure(t, ec, ac)
+	}
+}
+
+func TestNewFromSorted(t *testing.T) {
+	keys := []uint64{0, 1, 2, 5, 7, 8, 20, 21, 64, 100, 127, 128, 200, 255}
+	entries := make(Entries, 0, len(keys))
+	expected := New(uint8(0))
+	for _, key := range keys {
+		e := newMockEntry(key)
+		entries = append(entries, e)
+		expected.Insert(e)
+	}
+
+	xft, err := NewFromSorted(uint8(0), entries...)
+	assert.Nil(t, err)
+	checkTrie(t, xft)
+	checkSameStructure(t, expected.root, xft.root)
+	assert.Equal(t, uint64(len(keys)), xft.Len())
+	assert.Equal(t, entries[0], xft.Min())
+	assert.Equal(t, entries[len(entries)-1], xft.Max())
+	for i := range expected.layers {
+		assert.Len(t, xft.layers[i], len(expected.layers[i]))
+	}
+
+	for key := uint64(0); key < 256; key++ {
+		assert.Equal(t, expected.Successor(key), xft.Successor(key))
+		assert.Equal(t, expected.Predecessor(key), xft.Predecessor(key))
+	}
+
+	// the trie must remain fully functional
+	xft.Delete(5, 128)
+	xft.Insert(newMockEntry(6))
+	checkTrie(t, xft)
+	assert.Equal(t, uint64(7), xft.Successor(6+1).Key())
+	assert.Equal(t, uint64(6), xft.Predecessor(6).Key())
+}
+
+func TestNewFromSortedSparse(t *testing.T) {
+	keys := []uint64{3, 1 << 20, 1<<20 + 1, 1 << 40, math.MaxUint64 - 1}
+	entries := make(Entries, 0, len(keys))
+	expected := New(uint64(0))
+	for _, key := range keys {
+		e := newMockEntry(key)
+		entries = append(entries, e)
+		expected.Insert(e)
+	}
+
+	xft, err := NewFromSorted(uint64(0), entries...)
+	assert.Nil(t, err)
+	checkTrie(t, xft)
+	checkSameStructure(t, expected.root, xft.root)
+}
+
+func TestNewFromSortedDuplicates(t *testing.T) {
+	e1, e2, e3 := newMockEntry(1), newMockEntry(2), newMockEntry(2)
+	xft, err := NewFromSorted(uint8(0), e1, e2, e3)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), xft.Len())
+	assert.Equal(t, e3, xft.Get(2))
+	checkTrie(t, xft)
+}
+
+func TestNewFromSortedEmpty(t *testing.T) {
+	xft, err := NewFromSorted(uint8(0))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), xft.Len())
+	assert.Nil(t, xft.Min())
+	assert.Nil(t, xft.Successor(0))
+
+	xft.Insert(newMockEntry(5))
+	assert.Equal(t, uint64(5), xft.Min().Key())
+}
+
+func TestNewFromSortedUnsorted(t *testing.T) {
+	xft, err := NewFromSorted(uint8(0), newMockEntry(2), newMockEntry(1))
+	assert.Equal(t, ErrUnsorted, err)
+	assert.Nil(t, xft)
+}
+
+func TestCountRange(t *testing.T) {
+	xft := New(uint8(0))
+	assert.Equal(t, uint64(0), xft.CountRange(0, 255))
+
+	xft.Insert(newMockEntry(5), newMockEntry(10), newMockEntry(20), newMockEntry(255))
+	assert.Equal(t, uint64(4), xft.CountRange(0, 255))
+	assert.Equal(t, uint64(2), xft.CountRange(5, 10))
+	assert.Equal(t, uint64(2), xft.CountRange(6, 20))
+	assert.Equal(t, uint64(1), xft.CountRange(255, 255))
+	assert.Equal(t, uint64(0), xft.CountRange(11, 19))
+	assert.Equal(t, uint64(0), xft.CountRange(20, 10))
+	assert.Equal(t, uint64(1), xft.CountRange(0, 5))
+}
+
 func BenchmarkSuccessor(b *testing.B) {
 	numItems := 10000
 	xft := New(uint64(0))
@@ -563,6 +681,34 @@ func BenchmarkInsert(b *testing.B) {
 	}
 }
 
+func BenchmarkNewFromSorted(b *testing.B) {
+	numItems := 10000
+	entries := make(Entries, 0, numItems)
+	for i := 0; i < numItems; i++ {
+		entries = append(entries, newMockEntry(uint64(i)))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		NewFromSorted(uint64(0), entries...)
+	}
+}
+
+func BenchmarkBulkInsert(b *testing.B) {
+	numItems := 10000
+	entries := make(Entries, 0, numItems)
+	for i := 0; i < numItems; i++ {
+		entries = append(entries, newMockEntry(uint64(i)))
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		New(uint64(0)).Insert(entries...)
+	}
+}
+
 // benchmarked against a flat list
 func BenchmarkListInsert(b *testing.B) {
 	numItems := 100000
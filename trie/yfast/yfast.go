@@ -36,7 +36,11 @@ aid in multithreading these functions for performance optimization.
 */
 package yfast
 
-import "github.com/Workiva/go-datastructures/trie/xfast"
+import (
+	"math"
+
+	"github.com/Workiva/go-datastructures/trie/xfast"
+)
 
 // YFastTrie implements all the methods available to the y-fast
 // trie datastructure.  The top half is composed of an x-fast trie
@@ -185,7 +189,24 @@ func (yfast *YFastTrie) successor(key uint64) Entry {
 		return nil
 	}
 
-	entry, _ := bundle.(*entriesWrapper).entries.successor(key)
+	ew := bundle.(*entriesWrapper)
+	entry, _ := ew.entries.successor(key)
+	if entry != nil {
+		return entry
+	}
+
+	// every entry in this bucket precedes the key so the successor is
+	// the first entry in the next bucket
+	if ew.key == math.MaxUint64 {
+		return nil
+	}
+
+	bundle = yfast.xfast.Successor(ew.key + 1)
+	if bundle == nil {
+		return nil
+	}
+
+	entry, _ = bundle.(*entriesWrapper).entries.successor(key)
 	if entry == nil {
 		return nil
 	}
@@ -194,8 +215,9 @@ func (yfast *YFastTrie) successor(key uint64) Entry {
 }
 
 // Successor returns an Entry with a key equal to or immediately
-// greater than the provided key.  If such an Entry does not exist
-// this returns nil.
+// greater than the provided key.  The Entry returned is the one that
+// was inserted, so any value it carries is available to the caller.
+// If such an Entry does not exist this returns nil.
 func (yfast *YFastTrie) Successor(key uint64) Entry {
 	entry := yfast.successor(key)
 	if entry == nil {
@@ -237,8 +259,9 @@ func (yfast *YFastTrie) predecessor(key uint64) Entry {
 }
 
 // Predecessor returns an Entry with a key equal to or immediately
-// preceeding than the provided key.  If such an Entry does not exist
-// this returns nil.
+// preceeding than the provided key.  The Entry returned is the one that
+// was inserted, so any value it carries is available to the caller.
+// If such an Entry does not exist this returns nil.
 func (yfast *YFastTrie) Predecessor(key uint64) Entry {
 	entry := yfast.predecessor(key)
 	if entry == nil {
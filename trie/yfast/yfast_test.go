@@ -157,6 +157,39 @@ func TestTriePredecessor(t *testing.T) {
 	assert.Equal(t, e2, predecessor)
 }
 
+type valueEntry struct {
+	key   uint64
+	value string
+}
+
+func (ve *valueEntry) Key() uint64 {
+	return ve.key
+}
+
+func TestTrieNeighborValues(t *testing.T) {
+	yfast := New(uint16(0))
+	yfast.Insert(
+		&valueEntry{key: 10, value: `ten`},
+		&valueEntry{key: 300, value: `three hundred`},
+		&valueEntry{key: 5000, value: `five thousand`},
+	)
+
+	assert.Equal(t, `ten`, yfast.Successor(0).(*valueEntry).value)
+	assert.Equal(t, `three hundred`, yfast.Successor(11).(*valueEntry).value)
+	assert.Equal(t, `five thousand`, yfast.Successor(301).(*valueEntry).value)
+	assert.Nil(t, yfast.Successor(5001))
+
+	assert.Equal(t, `five thousand`, yfast.Predecessor(60000).(*valueEntry).value)
+	assert.Equal(t, `three hundred`, yfast.Predecessor(4999).(*valueEntry).value)
+	assert.Equal(t, `ten`, yfast.Predecessor(299).(*valueEntry).value)
+	assert.Nil(t, yfast.Predecessor(9))
+
+	// the most recently inserted entry for a key is returned
+	yfast.Insert(&valueEntry{key: 300, value: `replaced`})
+	assert.Equal(t, `replaced`, yfast.Successor(11).(*valueEntry).value)
+	assert.Equal(t, `replaced`, yfast.Predecessor(4999).(*valueEntry).value)
+}
+
 func TestTrieIterator(t *testing.T) {
 	yfast := New(uint8(0))
 